@@ -23,6 +23,7 @@ import (
 	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func Test_CreateThenReadArchive(t *testing.T) {
@@ -340,6 +341,14 @@ func Test_CreateThenReadArchiveUsingTags(t *testing.T) {
 		t.Fatalf("Expected message: %s, actual: %s", expectedMessageBytes, messageBytes)
 	}
 
+	messageBytes, err = ar.LoadRaw(TagSpecial("message"))
+	if err != nil {
+		t.Fatalf("Failed to load message: %s", err)
+	}
+	if !bytes.Equal(messageBytes, expectedMessageBytes) {
+		t.Fatalf("Expected message: %s, actual: %s", expectedMessageBytes, messageBytes)
+	}
+
 	uniqueAccountTags := ar.accountTags
 	if len(uniqueAccountTags) != 1 {
 		t.Fatalf("Expected 1 accounts, got %d: %v", len(uniqueAccountTags), uniqueAccountTags)
@@ -489,6 +498,211 @@ func Test_IterateResourcesUsingTags(t *testing.T) {
 	}
 }
 
+func Test_ReadSnapshots(t *testing.T) {
+	type DummyRecord struct {
+		Server   string
+		Snapshot int
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := NewWriter(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %s", err)
+	}
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.Local)
+	snapshots := []time.Time{start, start.Add(30 * time.Second), start.Add(time.Minute)}
+
+	for i, snapshot := range snapshots {
+		for _, serverName := range []string{"A", "B"} {
+			err = aw.Add(&DummyRecord{Server: serverName, Snapshot: i}, TagCluster("C1"), TagServer(serverName), TagServerVars(), TagSnapshot(snapshot))
+			if err != nil {
+				t.Fatalf("Failed to add artifact: %s", err)
+			}
+		}
+	}
+	err = aw.Add(&DummyRecord{Server: "A"}, TagCluster("C1"), TagServer("A"), TagServerHealth())
+	if err != nil {
+		t.Fatalf("Failed to add artifact: %s", err)
+	}
+
+	err = aw.Close()
+	if err != nil {
+		t.Fatalf("Error closing writer: %s", err)
+	}
+
+	ar, err := NewReader(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %s", err)
+	}
+	defer ar.Close()
+
+	readSnapshots := ar.GetSnapshots()
+	if len(readSnapshots) != len(snapshots) {
+		t.Fatalf("Expected %d snapshots, actual: %v", len(snapshots), readSnapshots)
+	}
+	for i, snapshot := range snapshots {
+		if !readSnapshots[i].Equal(snapshot) {
+			t.Fatalf("Expected snapshot %d to be %s, actual: %s", i, snapshot, readSnapshots[i])
+		}
+	}
+
+	if !slices.Equal(ar.GetClusterServerNames("C1"), []string{"A", "B"}) {
+		t.Fatalf("Unexpected servers: %v", ar.GetClusterServerNames("C1"))
+	}
+
+	// Without a snapshot tag, the most recent snapshot is loaded
+	var record DummyRecord
+	err = ar.Load(&record, TagServer("B"), TagServerVars())
+	if err != nil {
+		t.Fatalf("Failed to load artifact: %s", err)
+	}
+	if record.Snapshot != 2 {
+		t.Fatalf("Expected latest snapshot, actual: %d", record.Snapshot)
+	}
+
+	for i, snapshot := range readSnapshots {
+		err = ar.Load(&record, TagServer("A"), TagServerVars(), TagSnapshot(snapshot))
+		if err != nil {
+			t.Fatalf("Failed to load artifact for snapshot %s: %s", snapshot, err)
+		}
+		if record.Snapshot != i {
+			t.Fatalf("Expected snapshot %d, actual: %d", i, record.Snapshot)
+		}
+	}
+
+	// Artifacts that are not part of a snapshot are loaded as usual
+	err = ar.Load(&record, TagServer("A"), TagServerHealth())
+	if err != nil {
+		t.Fatalf("Failed to load artifact: %s", err)
+	}
+
+	// Mixing artifacts in and out of snapshots is ambiguous
+	err = ar.Load(&record, TagServer("A"))
+	if !errors.Is(err, ErrMultipleMatches) {
+		t.Fatalf("Expected error: %s, actual: %v", ErrMultipleMatches, err)
+	}
+}
+
+func Test_ReadIncompleteArchive(t *testing.T) {
+	type DummyRecord struct {
+		Server string
+	}
+
+	clusterServerMap := map[string][]string{
+		"C1": {"A", "B", "C"},
+		"C2": {"X", "Y", "Z"},
+	}
+
+	writeArtifacts := func(t *testing.T, aw *Writer) {
+		t.Helper()
+		for clusterName, serverNames := range clusterServerMap {
+			for _, serverName := range serverNames {
+				err := aw.Add(&DummyRecord{Server: serverName}, TagCluster(clusterName), TagServer(serverName), TagServerHealth())
+				if err != nil {
+					t.Fatalf("Failed to add artifact: %s", err)
+				}
+			}
+		}
+	}
+
+	verifyArtifacts := func(t *testing.T, archivePath string) {
+		t.Helper()
+		ar, err := NewReader(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to open archive: %s", err)
+		}
+		defer ar.Close()
+
+		if !ar.Recovered() {
+			t.Fatalf("Expected archive to be recovered")
+		}
+
+		for clusterName, serverNames := range clusterServerMap {
+			if !slices.Equal(serverNames, ar.GetClusterServerNames(clusterName)) {
+				t.Fatalf("Expected cluster %s servers: %v, actual: %v", clusterName, serverNames, ar.GetClusterServerNames(clusterName))
+			}
+			for _, serverName := range serverNames {
+				var r DummyRecord
+				err := ar.Load(&r, TagCluster(clusterName), TagServer(serverName), TagServerHealth())
+				if err != nil {
+					t.Fatalf("Failed to load artifact for server %s: %s", serverName, err)
+				}
+				if r.Server != serverName {
+					t.Fatalf("Unexpected value '%s' (should be: '%s')", r.Server, serverName)
+				}
+			}
+		}
+	}
+
+	t.Run("missing manifest", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		aw, err := NewWriter(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to create archive: %s", err)
+		}
+		writeArtifacts(t, aw)
+
+		// Close the zip without adding the manifest
+		err = aw.zipWriter.Close()
+		if err != nil {
+			t.Fatalf("Failed to close zip writer: %s", err)
+		}
+		err = aw.fileWriter.Close()
+		if err != nil {
+			t.Fatalf("Failed to close file writer: %s", err)
+		}
+
+		verifyArtifacts(t, archivePath)
+	})
+
+	t.Run("interrupted writer", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		aw, err := NewWriter(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to create archive: %s", err)
+		}
+		writeArtifacts(t, aw)
+
+		// Add one more artifact, then truncate the file half-way through it, as if the process was killed
+		fileInfo, err := aw.fileWriter.Stat()
+		if err != nil {
+			t.Fatalf("Failed to get archive stats: %s", err)
+		}
+		sizeBeforeLastArtifact := fileInfo.Size()
+		err = aw.Add(&DummyRecord{Server: "S"}, TagCluster("C3"), TagServer("S"), TagServerHealth())
+		if err != nil {
+			t.Fatalf("Failed to add artifact: %s", err)
+		}
+		fileInfo, err = aw.fileWriter.Stat()
+		if err != nil {
+			t.Fatalf("Failed to get archive stats: %s", err)
+		}
+		err = aw.fileWriter.Truncate((sizeBeforeLastArtifact + fileInfo.Size()) / 2)
+		if err != nil {
+			t.Fatalf("Failed to truncate archive: %s", err)
+		}
+		err = aw.fileWriter.Close()
+		if err != nil {
+			t.Fatalf("Failed to close file writer: %s", err)
+		}
+
+		verifyArtifacts(t, archivePath)
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		err := os.WriteFile(archivePath, []byte{}, 0600)
+		if err != nil {
+			t.Fatalf("Failed to create file: %s", err)
+		}
+		_, err = NewReader(archivePath)
+		if err == nil {
+			t.Fatalf("Expected error opening empty archive")
+		}
+	})
+}
+
 // TODO test writer overwrites existing file
 // TODO test creation in non-existing directory fails
 // TODO test adding twice a file with the same name (or tags)
@@ -0,0 +1,338 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Query is a boolean expression over artifact tags, used to select artifacts in an archive.
+//
+// A query is composed of terms in the form `label:value`, for example `server:n1` or `artifact_type:stream_info`.
+// Values may contain `*` wildcards, matching any sequence of characters (e.g. `server:n1-*`).
+// Values containing spaces or parentheses can be double-quoted (e.g. `account:"my account"`).
+// Terms are combined with the operators `and`, `or`, `not` (case-insensitive) and grouped with parentheses.
+// Adjacent terms without an operator are combined with `and`. `not` binds tighter than `and`, which binds tighter
+// than `or`.
+//
+// Example: `artifact_type:stream_info and (account:A or account:B) and not server:n1-*`
+type Query struct {
+	text string
+	root queryNode
+}
+
+// queryNode is a node in the query expression tree
+type queryNode interface {
+	matches(tags []Tag) bool
+}
+
+type queryTerm struct {
+	label   TagLabel
+	pattern string
+}
+
+type queryAnd struct {
+	left, right queryNode
+}
+
+type queryOr struct {
+	left, right queryNode
+}
+
+type queryNot struct {
+	operand queryNode
+}
+
+func (t *queryTerm) matches(tags []Tag) bool {
+	for _, tag := range tags {
+		if tag.Name == t.label && wildcardMatch(t.pattern, tag.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *queryAnd) matches(tags []Tag) bool {
+	return n.left.matches(tags) && n.right.matches(tags)
+}
+
+func (n *queryOr) matches(tags []Tag) bool {
+	return n.left.matches(tags) || n.right.matches(tags)
+}
+
+func (n *queryNot) matches(tags []Tag) bool {
+	return !n.operand.matches(tags)
+}
+
+// queryLabelAliases are shorthands accepted in place of the full tag label
+var queryLabelAliases = map[string]TagLabel{
+	"type":    typeTagLabel,
+	"profile": profileNameTagLabel,
+}
+
+// queryLabels are the tag labels that can be used in a query
+var queryLabels = map[TagLabel]struct{}{
+	serverTagLabel:      {},
+	clusterTagLabel:     {},
+	accountTagLabel:     {},
+	streamTagLabel:      {},
+	typeTagLabel:        {},
+	profileNameTagLabel: {},
+	snapshotTagLabel:    {},
+	specialTagLabel:     {},
+}
+
+// ParseQuery parses the given expression into a Query
+func ParseQuery(expression string) (*Query, error) {
+	tokens, err := tokenizeQuery(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty query")
+	}
+
+	p := &queryParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", p.peek().text, p.peek().position)
+	}
+
+	return &Query{
+		text: expression,
+		root: root,
+	}, nil
+}
+
+// Matches returns true if the given set of tags satisfies the query
+func (q *Query) Matches(tags []Tag) bool {
+	return q.root.matches(tags)
+}
+
+// String returns the expression this query was parsed from
+func (q *Query) String() string {
+	return q.text
+}
+
+type queryTokenKind int
+
+const (
+	queryTokenWord queryTokenKind = iota
+	queryTokenOpenParen
+	queryTokenCloseParen
+)
+
+type queryToken struct {
+	kind     queryTokenKind
+	text     string
+	position int
+}
+
+// tokenizeQuery splits a query expression into words and parentheses.
+// Double-quoted sections of a word can contain whitespace and parentheses.
+func tokenizeQuery(expression string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, queryToken{queryTokenOpenParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, queryToken{queryTokenCloseParen, ")", i})
+			i++
+		default:
+			start := i
+			var word strings.Builder
+			quoted := false
+			for ; i < len(runes); i++ {
+				r = runes[i]
+				if r == '"' {
+					quoted = !quoted
+					continue
+				}
+				if !quoted && (unicode.IsSpace(r) || r == '(' || r == ')') {
+					break
+				}
+				word.WriteRune(r)
+			}
+			if quoted {
+				return nil, fmt.Errorf("unterminated quote at position %d", start)
+			}
+			tokens = append(tokens, queryToken{queryTokenWord, word.String(), start})
+		}
+	}
+
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	next   int
+}
+
+func (p *queryParser) done() bool {
+	return p.next >= len(p.tokens)
+}
+
+func (p *queryParser) peek() queryToken {
+	return p.tokens[p.next]
+}
+
+// peekKeyword returns true if the next token is the given (case-insensitive) keyword
+func (p *queryParser) peekKeyword(keyword string) bool {
+	return !p.done() && p.peek().kind == queryTokenWord && strings.EqualFold(p.peek().text, keyword)
+}
+
+func (p *queryParser) parseOr() (queryNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peekKeyword("or") {
+		p.next++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &queryOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseAnd() (queryNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() && p.peek().kind != queryTokenCloseParen && !p.peekKeyword("or") {
+		// Explicit 'and' is optional
+		if p.peekKeyword("and") {
+			p.next++
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &queryAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *queryParser) parseNot() (queryNode, error) {
+	if p.peekKeyword("not") {
+		p.next++
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &queryNot{operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *queryParser) parsePrimary() (queryNode, error) {
+	if p.done() {
+		return nil, fmt.Errorf("unexpected end of query")
+	}
+
+	token := p.peek()
+	p.next++
+
+	switch token.kind {
+	case queryTokenOpenParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != queryTokenCloseParen {
+			return nil, fmt.Errorf("missing ')' for '(' at position %d", token.position)
+		}
+		p.next++
+		return node, nil
+
+	case queryTokenCloseParen:
+		return nil, fmt.Errorf("unexpected ')' at position %d", token.position)
+
+	default:
+		for _, keyword := range []string{"and", "or", "not"} {
+			if strings.EqualFold(token.text, keyword) {
+				return nil, fmt.Errorf("unexpected '%s' at position %d", token.text, token.position)
+			}
+		}
+		return parseQueryTerm(token)
+	}
+}
+
+// parseQueryTerm parses a `label:value` term
+func parseQueryTerm(token queryToken) (queryNode, error) {
+	labelString, pattern, found := strings.Cut(token.text, ":")
+	if !found {
+		return nil, fmt.Errorf("invalid term '%s' at position %d, expected 'label:value'", token.text, token.position)
+	}
+
+	label := TagLabel(labelString)
+	if alias, isAlias := queryLabelAliases[labelString]; isAlias {
+		label = alias
+	}
+	if _, known := queryLabels[label]; !known {
+		return nil, fmt.Errorf("unknown tag label '%s' at position %d", labelString, token.position)
+	}
+
+	if pattern == "" {
+		return nil, fmt.Errorf("missing value for '%s' at position %d", labelString, token.position)
+	}
+
+	return &queryTerm{
+		label:   label,
+		pattern: pattern,
+	}, nil
+}
+
+// wildcardMatch returns true if the value matches the pattern, where `*` in the pattern matches any sequence of
+// characters (including none). There are no other special characters.
+func wildcardMatch(pattern, value string) bool {
+	// Split into literal parts separated by wildcards
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+
+	// First part must be a prefix, last part must be a suffix
+	first, last := parts[0], parts[len(parts)-1]
+	if !strings.HasPrefix(value, first) {
+		return false
+	}
+	value = value[len(first):]
+
+	// Middle parts must appear in order, match each as early as possible
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(value, part)
+		if index < 0 {
+			return false
+		}
+		value = value[index+len(part):]
+	}
+
+	return strings.HasSuffix(value, last)
+}
@@ -0,0 +1,175 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func Test_ParseAndMatchQuery(t *testing.T) {
+	streamInfoTags := []Tag{
+		*TagAccount("A"),
+		*TagCluster("C1"),
+		*TagServer("n1-east"),
+		*TagStream("ORDERS"),
+		*TagStreamInfo(),
+	}
+
+	tests := []struct {
+		query   string
+		matches bool
+		wantErr bool
+	}{
+		{"server:n1-east", true, false},
+		{"server:n2-east", false, false},
+		{"server:n1-*", true, false},
+		{"server:*-east", true, false},
+		{"server:n*e*t", true, false},
+		{"server:*", true, false},
+		{"server:n1", false, false},
+		{"type:stream_info", true, false},
+		{"artifact_type:stream_info", true, false},
+		{"account:A stream:ORDERS", true, false},
+		{"account:A and stream:ORDERS", true, false},
+		{"account:A AND stream:OTHER", false, false},
+		{"account:B or stream:ORDERS", true, false},
+		{"account:B or stream:OTHER", false, false},
+		{"not account:B", true, false},
+		{"NOT account:A", false, false},
+		{"account:A and not (server:n2-* or server:n3-*)", true, false},
+		{"not not account:A", true, false},
+		{"account:B or account:A and stream:OTHER", false, false},
+		{"(account:B or account:A) and stream:ORDERS", true, false},
+		{"profile_name:*", false, false},
+		{`account:"A"`, true, false},
+		{"", false, true},
+		{"server", false, true},
+		{"server:", false, true},
+		{"foo:bar", false, true},
+		{"(server:n1-east", false, true},
+		{"server:n1-east)", false, true},
+		{"server:n1-east and", false, true},
+		{"or server:n1-east", false, true},
+		{`account:"A`, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			query, err := ParseQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseQuery() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if matches := query.Matches(streamInfoTags); matches != tt.matches {
+				t.Fatalf("Matches() = %v, want %v", matches, tt.matches)
+			}
+		})
+	}
+}
+
+func Test_WildcardMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		value   string
+		want    bool
+	}{
+		{"abc", "abc", true},
+		{"abc", "abcd", false},
+		{"*", "", true},
+		{"a*", "a", true},
+		{"a*a", "a", false},
+		{"a*a", "aa", true},
+		{"*b*", "abc", true},
+		{"a*b*c", "aXbYc", true},
+		{"a*b*c", "aXcYb", false},
+		{"**", "x", true},
+	}
+
+	for _, tt := range tests {
+		if got := wildcardMatch(tt.pattern, tt.value); got != tt.want {
+			t.Fatalf("wildcardMatch(%q, %q) = %v, want %v", tt.pattern, tt.value, got, tt.want)
+		}
+	}
+}
+
+func Test_QueryArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := NewWriter(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %s", err)
+	}
+
+	for _, serverName := range []string{"n1-east", "n2-east", "n1-west"} {
+		err = aw.Add(serverName, TagCluster("C1"), TagServer(serverName), TagServerHealth())
+		if err != nil {
+			t.Fatalf("Failed to add artifact: %s", err)
+		}
+		err = aw.Add(serverName, TagCluster("C1"), TagServer(serverName), TagServerVars())
+		if err != nil {
+			t.Fatalf("Failed to add artifact: %s", err)
+		}
+	}
+
+	err = aw.Close()
+	if err != nil {
+		t.Fatalf("Error closing writer: %s", err)
+	}
+
+	ar, err := NewReader(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to open archive: %s", err)
+	}
+	defer ar.Close()
+
+	query, err := ParseQuery("type:health and server:n1-*")
+	if err != nil {
+		t.Fatalf("Failed to parse query: %s", err)
+	}
+
+	expectedNames := []string{
+		"capture/clusters/C1/n1-east/health.json",
+		"capture/clusters/C1/n1-west/health.json",
+	}
+	names := ar.Query(query)
+	if !slices.Equal(expectedNames, names) {
+		t.Fatalf("Expected artifacts: %v, got: %v", expectedNames, names)
+	}
+
+	for _, name := range names {
+		var serverName string
+		err = ar.LoadArtifact(&serverName, name)
+		if err != nil {
+			t.Fatalf("Failed to load artifact %s: %s", name, err)
+		}
+		tags, err := ar.GetArtifactTags(name)
+		if err != nil {
+			t.Fatalf("Failed to get artifact %s tags: %s", name, err)
+		}
+		if !slices.Contains(tags, *TagServer(serverName)) {
+			t.Fatalf("Artifact %s tags %v do not include server %s", name, tags, serverName)
+		}
+	}
+
+	if len(ar.ListArtifacts()) != 6 {
+		t.Fatalf("Expected 6 artifacts, got: %v", ar.ListArtifacts())
+	}
+
+	if _, err = ar.ReadArtifact("capture/does/not/exist.json"); err == nil {
+		t.Fatalf("Expected error reading non-existent artifact")
+	}
+}
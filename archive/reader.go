@@ -15,25 +15,32 @@ package archive
 
 import (
 	"archive/zip"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"slices"
 	"strings"
+	"time"
 )
 
 // Reader encapsulates a reader for the actual underlying archive, and also provides indices for faster and
 // more convenient iteration and querying of the archive content
 type Reader struct {
-	archiveReader       *zip.ReadCloser
+	archiveReader       *zip.Reader
+	archiveCloser       io.Closer
 	path                string
+	recovered           bool
+	signer              string
 	filesMap            map[string]*zip.File
 	manifestMap         map[string][]Tag
 	accountTags         []Tag
 	clusterTags         []Tag
 	serverTags          []Tag
 	streamTags          []Tag
+	snapshots           []time.Time
 	accountNames        []string
 	clusterNames        []string
 	clustersServerNames map[string][]string
@@ -47,9 +54,10 @@ func (r *Reader) rawFilesCount() int {
 
 // Close closes the reader
 func (r *Reader) Close() error {
-	if r.archiveReader != nil {
-		err := r.archiveReader.Close()
-		r.archiveReader = nil
+	r.archiveReader = nil
+	if r.archiveCloser != nil {
+		err := r.archiveCloser.Close()
+		r.archiveCloser = nil
 		return err
 	}
 	return nil
@@ -74,6 +82,7 @@ func (r *Reader) loadFile(name string, v any) error {
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 	decoder := json.NewDecoder(f)
 	err = decoder.Decode(v)
 	if err != nil {
@@ -91,8 +100,32 @@ var ErrMultipleMatches = fmt.Errorf("multiple files matched the given query")
 // Load queries the indices for a single artifact matching the given input tags.
 // If a single artifact is found, then it is deserialized into v
 // If multiple artifact or no artifacts match the input tag, then ErrMultipleMatches and ErrNoMatches are returned
-// respectively
+// respectively.
+// In archives with multiple snapshots, artifacts of the most recent snapshot are loaded, unless a snapshot is selected
+// with TagSnapshot
 func (r *Reader) Load(v any, queryTags ...*Tag) error {
+	matchedFileName, err := r.findArtifact(queryTags)
+	if err != nil {
+		return err
+	}
+
+	// Unmarshall it into v
+	return r.loadFile(matchedFileName, v)
+}
+
+// LoadRaw queries the indices for a single artifact matching the given input tags, like Load, and returns its content
+// as-is. Use it for artifacts that are not JSON, like server profiles.
+func (r *Reader) LoadRaw(queryTags ...*Tag) ([]byte, error) {
+	matchedFileName, err := r.findArtifact(queryTags)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.ReadArtifact(matchedFileName)
+}
+
+// findArtifact returns the name of the single artifact matching the given tags, see Load
+func (r *Reader) findArtifact(queryTags []*Tag) (string, error) {
 	// TODO build and use inverted index
 	// This method scans the entire manifest every time. Ok for now, but may get noticeably slow for very
 	// large archives, or large number of checks.
@@ -124,81 +157,135 @@ manifestSearchLoop:
 		continue manifestSearchLoop
 	}
 
+	// In archives with multiple snapshots, the same query may match one artifact in each snapshot.
+	// Unless the query selects a snapshot, use the most recent one.
+	if len(matchedFileNames) > 1 {
+		matchedFileNames = r.latestSnapshotArtifacts(matchedFileNames)
+	}
+
 	if len(matchedFileNames) < 1 {
-		return ErrNoMatches
+		return "", ErrNoMatches
 	} else if len(matchedFileNames) > 1 {
-		return ErrMultipleMatches
+		return "", ErrMultipleMatches
 	}
 
 	// A single file matched
-	matchedFileName := matchedFileNames[0]
+	return matchedFileNames[0], nil
+}
 
-	// Unmarshall it into v
-	return r.loadFile(matchedFileName, v)
+// latestSnapshotArtifacts filters the given artifacts, keeping those of the most recent snapshot.
+// If any of the artifacts is not part of a snapshot, the list is returned unchanged.
+func (r *Reader) latestSnapshotArtifacts(fileNames []string) []string {
+	snapshotValues := make(map[string]string, len(fileNames))
+	latest := ""
+	for _, fileName := range fileNames {
+		for _, tag := range r.manifestMap[fileName] {
+			if tag.Name == snapshotTagLabel {
+				snapshotValues[fileName] = tag.Value
+				latest = max(latest, tag.Value)
+			}
+		}
+		if _, inSnapshot := snapshotValues[fileName]; !inSnapshot {
+			return fileNames
+		}
+	}
+
+	latestFileNames := make([]string, 0, 1)
+	for _, fileName := range fileNames {
+		if snapshotValues[fileName] == latest {
+			latestFileNames = append(latestFileNames, fileName)
+		}
+	}
+	return latestFileNames
 }
 
-// NewReader creates a new reader for the file at the given archivePath.
-// Reader expect the file to comply to format and content created by a Writer in this same package.
-// During creation, Reader creates in-memory indices to speed up subsequent queries.
-func NewReader(archivePath string) (*Reader, error) {
-	// Create a zip reader
-	archiveReader, err := zip.OpenReader(archivePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open archive: %w", err)
+// Query lists the names of all artifacts whose tags satisfy the given query.
+// The list of names is sorted alphabetically
+func (r *Reader) Query(query *Query) []string {
+	matchedFileNames := make([]string, 0)
+	for fileName, fileTags := range r.manifestMap {
+		if query.Matches(fileTags) {
+			matchedFileNames = append(matchedFileNames, fileName)
+		}
 	}
+	slices.Sort(matchedFileNames)
+	return matchedFileNames
+}
 
-	// Create map of filename -> file
-	filesMap := make(map[string]*zip.File, len(archiveReader.File))
-	for _, f := range archiveReader.File {
-		filesMap[f.Name] = f
+// ListArtifacts lists the names of all artifacts in the manifest.
+// The list of names is sorted alphabetically
+func (r *Reader) ListArtifacts() []string {
+	artifactNames := make([]string, 0, len(r.manifestMap))
+	for fileName := range r.manifestMap {
+		artifactNames = append(artifactNames, fileName)
 	}
+	slices.Sort(artifactNames)
+	return artifactNames
+}
 
-	// Find and open the manifest file
-	manifestFileName, err := createFilenameFromTags("json", []*Tag{internalTagManifest()})
-	if err != nil {
-		return nil, fmt.Errorf("failed to load manifest: %w", err)
+// GetArtifactTags returns the tags of the artifact with the given name
+func (r *Reader) GetArtifactTags(name string) ([]Tag, error) {
+	tags, present := r.manifestMap[name]
+	if !present {
+		return nil, os.ErrNotExist
 	}
+	return slices.Clone(tags), nil
+}
 
-	manifestFile, exists := filesMap[manifestFileName]
-	if !exists {
-		return nil, fmt.Errorf("manifest file not found in archive")
+// LoadArtifact deserializes the artifact with the given name into v
+func (r *Reader) LoadArtifact(v any, name string) error {
+	if _, present := r.manifestMap[name]; !present {
+		return os.ErrNotExist
 	}
+	return r.loadFile(name, v)
+}
 
-	manifestFileReader, err := manifestFile.Open()
+// ReadArtifact returns the content of the artifact with the given name as-is
+func (r *Reader) ReadArtifact(name string) ([]byte, error) {
+	if _, present := r.manifestMap[name]; !present {
+		return nil, os.ErrNotExist
+	}
+	f, _, err := r.getFileReader(name)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open manifest: %w", err)
+		return nil, err
 	}
-	defer manifestFileReader.Close()
+	defer f.Close()
+	return io.ReadAll(f)
+}
 
-	// Load manifest, which is a normalized index:
-	// For each file, a list of tags is present
-	manifestMap := make(map[string][]Tag, len(filesMap))
-	err = json.NewDecoder(manifestFileReader).Decode(&manifestMap)
+// NewReader creates a new reader for the file at the given archivePath.
+// Reader expect the file to comply to format and content created by a Writer in this same package.
+// During creation, Reader creates in-memory indices to speed up subsequent queries.
+// Options can be used to provide the key to open sealed archives, and to require a signature by a trusted key.
+// If the archive is signed, the signature is verified before reading.
+func NewReader(archivePath string, opts ...ReaderOption) (*Reader, error) {
+	content, err := openArchiveContent(archivePath, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load manifest: %w", err)
+		return nil, err
+	}
+
+	manifestMap := make(map[string][]Tag, len(content.manifest))
+	for fileName, entry := range content.manifest {
+		manifestMap[fileName] = entry.Tags
 	}
 
 	// Check that each file in the manifest exists in the archive
 	for fileName := range manifestMap {
-		_, present := filesMap[fileName]
+		_, present := content.filesMap[fileName]
 		if !present {
+			content.close()
 			return nil, fmt.Errorf("file %s is in manifest, but not present in archive", fileName)
 		}
 	}
 
 	// Check that each file in the archive is present in the manifest
-	manifestFilePath, err := createFilenameFromTags("json", []*Tag{internalTagManifest()})
-	if err != nil {
-		return nil, fmt.Errorf("failed to compose expected manifest path: %w", err)
-	}
-	for filePath := range filesMap {
-		switch filePath {
-		case manifestFilePath:
-			// Manifest is not present in manifest
-		default:
-			if _, present := manifestMap[filePath]; !present {
-				fmt.Printf("Warning: archive file %s is not present in manifest\n", filePath)
-			}
+	for filePath := range content.filesMap {
+		if isInternalFile(filePath) {
+			// Manifest and signature are not present in manifest
+			continue
+		}
+		if _, present := manifestMap[filePath]; !present {
+			fmt.Printf("Warning: archive file %s is not present in manifest\n", filePath)
 		}
 	}
 
@@ -290,15 +377,30 @@ func NewReader(archivePath string) (*Reader, error) {
 		return tagsList
 	}
 
+	snapshotTags := getUniqueTags(snapshotTagLabel)
+	snapshots := make([]time.Time, 0, len(snapshotTags))
+	for _, tag := range snapshotTags {
+		snapshot, err := time.Parse(snapshotTimeFormat, tag.Value)
+		if err != nil {
+			content.close()
+			return nil, fmt.Errorf("invalid snapshot tag '%s': %w", tag.Value, err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
 	return &Reader{
 		path:                archivePath,
-		archiveReader:       archiveReader,
-		filesMap:            filesMap,
+		archiveReader:       content.archiveReader,
+		archiveCloser:       content.archiveCloser,
+		recovered:           content.recovered,
+		signer:              content.signer,
+		filesMap:            content.filesMap,
 		manifestMap:         manifestMap,
 		accountTags:         getUniqueTags(accountTagLabel),
 		clusterTags:         getUniqueTags(clusterTagLabel),
 		serverTags:          getUniqueTags(serverTagLabel),
 		streamTags:          getUniqueTags(streamTagLabel),
+		snapshots:           snapshots,
 		accountNames:        accounts,
 		clusterNames:        clusters,
 		clustersServerNames: clusterServers,
@@ -307,6 +409,186 @@ func NewReader(archivePath string) (*Reader, error) {
 	}, nil
 }
 
+// archiveContent is an opened archive, with its files indexed by name and its manifest loaded (or recovered)
+type archiveContent struct {
+	archiveReader *zip.Reader
+	archiveCloser io.Closer
+	recovered     bool
+	signer        string
+	filesMap      map[string]*zip.File
+	manifest      map[string]manifestEntry
+}
+
+// close closes the underlying archive file, if it is kept open
+func (c *archiveContent) close() {
+	if c.archiveCloser != nil {
+		_ = c.archiveCloser.Close()
+	}
+}
+
+// openArchiveContent opens the archive at the given path, decrypting it if it is sealed and verifying its signature if
+// it is signed, then loads its manifest.
+// If the archive was not closed properly, the content that was completely written is salvaged and the manifest is
+// rebuilt from the tags stored with each artifact.
+func openArchiveContent(archivePath string, opts ...ReaderOption) (*archiveContent, error) {
+	var options readerOptions
+	for _, opt := range opts {
+		err := opt(&options)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sealed, err := isSealedArchive(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	content := &archiveContent{}
+	if sealed {
+		// Decrypt the archive on demand, salvage what is possible if the archive was not properly closed
+		content.archiveReader, content.archiveCloser, content.recovered, err = openSealed(archivePath, options.decryptionKey)
+	} else {
+		// Create a zip reader, salvage what is possible if the archive was not properly closed
+		content.archiveReader, content.archiveCloser, content.recovered, err = openArchive(archivePath)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	// Create map of filename -> file
+	content.filesMap = make(map[string]*zip.File, len(content.archiveReader.File))
+	for _, f := range content.archiveReader.File {
+		content.filesMap[f.Name] = f
+	}
+
+	// Verify signature before trusting any of the content
+	content.signer, err = verifyArchiveSignature(content.filesMap, options.trustedSigners)
+	if err != nil {
+		content.close()
+		return nil, fmt.Errorf("failed to verify archive signature: %w", err)
+	}
+
+	// Load manifest, which is a normalized index:
+	// For each file, a list of tags is present
+	content.manifest, err = loadManifest(content.filesMap)
+	if errors.Is(err, errManifestNotFound) {
+		// The writer did not get to add the manifest, rebuild it from the tags stored with each artifact
+		content.manifest, err = recoverManifest(content.archiveReader.File)
+		if err != nil {
+			content.close()
+			return nil, fmt.Errorf("failed to recover manifest: %w", err)
+		}
+		content.recovered = true
+	} else if err != nil {
+		content.close()
+		return nil, fmt.Errorf("failed to load manifest: %w", err)
+	}
+
+	return content, nil
+}
+
+// manifestEntry is the manifest record of an artifact: its tags and the SHA-256 digest of its content (hex encoded).
+// Archives created before digests were recorded list only the tags of each artifact.
+type manifestEntry struct {
+	Tags   []Tag  `json:"tags"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// UnmarshalJSON decodes a manifest entry, or a list of tags if the manifest was created by an older Writer
+func (e *manifestEntry) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		*e = manifestEntry{}
+		return json.Unmarshal(data, &e.Tags)
+	}
+	type plainManifestEntry manifestEntry
+	return json.Unmarshal(data, (*plainManifestEntry)(e))
+}
+
+// isInternalFile returns true for files that are part of the archive structure (manifest and signature), and are
+// therefore not listed in the manifest
+func isInternalFile(name string) bool {
+	for _, tag := range []*Tag{internalTagManifest(), internalTagSignature()} {
+		internalFileName, err := createFilenameFromTags("json", []*Tag{tag})
+		if err == nil && name == internalFileName {
+			return true
+		}
+	}
+	return false
+}
+
+// errManifestNotFound is returned by loadManifest if the archive does not contain a manifest
+var errManifestNotFound = fmt.Errorf("manifest file not found in archive")
+
+// loadManifest finds the manifest file in the archive and decodes it
+func loadManifest(filesMap map[string]*zip.File) (map[string]manifestEntry, error) {
+	manifestFileName, err := createFilenameFromTags("json", []*Tag{internalTagManifest()})
+	if err != nil {
+		return nil, err
+	}
+
+	manifestFile, exists := filesMap[manifestFileName]
+	if !exists {
+		return nil, errManifestNotFound
+	}
+
+	manifestFileReader, err := manifestFile.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer manifestFileReader.Close()
+
+	manifest := make(map[string]manifestEntry, len(filesMap))
+	err = json.NewDecoder(manifestFileReader).Decode(&manifest)
+	if err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// recoverManifest rebuilds the manifest using the tags that the Writer stores alongside each artifact.
+// Files without tags are left out of the manifest. Digests are not stored alongside artifacts, so the recovered
+// manifest does not contain them.
+func recoverManifest(files []*zip.File) (map[string]manifestEntry, error) {
+	manifestFileName, err := createFilenameFromTags("json", []*Tag{internalTagManifest()})
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := make(map[string]manifestEntry, len(files))
+	for _, f := range files {
+		if f.Name == manifestFileName {
+			continue
+		}
+		tags, found, err := decodeTagsExtraField(f.Extra)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode tags of %s: %w", f.Name, err)
+		} else if !found {
+			continue
+		}
+		manifest[f.Name] = manifestEntry{Tags: tags}
+	}
+	return manifest, nil
+}
+
+// Recovered returns true if the archive was not closed properly by the Writer, and its content and manifest were
+// reconstructed from the artifacts that were successfully written to it.
+func (r *Reader) Recovered() bool {
+	return r.recovered
+}
+
+// Signer returns the public key of the nkey that signed the archive, or an empty string if the archive is not signed
+func (r *Reader) Signer() string {
+	return r.signer
+}
+
+// GetSnapshots lists the capture times of the snapshots in the archive, for archives created by repeated captures.
+// Artifacts of a snapshot can be selected with TagSnapshot. The list is sorted chronologically and is empty if the
+// archive contains a single capture.
+func (r *Reader) GetSnapshots() []time.Time {
+	return slices.Clone(r.snapshots)
+}
+
 // GetAccountNames list the unique names of accounts found in the archive
 // The list of names is sorted alphabetically
 func (r *Reader) GetAccountNames() []string {
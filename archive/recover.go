@@ -0,0 +1,185 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	localFileHeaderSignature = 0x04034b50
+	localFileHeaderLen       = 30
+	dataDescriptorFlag       = 0x8
+	zip64SizePlaceholder     = 0xffffffff
+
+	centralDirectoryHeaderSignature = 0x02014b50
+	centralDirectoryHeaderLen       = 46
+	endOfCentralDirectorySignature  = 0x06054b50
+	endOfCentralDirectoryLen        = 22
+)
+
+// openArchive opens the zip archive at the given path.
+// If the file is not a valid zip archive, for example because the process writing it was interrupted before the
+// archive was closed, then an attempt is made to salvage all the entries that were completely written.
+// Returns a closer if the underlying file is kept open, and true if the archive content was recovered.
+func openArchive(archivePath string) (*zip.Reader, io.Closer, bool, error) {
+	readCloser, err := zip.OpenReader(archivePath)
+	if err == nil {
+		return &readCloser.Reader, readCloser, false, nil
+	} else if !errors.Is(err, zip.ErrFormat) {
+		return nil, nil, false, err
+	}
+
+	recoveredReader, recoveredCloser, err := recoverArchive(archivePath)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("archive is damaged or incomplete, and recovery failed: %w", err)
+	}
+	return recoveredReader, recoveredCloser, true, nil
+}
+
+// recoverArchive scans the local file headers of a zip file missing its central directory, and indexes each entry
+// that is intact.
+// The scan stops at the first entry that is truncated, or whose size cannot be determined from the local header.
+// A central directory for the intact entries is created in memory and appended (virtually) to the intact portion
+// of the file, so that content is still read from disk on demand.
+// Returns the file as closer, it must be closed after the reader is no longer in use.
+func recoverArchive(archivePath string) (*zip.Reader, io.Closer, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	zipReader, err := indexIntactEntries(f, fileInfo.Size())
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return zipReader, f, nil
+}
+
+// indexIntactEntries creates a zip reader for all the intact entries of the given (incomplete) zip file
+func indexIntactEntries(f io.ReaderAt, fileSize int64) (*zip.Reader, error) {
+	var centralDirectory bytes.Buffer
+	recoveredCount := 0
+
+	header := make([]byte, localFileHeaderLen)
+	offset := int64(0)
+	for offset+localFileHeaderLen <= fileSize {
+		_, err := f.ReadAt(header, offset)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header at offset %d: %w", offset, err)
+		}
+
+		if binary.LittleEndian.Uint32(header[0:4]) != localFileHeaderSignature {
+			// Beginning of a (partial) central directory, or garbage
+			break
+		}
+
+		flags := binary.LittleEndian.Uint16(header[6:8])
+		compressedSize := binary.LittleEndian.Uint32(header[18:22])
+		uncompressedSize := binary.LittleEndian.Uint32(header[22:26])
+		nameLen := int64(binary.LittleEndian.Uint16(header[26:28]))
+		extraLen := int64(binary.LittleEndian.Uint16(header[28:30]))
+
+		if flags&dataDescriptorFlag != 0 || compressedSize == zip64SizePlaceholder || uncompressedSize == zip64SizePlaceholder {
+			// Size of the content is not known (written after the content, or in a ZIP64 extra field), stop here
+			break
+		}
+
+		dataOffset := offset + localFileHeaderLen + nameLen + extraLen
+		if dataOffset+int64(compressedSize) > fileSize || offset > zip64SizePlaceholder {
+			// Entry is truncated, or its offset cannot be stored without ZIP64
+			break
+		}
+
+		nameAndExtra := make([]byte, nameLen+extraLen)
+		_, err = f.ReadAt(nameAndExtra, offset+localFileHeaderLen)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read header at offset %d: %w", offset, err)
+		}
+
+		// Central directory record, reusing the fields of the local header
+		record := make([]byte, centralDirectoryHeaderLen)
+		binary.LittleEndian.PutUint32(record[0:4], centralDirectoryHeaderSignature)
+		copy(record[4:6], header[4:6])   // Version made by, same as version needed
+		copy(record[6:32], header[4:30]) // Version needed, flags, method, time, date, crc, sizes, name and extra length
+		binary.LittleEndian.PutUint32(record[42:46], uint32(offset))
+		centralDirectory.Write(record)
+		centralDirectory.Write(nameAndExtra)
+
+		recoveredCount += 1
+		offset = dataOffset + int64(compressedSize)
+	}
+
+	if recoveredCount == 0 {
+		return nil, fmt.Errorf("no intact files found")
+	}
+
+	// End of central directory record. The count of records overflows if there are more than 65535 entries, the
+	// zip reader tolerates this as long as the lower 16 bits match.
+	end := make([]byte, endOfCentralDirectoryLen)
+	binary.LittleEndian.PutUint32(end[0:4], endOfCentralDirectorySignature)
+	binary.LittleEndian.PutUint16(end[8:10], uint16(recoveredCount))
+	binary.LittleEndian.PutUint16(end[10:12], uint16(recoveredCount))
+	binary.LittleEndian.PutUint32(end[12:16], uint32(centralDirectory.Len()))
+	binary.LittleEndian.PutUint32(end[16:20], uint32(offset))
+	centralDirectory.Write(end)
+
+	archive := &appendedReaderAt{
+		head:     io.NewSectionReader(f, 0, offset),
+		headSize: offset,
+		tail:     bytes.NewReader(centralDirectory.Bytes()),
+	}
+	size := offset + int64(centralDirectory.Len())
+
+	zipReader, err := zip.NewReader(archive, size)
+	if err != nil {
+		return nil, err
+	}
+	return zipReader, nil
+}
+
+// appendedReaderAt reads from head and then continues into tail, as if they were a single file
+type appendedReaderAt struct {
+	head     io.ReaderAt
+	headSize int64
+	tail     io.ReaderAt
+}
+
+func (r *appendedReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.headSize {
+		return r.tail.ReadAt(p, off-r.headSize)
+	}
+
+	headLen := min(int64(len(p)), r.headSize-off)
+	n, err := r.head.ReadAt(p[:headLen], off)
+	if err != nil || n == len(p) {
+		return n, err
+	}
+
+	m, err := r.tail.ReadAt(p[n:], 0)
+	return n + m, err
+}
@@ -0,0 +1,488 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nkeys"
+)
+
+// sealedArchiveHeader is the prefix of a sealed archive file, it is followed by the public curve key (XKey) of the
+// (ephemeral) sender, a newline, and the zip archive sealed in chunks (see sealWriter)
+const sealedArchiveHeader = "NATS-AUDIT-SEALED-V1\n"
+
+// sealChunkSize is the largest amount of archive content sealed in a single chunk
+const sealChunkSize = 1024 * 1024
+
+// sealChunkHeaderLen is the length of the header preceding the content of each chunk, once opened: the sequence
+// number of the chunk (8 bytes) and a flag set on the final chunk (1 byte)
+const sealChunkHeaderLen = 9
+
+// ErrArchiveSealed is returned when opening a sealed archive without a decryption key
+var ErrArchiveSealed = fmt.Errorf("archive is sealed, a decryption key is required to open it")
+
+// archiveSignature is the content of the special signature artifact
+type archiveSignature struct {
+	// Public key of the nkey that produced the signature
+	PublicKey string `json:"public_key"`
+	// Signature of the archive digests (see signaturePayload)
+	Signature []byte `json:"signature"`
+}
+
+// WriterOption configures optional behavior of a Writer
+type WriterOption func(*Writer) error
+
+// SealTo encrypts the archive so that it can only be opened with the seed of the given public curve key (XKey).
+// The archive is encrypted in chunks as it is written, so that no part of the plaintext content ever touches the disk.
+// A sealed archive that is not closed can be recovered up to the last artifact written.
+func SealTo(recipientPublicXKey string) WriterOption {
+	return func(w *Writer) error {
+		if !nkeys.IsValidPublicCurveKey(recipientPublicXKey) {
+			return fmt.Errorf("invalid recipient public XKey: %s", recipientPublicXKey)
+		}
+		w.sealRecipient = recipientPublicXKey
+		return nil
+	}
+}
+
+// SignWith signs the archive with the given nkey seed.
+// The signature covers the manifest and the content of every artifact.
+func SignWith(seed []byte) WriterOption {
+	return func(w *Writer) error {
+		prefix, _, err := nkeys.DecodeSeed(seed)
+		if err != nil {
+			return fmt.Errorf("invalid signing key: %w", err)
+		}
+		if prefix == nkeys.PrefixByteCurve {
+			return fmt.Errorf("invalid signing key: curve keys cannot sign")
+		}
+		kp, err := nkeys.FromSeed(seed)
+		if err != nil {
+			return fmt.Errorf("invalid signing key: %w", err)
+		}
+		w.signer = kp
+		return nil
+	}
+}
+
+// ReaderOption configures optional behavior of a Reader
+type ReaderOption func(*readerOptions) error
+
+type readerOptions struct {
+	decryptionKey  nkeys.KeyPair
+	trustedSigners []string
+}
+
+// WithDecryptionKey provides the curve key seed (XKey) used to open sealed archives.
+// Unsealed archives are opened normally.
+func WithDecryptionKey(seed []byte) ReaderOption {
+	return func(o *readerOptions) error {
+		kp, err := nkeys.FromCurveSeed(seed)
+		if err != nil {
+			return fmt.Errorf("invalid decryption key: %w", err)
+		}
+		o.decryptionKey = kp
+		return nil
+	}
+}
+
+// WithTrustedSigners requires the archive to be signed by one of the given public nkeys.
+// Signed archives are always verified, this option also rejects archives that are unsigned or signed by some other
+// key.
+func WithTrustedSigners(publicKeys ...string) ReaderOption {
+	return func(o *readerOptions) error {
+		for _, publicKey := range publicKeys {
+			if !nkeys.IsValidPublicKey(publicKey) {
+				return fmt.Errorf("invalid trusted signer public key: %s", publicKey)
+			}
+		}
+		o.trustedSigners = append(o.trustedSigners, publicKeys...)
+		return nil
+	}
+}
+
+// sealWriter encrypts the archive content written to it for a recipient, using a newly created sender key.
+// Content is sealed in chunks of at most sealChunkSize bytes, each written as its length (4 bytes) followed by the
+// sealed chunk. Chunks are numbered so they cannot be reordered, and the last one is flagged so that a truncated
+// archive is detected.
+type sealWriter struct {
+	w         io.Writer
+	sender    nkeys.KeyPair
+	recipient string
+	buf       []byte
+	sequence  uint64
+}
+
+// newSealWriter creates a sender key and writes the sealed archive header to w
+func newSealWriter(w io.Writer, recipientPublicXKey string) (*sealWriter, error) {
+	sender, err := nkeys.CreateCurveKeys()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sender key: %w", err)
+	}
+
+	senderPublicKey, err := sender.PublicKey()
+	if err != nil {
+		sender.Wipe()
+		return nil, err
+	}
+
+	_, err = io.WriteString(w, sealedArchiveHeader+senderPublicKey+"\n")
+	if err != nil {
+		sender.Wipe()
+		return nil, err
+	}
+
+	return &sealWriter{w: w, sender: sender, recipient: recipientPublicXKey}, nil
+}
+
+func (s *sealWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		n := min(len(p), sealChunkSize-len(s.buf))
+		s.buf = append(s.buf, p[:n]...)
+		p = p[n:]
+		written += n
+
+		if len(s.buf) == sealChunkSize {
+			err := s.writeChunk(false)
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Flush seals and writes the content buffered so far
+func (s *sealWriter) Flush() error {
+	if len(s.buf) == 0 {
+		return nil
+	}
+	return s.writeChunk(false)
+}
+
+// Close seals and writes the final chunk, then wipes the sender key
+func (s *sealWriter) Close() error {
+	defer s.sender.Wipe()
+	return s.writeChunk(true)
+}
+
+func (s *sealWriter) writeChunk(final bool) error {
+	chunk := make([]byte, sealChunkHeaderLen+len(s.buf))
+	binary.BigEndian.PutUint64(chunk[0:8], s.sequence)
+	if final {
+		chunk[8] = 1
+	}
+	copy(chunk[sealChunkHeaderLen:], s.buf)
+
+	sealed, err := s.sender.Seal(chunk, s.recipient)
+	if err != nil {
+		return fmt.Errorf("failed to seal archive: %w", err)
+	}
+
+	length := binary.BigEndian.AppendUint32(nil, uint32(len(sealed)))
+	_, err = s.w.Write(append(length, sealed...))
+	if err != nil {
+		return err
+	}
+
+	s.buf = s.buf[:0]
+	s.sequence++
+	return nil
+}
+
+// isSealedArchive returns true if the file at the given path starts with the sealed archive header
+func isSealedArchive(archivePath string) (bool, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	header := make([]byte, len(sealedArchiveHeader))
+	_, err = io.ReadFull(f, header)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return string(header) == sealedArchiveHeader, nil
+}
+
+// openSealed opens the sealed archive at the given path, like openArchive.
+// An archive whose final chunk is missing was not closed, the entries in the intact chunks are salvaged.
+func openSealed(archivePath string, key nkeys.KeyPair) (*zip.Reader, io.Closer, bool, error) {
+	sealed, err := openSealedArchive(archivePath, key)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if sealed.complete {
+		zipReader, err := zip.NewReader(sealed, sealed.size)
+		if err != nil {
+			sealed.Close()
+			return nil, nil, false, err
+		}
+		return zipReader, sealed, false, nil
+	}
+
+	zipReader, err := indexIntactEntries(sealed, sealed.size)
+	if err != nil {
+		sealed.Close()
+		return nil, nil, false, fmt.Errorf("archive is damaged or incomplete, and recovery failed: %w", err)
+	}
+	return zipReader, sealed, true, nil
+}
+
+// sealedArchive is a sealed archive opened for reading. Chunks are decrypted on demand, so that the content is not
+// held in memory, nor written to disk in plaintext.
+type sealedArchive struct {
+	f      *os.File
+	key    nkeys.KeyPair
+	sender string
+	// offsets of each chunk in the file, and of its content in the archive
+	fileOffsets    []int64
+	contentOffsets []int64
+	// size of the archive content in the intact chunks
+	size int64
+	// complete is true if the final chunk is present
+	complete bool
+
+	mu          sync.Mutex
+	cachedIndex int
+	cached      []byte
+}
+
+// openSealedArchive opens the sealed archive at the given path. Every chunk is decrypted once to authenticate it
+// and index its content. Scanning stops at the first truncated chunk, leaving the archive incomplete.
+func openSealedArchive(archivePath string, key nkeys.KeyPair) (*sealedArchive, error) {
+	if key == nil {
+		return nil, ErrArchiveSealed
+	}
+
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	header := bufio.NewReader(io.NewSectionReader(f, 0, int64(len(sealedArchiveHeader))+128))
+	_, err = header.Discard(len(sealedArchiveHeader))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	sender, err := header.ReadString('\n')
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("malformed sealed archive header")
+	}
+
+	archive := &sealedArchive{
+		f:           f,
+		key:         key,
+		sender:      strings.TrimSuffix(sender, "\n"),
+		cachedIndex: -1,
+	}
+
+	offset := int64(len(sealedArchiveHeader) + len(sender))
+	for !archive.complete {
+		chunk, next, err := archive.readChunk(offset, len(archive.fileOffsets))
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		} else if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		archive.fileOffsets = append(archive.fileOffsets, offset)
+		archive.contentOffsets = append(archive.contentOffsets, archive.size)
+		archive.size += int64(len(chunk) - sealChunkHeaderLen)
+		archive.complete = chunk[8] == 1
+		offset = next
+	}
+
+	return archive, nil
+}
+
+// readChunk reads and opens the chunk at the given file offset, checking its sequence number.
+// Returns the opened chunk (with its header) and the offset of the next chunk.
+func (a *sealedArchive) readChunk(offset int64, sequence int) ([]byte, int64, error) {
+	length := make([]byte, 4)
+	_, err := a.f.ReadAt(length, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Sealing adds a version, nonce and authentication tag to the chunk, well under 1KiB
+	sealedLen := binary.BigEndian.Uint32(length)
+	if sealedLen > sealChunkHeaderLen+sealChunkSize+1024 {
+		return nil, 0, fmt.Errorf("failed to unseal archive: chunk %d is malformed", sequence)
+	}
+
+	sealed := make([]byte, sealedLen)
+	_, err = a.f.ReadAt(sealed, offset+4)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	chunk, err := a.key.Open(sealed, a.sender)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to unseal archive: %w", err)
+	}
+	if len(chunk) < sealChunkHeaderLen || binary.BigEndian.Uint64(chunk[0:8]) != uint64(sequence) {
+		return nil, 0, fmt.Errorf("failed to unseal archive: chunk %d is malformed or out of order", sequence)
+	}
+
+	return chunk, offset + 4 + int64(len(sealed)), nil
+}
+
+// ReadAt reads the archive content, decrypting the chunks it spans
+func (a *sealedArchive) ReadAt(p []byte, off int64) (int, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	read := 0
+	for read < len(p) {
+		if off >= a.size {
+			return read, io.EOF
+		}
+
+		// Index of the last chunk starting at or before the offset
+		index := sort.Search(len(a.contentOffsets), func(i int) bool { return a.contentOffsets[i] > off }) - 1
+		if index != a.cachedIndex {
+			chunk, _, err := a.readChunk(a.fileOffsets[index], index)
+			if err != nil {
+				return read, err
+			}
+			a.cachedIndex, a.cached = index, chunk[sealChunkHeaderLen:]
+		}
+
+		n := copy(p[read:], a.cached[off-a.contentOffsets[index]:])
+		read += n
+		off += int64(n)
+	}
+	return read, nil
+}
+
+// Close closes the underlying file
+func (a *sealedArchive) Close() error {
+	return a.f.Close()
+}
+
+// signaturePayload creates the document that is signed: one line for each file, in the format
+// `<sha256 hex> <name>`, sorted by name
+func signaturePayload(digests map[string][]byte) []byte {
+	names := make([]string, 0, len(digests))
+	for name := range digests {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var payload bytes.Buffer
+	for _, name := range names {
+		fmt.Fprintf(&payload, "%s %s\n", hex.EncodeToString(digests[name]), name)
+	}
+	return payload.Bytes()
+}
+
+// verifyArchiveSignature checks the signature file against the content of all other files in the archive.
+// Returns the public key of the signer, or an empty string if the archive is not signed.
+func verifyArchiveSignature(filesMap map[string]*zip.File, trustedSigners []string) (string, error) {
+	signatureFileName, err := createFilenameFromTags("json", []*Tag{internalTagSignature()})
+	if err != nil {
+		return "", err
+	}
+
+	signatureFile, signed := filesMap[signatureFileName]
+	if !signed {
+		if len(trustedSigners) > 0 {
+			return "", fmt.Errorf("archive is not signed")
+		}
+		return "", nil
+	}
+
+	var signature archiveSignature
+	err = decodeZipFile(signatureFile, &signature)
+	if err != nil {
+		return "", fmt.Errorf("failed to load signature: %w", err)
+	}
+
+	if len(trustedSigners) > 0 && !slices.Contains(trustedSigners, signature.PublicKey) {
+		return "", fmt.Errorf("archive is signed by untrusted key %s", signature.PublicKey)
+	}
+
+	signer, err := nkeys.FromPublicKey(signature.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("invalid signer public key: %w", err)
+	}
+
+	digests := make(map[string][]byte, len(filesMap))
+	for name, f := range filesMap {
+		if name == signatureFileName {
+			continue
+		}
+		digests[name], err = zipFileDigest(f)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash %s: %w", name, err)
+		}
+	}
+
+	err = signer.Verify(signaturePayload(digests), signature.Signature)
+	if err != nil {
+		return "", fmt.Errorf("invalid signature by %s: %w", signature.PublicKey, err)
+	}
+
+	return signature.PublicKey, nil
+}
+
+// decodeZipFile decodes the JSON content of the given archive file into v
+func decodeZipFile(f *zip.File, v any) error {
+	reader, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return json.NewDecoder(reader).Decode(v)
+}
+
+// zipFileDigest computes the SHA-256 digest of the (uncompressed) content of the given archive file
+func zipFileDigest(f *zip.File) ([]byte, error) {
+	reader, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	_, err = io.Copy(hash, reader)
+	if err != nil {
+		return nil, err
+	}
+	return hash.Sum(nil), nil
+}
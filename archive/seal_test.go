@@ -0,0 +1,314 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/nats-io/nkeys"
+)
+
+func Test_SealedAndSignedArchive(t *testing.T) {
+	type DummyRecord struct {
+		Server string
+	}
+
+	recipient, err := nkeys.CreateCurveKeys()
+	if err != nil {
+		t.Fatalf("Failed to create recipient key: %s", err)
+	}
+	recipientSeed, _ := recipient.Seed()
+	recipientPublicKey, _ := recipient.PublicKey()
+
+	signer, err := nkeys.CreateUser()
+	if err != nil {
+		t.Fatalf("Failed to create signer key: %s", err)
+	}
+	signerSeed, _ := signer.Seed()
+	signerPublicKey, _ := signer.PublicKey()
+
+	writeArchive := func(t *testing.T, opts ...WriterOption) string {
+		t.Helper()
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		aw, err := NewWriter(archivePath, opts...)
+		if err != nil {
+			t.Fatalf("Failed to create archive: %s", err)
+		}
+		for _, serverName := range []string{"A", "B", "C"} {
+			err := aw.Add(&DummyRecord{Server: serverName}, TagCluster("C1"), TagServer(serverName), TagServerHealth())
+			if err != nil {
+				t.Fatalf("Failed to add artifact: %s", err)
+			}
+		}
+		err = aw.Close()
+		if err != nil {
+			t.Fatalf("Failed to close archive: %s", err)
+		}
+		return archivePath
+	}
+
+	verifyArtifacts := func(t *testing.T, ar *Reader) {
+		t.Helper()
+		for _, serverName := range []string{"A", "B", "C"} {
+			var r DummyRecord
+			err := ar.Load(&r, TagCluster("C1"), TagServer(serverName), TagServerHealth())
+			if err != nil {
+				t.Fatalf("Failed to load artifact for server %s: %s", serverName, err)
+			}
+			if r.Server != serverName {
+				t.Fatalf("Unexpected value '%s' (should be: '%s')", r.Server, serverName)
+			}
+		}
+	}
+
+	t.Run("sealed", func(t *testing.T) {
+		archivePath := writeArchive(t, SealTo(recipientPublicKey))
+
+		_, err := zip.OpenReader(archivePath)
+		if err == nil {
+			t.Fatalf("Expected sealed archive not to be a readable zip")
+		}
+
+		_, err = NewReader(archivePath)
+		if !errors.Is(err, ErrArchiveSealed) {
+			t.Fatalf("Expected error: %s, got: %v", ErrArchiveSealed, err)
+		}
+
+		otherKey, _ := nkeys.CreateCurveKeys()
+		otherSeed, _ := otherKey.Seed()
+		_, err = NewReader(archivePath, WithDecryptionKey(otherSeed))
+		if err == nil {
+			t.Fatalf("Expected error opening archive with the wrong key")
+		}
+
+		ar, err := NewReader(archivePath, WithDecryptionKey(recipientSeed))
+		if err != nil {
+			t.Fatalf("Failed to open sealed archive: %s", err)
+		}
+		defer ar.Close()
+		verifyArtifacts(t, ar)
+
+		if ar.Signer() != "" {
+			t.Fatalf("Expected unsigned archive, signer: %s", ar.Signer())
+		}
+	})
+
+	t.Run("sealed in chunks", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		aw, err := NewWriter(archivePath, SealTo(recipientPublicKey))
+		if err != nil {
+			t.Fatalf("Failed to create archive: %s", err)
+		}
+
+		// Random content does not compress, the profile spans multiple chunks
+		profile := make([]byte, 3*sealChunkSize+100)
+		_, err = rand.Read(profile)
+		if err != nil {
+			t.Fatalf("Failed to create profile: %s", err)
+		}
+		err = aw.AddRaw(bytes.NewReader(profile), "prof", TagCluster("C1"), TagServer("A"), TagServerProfile(), TagProfileName("cpu"))
+		if err != nil {
+			t.Fatalf("Failed to add profile: %s", err)
+		}
+		for _, serverName := range []string{"A", "B", "C"} {
+			err := aw.Add(&DummyRecord{Server: serverName}, TagCluster("C1"), TagServer(serverName), TagServerHealth())
+			if err != nil {
+				t.Fatalf("Failed to add artifact: %s", err)
+			}
+		}
+		err = aw.Close()
+		if err != nil {
+			t.Fatalf("Failed to close archive: %s", err)
+		}
+
+		ar, err := NewReader(archivePath, WithDecryptionKey(recipientSeed))
+		if err != nil {
+			t.Fatalf("Failed to open sealed archive: %s", err)
+		}
+		defer ar.Close()
+		verifyArtifacts(t, ar)
+
+		if ar.Recovered() {
+			t.Fatalf("Expected complete archive not to be recovered")
+		}
+		rawProfile, err := ar.LoadRaw(TagCluster("C1"), TagServer("A"), TagServerProfile(), TagProfileName("cpu"))
+		if err != nil {
+			t.Fatalf("Failed to load profile: %s", err)
+		}
+		if !bytes.Equal(rawProfile, profile) {
+			t.Fatalf("Unexpected profile content")
+		}
+	})
+
+	t.Run("sealed interrupted writer", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		aw, err := NewWriter(archivePath, SealTo(recipientPublicKey))
+		if err != nil {
+			t.Fatalf("Failed to create archive: %s", err)
+		}
+		for _, serverName := range []string{"A", "B", "C"} {
+			err := aw.Add(&DummyRecord{Server: serverName}, TagCluster("C1"), TagServer(serverName), TagServerHealth())
+			if err != nil {
+				t.Fatalf("Failed to add artifact: %s", err)
+			}
+		}
+
+		// Add one more artifact, then truncate the file half-way through it, as if the process was killed
+		fileInfo, err := aw.fileWriter.Stat()
+		if err != nil {
+			t.Fatalf("Failed to get archive stats: %s", err)
+		}
+		sizeBeforeLastArtifact := fileInfo.Size()
+		err = aw.Add(&DummyRecord{Server: "D"}, TagCluster("C1"), TagServer("D"), TagServerHealth())
+		if err != nil {
+			t.Fatalf("Failed to add artifact: %s", err)
+		}
+		fileInfo, err = aw.fileWriter.Stat()
+		if err != nil {
+			t.Fatalf("Failed to get archive stats: %s", err)
+		}
+		err = aw.fileWriter.Truncate((sizeBeforeLastArtifact + fileInfo.Size()) / 2)
+		if err != nil {
+			t.Fatalf("Failed to truncate archive: %s", err)
+		}
+		aw.fileWriter.Close()
+
+		ar, err := NewReader(archivePath, WithDecryptionKey(recipientSeed))
+		if err != nil {
+			t.Fatalf("Failed to open interrupted sealed archive: %s", err)
+		}
+		defer ar.Close()
+		verifyArtifacts(t, ar)
+
+		if !ar.Recovered() {
+			t.Fatalf("Expected archive to be recovered")
+		}
+		if slices.Contains(ar.GetClusterServerNames("C1"), "D") {
+			t.Fatalf("Expected truncated artifact not to be recovered")
+		}
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		archivePath := writeArchive(t, SignWith(signerSeed))
+
+		ar, err := NewReader(archivePath, WithTrustedSigners(signerPublicKey))
+		if err != nil {
+			t.Fatalf("Failed to open signed archive: %s", err)
+		}
+		defer ar.Close()
+		verifyArtifacts(t, ar)
+
+		if ar.Signer() != signerPublicKey {
+			t.Fatalf("Expected signer: %s, actual: %s", signerPublicKey, ar.Signer())
+		}
+
+		otherSigner, _ := nkeys.CreateUser()
+		otherSignerPublicKey, _ := otherSigner.PublicKey()
+		_, err = NewReader(archivePath, WithTrustedSigners(otherSignerPublicKey))
+		if err == nil {
+			t.Fatalf("Expected error opening archive signed by untrusted key")
+		}
+	})
+
+	t.Run("sealed and signed", func(t *testing.T) {
+		archivePath := writeArchive(t, SealTo(recipientPublicKey), SignWith(signerSeed))
+
+		ar, err := NewReader(archivePath, WithDecryptionKey(recipientSeed), WithTrustedSigners(signerPublicKey))
+		if err != nil {
+			t.Fatalf("Failed to open sealed and signed archive: %s", err)
+		}
+		defer ar.Close()
+		verifyArtifacts(t, ar)
+	})
+
+	t.Run("unsigned with trusted signers", func(t *testing.T) {
+		archivePath := writeArchive(t)
+
+		_, err := NewReader(archivePath, WithTrustedSigners(signerPublicKey))
+		if err == nil {
+			t.Fatalf("Expected error opening unsigned archive")
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		archivePath := writeArchive(t, SignWith(signerSeed))
+
+		// Copy the archive, replacing the content of one artifact
+		tamperedPath := filepath.Join(t.TempDir(), "tampered.zip")
+		zr, err := zip.OpenReader(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to open archive: %s", err)
+		}
+		defer zr.Close()
+		f, err := os.Create(tamperedPath)
+		if err != nil {
+			t.Fatalf("Failed to create file: %s", err)
+		}
+		zw := zip.NewWriter(f)
+		tampered := false
+		for _, zf := range zr.File {
+			w, err := zw.Create(zf.Name)
+			if err != nil {
+				t.Fatalf("Failed to create file in archive: %s", err)
+			}
+			if !tampered && filepath.Ext(zf.Name) == ".json" && filepath.Base(filepath.Dir(zf.Name)) != specialFilesDirectory {
+				_, err = w.Write([]byte(`{"Server": "X"}`))
+				tampered = true
+			} else {
+				var r io.ReadCloser
+				r, err = zf.Open()
+				if err == nil {
+					_, err = io.Copy(w, r)
+					r.Close()
+				}
+			}
+			if err != nil {
+				t.Fatalf("Failed to copy file: %s", err)
+			}
+		}
+		if !tampered {
+			t.Fatalf("No artifact was tampered with")
+		}
+		err = zw.Close()
+		if err != nil {
+			t.Fatalf("Failed to close zip: %s", err)
+		}
+		f.Close()
+
+		_, err = NewReader(tamperedPath)
+		if err == nil {
+			t.Fatalf("Expected error opening tampered archive")
+		}
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		_, err := NewWriter(archivePath, SealTo(signerPublicKey))
+		if err == nil {
+			t.Fatalf("Expected error sealing to a non-curve key")
+		}
+		_, err = NewWriter(archivePath, SignWith(recipientSeed))
+		if err == nil {
+			t.Fatalf("Expected error signing with a curve key")
+		}
+	})
+}
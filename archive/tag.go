@@ -16,6 +16,7 @@ package archive
 import (
 	"fmt"
 	"path"
+	"time"
 )
 
 type TagLabel string
@@ -32,6 +33,7 @@ const (
 	streamTagLabel      TagLabel = "stream"
 	typeTagLabel        TagLabel = "artifact_type"
 	profileNameTagLabel TagLabel = "profile_name"
+	snapshotTagLabel    TagLabel = "snapshot"
 	specialTagLabel     TagLabel = "special"
 )
 
@@ -54,8 +56,9 @@ const (
 	accountInfoArtifactType        = "account_info"
 	streamDetailsArtifactType      = "stream_info"
 	// Other artifacts
-	manifestArtifactName = "manifest"
-	profileArtifactType  = "profile"
+	manifestArtifactName  = "manifest"
+	signatureArtifactName = "signature"
+	profileArtifactType   = "profile"
 )
 
 const (
@@ -67,6 +70,10 @@ const (
 	specialFilesDirectory = "misc"
 	// Used to join dimensions in a path, for example cluster name and server name
 	separator = "__"
+	// Directory where artifacts of each snapshot are filed under, in archives with multiple captures
+	snapshotsDirectory = "snapshots"
+	// Format of snapshot tag values (capture timestamp, UTC), values sort in chronological order
+	snapshotTimeFormat = "20060102T150405.000Z"
 )
 
 // Special tags that get composed and combined in the filename
@@ -77,6 +84,7 @@ var dimensionTagsNames = map[TagLabel]any{
 	streamTagLabel:      nil,
 	typeTagLabel:        nil,
 	profileNameTagLabel: nil,
+	snapshotTagLabel:    nil,
 }
 
 func createFilenameFromTags(extension string, tags []*Tag) (string, error) {
@@ -130,6 +138,7 @@ func createFilenameFromTags(extension string, tags []*Tag) (string, error) {
 	streamTag, hasStreamTag := dimensionTagsMap[streamTagLabel], dimensionTagsMap[streamTagLabel] != nil
 	typeTag, hasTypeTag := dimensionTagsMap[typeTagLabel], dimensionTagsMap[typeTagLabel] != nil
 	profileNameTag, hasProfileNameTag := dimensionTagsMap[profileNameTagLabel], dimensionTagsMap[profileNameTagLabel] != nil
+	snapshotTag, hasSnapshotTag := dimensionTagsMap[snapshotTagLabel], dimensionTagsMap[snapshotTagLabel] != nil
 
 	// All artifacts must have a type, source server and source cluster (or "un-clustered")
 	for requiredTagName, hasRequiredTag := range map[string]bool{
@@ -142,13 +151,19 @@ func createFilenameFromTags(extension string, tags []*Tag) (string, error) {
 		}
 	}
 
+	// Artifacts of each snapshot are filed under a separate root
+	root := rootDirectory
+	if hasSnapshotTag {
+		root = path.Join(rootDirectory, snapshotsDirectory, snapshotTag.Value)
+	}
+
 	if hasStreamTag {
 		// Stream artifact must have account and cluster tag
 		if !hasClusterTag || !hasAccountTag {
 			return "", fmt.Errorf("stream artifact is missing cluster or account tags")
 		}
 		return path.Join(
-			rootDirectory,
+			root,
 			"accounts",
 			accountTag.Value,
 			"streams",
@@ -164,7 +179,7 @@ func createFilenameFromTags(extension string, tags []*Tag) (string, error) {
 			return "", fmt.Errorf("account artifact is missing cluster tag")
 		}
 		return path.Join(
-			rootDirectory,
+			root,
 			"accounts",
 			accountTag.Value,
 			"servers",
@@ -186,7 +201,7 @@ func createFilenameFromTags(extension string, tags []*Tag) (string, error) {
 				return "", fmt.Errorf("profile artifact is missing profile name")
 			}
 			return path.Join(
-				rootDirectory,
+				root,
 				"profiles",
 				clusterName,
 				serverTag.Value+separator+profileNameTag.Value+"."+extension,
@@ -194,7 +209,7 @@ func createFilenameFromTags(extension string, tags []*Tag) (string, error) {
 
 		default:
 			return path.Join(
-				rootDirectory,
+				root,
 				"clusters",
 				clusterName,
 				serverTag.Value,
@@ -276,6 +291,10 @@ func internalTagManifest() *Tag {
 	return TagSpecial(manifestArtifactName)
 }
 
+func internalTagSignature() *Tag {
+	return TagSpecial(signatureArtifactName)
+}
+
 func TagServer(serverName string) *Tag {
 	return &Tag{
 		Name:  serverTagLabel,
@@ -322,6 +341,20 @@ func TagProfileName(profileType string) *Tag {
 	}
 }
 
+// TagSnapshot tags an artifact as part of the snapshot captured at the given time, in archives that contain multiple
+// captures of the same deployment
+func TagSnapshot(captureTime time.Time) *Tag {
+	return &Tag{
+		Name:  snapshotTagLabel,
+		Value: captureTime.UTC().Format(snapshotTimeFormat),
+	}
+}
+
+// IsSnapshotTag returns true if the tag is the snapshot tag of an artifact, see TagSnapshot
+func IsSnapshotTag(tag Tag) bool {
+	return tag.Name == snapshotTagLabel
+}
+
 func TagSpecial(special string) *Tag {
 	return &Tag{
 		Name:  specialTagLabel,
@@ -15,6 +15,7 @@ package archive
 
 import (
 	"testing"
+	"time"
 )
 
 func Test_CreateFilenameFromTags(t *testing.T) {
@@ -117,6 +118,20 @@ func Test_CreateFilenameFromTags(t *testing.T) {
 			"",
 			true,
 		},
+		{
+			"server info snapshot",
+			[]*Tag{TagCluster("C1"), TagServer("S1"), TagServerVars(), TagSnapshot(time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC))},
+			"json",
+			"capture/snapshots/20240501T103000.000Z/clusters/C1/S1/variables.json",
+			false,
+		},
+		{
+			"stream info snapshot",
+			[]*Tag{TagAccount("A1"), TagStream("Foo"), TagCluster("C1"), TagServer("S1"), TagStreamInfo(), TagSnapshot(time.Date(2024, 5, 1, 10, 30, 0, 5e6, time.UTC))},
+			"json",
+			"capture/snapshots/20240501T103000.005Z/accounts/A1/streams/Foo/replicas/C1__S1/stream_info.json",
+			false,
+		},
 		{
 			"manifest",
 			[]*Tag{internalTagManifest()},
@@ -0,0 +1,103 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// VerifyReport is the outcome of the integrity verification of an archive
+type VerifyReport struct {
+	// Recovered is true if the archive was not closed properly and its content was salvaged
+	Recovered bool
+	// Signer is the public key of the nkey that signed the archive, empty if the archive is not signed
+	Signer string
+	// Artifacts is the number of artifacts listed in the manifest
+	Artifacts int
+	// Verified is the number of artifacts whose content matches the digest recorded in the manifest
+	Verified int
+	// Unchecked lists artifacts that are readable, but have no digest in the manifest to compare against (archives
+	// created by older versions, or recovered)
+	Unchecked []string
+	// Missing lists artifacts that are in the manifest, but not in the archive
+	Missing []string
+	// Corrupted lists artifacts that cannot be read, or whose content does not match the digest in the manifest
+	Corrupted []string
+	// Orphans lists files in the archive that are not in the manifest
+	Orphans []string
+}
+
+// Ok returns true if all artifacts in the manifest are present and intact, and the archive has no unexpected files
+func (r *VerifyReport) Ok() bool {
+	return !r.Recovered && len(r.Missing) == 0 && len(r.Corrupted) == 0 && len(r.Orphans) == 0
+}
+
+// VerifyArchive checks the integrity of the archive at the given path.
+// Every artifact listed in the manifest must be present in the archive, and its content must match the SHA-256 digest
+// recorded in the manifest. Files not listed in the manifest are reported as orphans.
+// An error is returned if the archive cannot be opened at all, or if its signature is not valid.
+func VerifyArchive(archivePath string, opts ...ReaderOption) (*VerifyReport, error) {
+	content, err := openArchiveContent(archivePath, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer content.close()
+
+	report := &VerifyReport{
+		Recovered: content.recovered,
+		Signer:    content.signer,
+		Artifacts: len(content.manifest),
+	}
+
+	manifestFileNames := make([]string, 0, len(content.manifest))
+	for fileName := range content.manifest {
+		manifestFileNames = append(manifestFileNames, fileName)
+	}
+	slices.Sort(manifestFileNames)
+
+	for _, fileName := range manifestFileNames {
+		f, present := content.filesMap[fileName]
+		if !present {
+			report.Missing = append(report.Missing, fileName)
+			continue
+		}
+
+		// Reading the entire content also validates the zip CRC-32 checksum
+		digest, err := zipFileDigest(f)
+		switch expectedDigest := content.manifest[fileName].SHA256; {
+		case err != nil:
+			report.Corrupted = append(report.Corrupted, fmt.Sprintf("%s (%s)", fileName, err))
+		case expectedDigest == "":
+			report.Unchecked = append(report.Unchecked, fileName)
+		case hex.EncodeToString(digest) != expectedDigest:
+			report.Corrupted = append(report.Corrupted, fmt.Sprintf("%s (checksum mismatch)", fileName))
+		default:
+			report.Verified += 1
+		}
+	}
+
+	for _, f := range content.archiveReader.File {
+		if isInternalFile(f.Name) {
+			continue
+		}
+		if _, present := content.manifest[f.Name]; !present {
+			report.Orphans = append(report.Orphans, f.Name)
+		}
+	}
+	slices.Sort(report.Orphans)
+
+	return report, nil
+}
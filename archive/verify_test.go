@@ -0,0 +1,200 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func Test_VerifyArchive(t *testing.T) {
+	type DummyRecord struct {
+		Server string
+	}
+
+	serverNames := []string{"A", "B", "C"}
+
+	writeArchive := func(t *testing.T) string {
+		t.Helper()
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		aw, err := NewWriter(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to create archive: %s", err)
+		}
+		for _, serverName := range serverNames {
+			err := aw.Add(&DummyRecord{Server: serverName}, TagCluster("C1"), TagServer(serverName), TagServerHealth())
+			if err != nil {
+				t.Fatalf("Failed to add artifact: %s", err)
+			}
+		}
+		err = aw.Close()
+		if err != nil {
+			t.Fatalf("Failed to close archive: %s", err)
+		}
+		return archivePath
+	}
+
+	// rewriteArchive copies the given archive into a new one, replacing the content of some files (or dropping them,
+	// if the replacement is nil), and adding files not present in the original
+	rewriteArchive := func(t *testing.T, archivePath string, replace map[string][]byte) string {
+		t.Helper()
+		zr, err := zip.OpenReader(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to open archive: %s", err)
+		}
+		defer zr.Close()
+
+		rewrittenPath := filepath.Join(t.TempDir(), "rewritten.zip")
+		f, err := os.Create(rewrittenPath)
+		if err != nil {
+			t.Fatalf("Failed to create archive: %s", err)
+		}
+		defer f.Close()
+		zw := zip.NewWriter(f)
+
+		for _, file := range zr.File {
+			content, replaced := replace[file.Name]
+			if !replaced {
+				err = zw.Copy(file)
+			} else if content != nil {
+				var w io.Writer
+				w, err = zw.Create(file.Name)
+				if err == nil {
+					_, err = w.Write(content)
+				}
+			}
+			if err != nil {
+				t.Fatalf("Failed to copy %s: %s", file.Name, err)
+			}
+			delete(replace, file.Name)
+		}
+		for name, content := range replace {
+			w, err := zw.Create(name)
+			if err != nil {
+				t.Fatalf("Failed to create %s: %s", name, err)
+			}
+			_, err = w.Write(content)
+			if err != nil {
+				t.Fatalf("Failed to write %s: %s", name, err)
+			}
+		}
+
+		err = zw.Close()
+		if err != nil {
+			t.Fatalf("Failed to close archive: %s", err)
+		}
+		return rewrittenPath
+	}
+
+	artifactName := func(t *testing.T, serverName string) string {
+		t.Helper()
+		name, err := createFilenameFromTags("json", []*Tag{TagCluster("C1"), TagServer(serverName), TagServerHealth()})
+		if err != nil {
+			t.Fatalf("Failed to create artifact name: %s", err)
+		}
+		return name
+	}
+
+	t.Run("intact", func(t *testing.T) {
+		report, err := VerifyArchive(writeArchive(t))
+		if err != nil {
+			t.Fatalf("Failed to verify archive: %s", err)
+		}
+		if !report.Ok() || report.Artifacts != len(serverNames) || report.Verified != len(serverNames) {
+			t.Fatalf("Unexpected report: %+v", report)
+		}
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		archivePath := rewriteArchive(t, writeArchive(t), map[string][]byte{
+			artifactName(t, "A"):     []byte(`{"Server": "X"}`),
+			artifactName(t, "B"):     nil,
+			"capture/unexpected.txt": []byte("?"),
+		})
+
+		report, err := VerifyArchive(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to verify archive: %s", err)
+		}
+		if report.Ok() || report.Verified != 1 {
+			t.Fatalf("Unexpected report: %+v", report)
+		}
+		if len(report.Corrupted) != 1 || !strings.HasPrefix(report.Corrupted[0], artifactName(t, "A")) {
+			t.Fatalf("Expected corrupted artifact A, actual: %v", report.Corrupted)
+		}
+		if !slices.Equal(report.Missing, []string{artifactName(t, "B")}) {
+			t.Fatalf("Expected missing artifact B, actual: %v", report.Missing)
+		}
+		if !slices.Equal(report.Orphans, []string{"capture/unexpected.txt"}) {
+			t.Fatalf("Expected orphan file, actual: %v", report.Orphans)
+		}
+	})
+
+	t.Run("manifest without digests", func(t *testing.T) {
+		archivePath := writeArchive(t)
+
+		// Rewrite the manifest as created by older versions, a list of tags for each artifact
+		legacyManifest := make(map[string][]*Tag)
+		for _, serverName := range serverNames {
+			legacyManifest[artifactName(t, serverName)] = []*Tag{TagCluster("C1"), TagServer(serverName), TagServerHealth()}
+		}
+		legacyManifestBytes, err := json.Marshal(legacyManifest)
+		if err != nil {
+			t.Fatalf("Failed to encode manifest: %s", err)
+		}
+		manifestName, err := createFilenameFromTags("json", []*Tag{internalTagManifest()})
+		if err != nil {
+			t.Fatalf("Failed to create manifest name: %s", err)
+		}
+		archivePath = rewriteArchive(t, archivePath, map[string][]byte{manifestName: legacyManifestBytes})
+
+		report, err := VerifyArchive(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to verify archive: %s", err)
+		}
+		if !report.Ok() || report.Verified != 0 || len(report.Unchecked) != len(serverNames) {
+			t.Fatalf("Unexpected report: %+v", report)
+		}
+
+		// Archive is still readable
+		ar, err := NewReader(archivePath)
+		if err != nil {
+			t.Fatalf("Failed to open archive: %s", err)
+		}
+		defer ar.Close()
+		var record DummyRecord
+		err = ar.Load(&record, TagServer("B"), TagServerHealth())
+		if err != nil || record.Server != "B" {
+			t.Fatalf("Failed to load artifact: %v (%+v)", err, record)
+		}
+	})
+
+	t.Run("not an archive", func(t *testing.T) {
+		archivePath := filepath.Join(t.TempDir(), "archive.zip")
+		err := os.WriteFile(archivePath, []byte{}, 0600)
+		if err != nil {
+			t.Fatalf("Failed to create file: %s", err)
+		}
+		_, err = VerifyArchive(archivePath)
+		if err == nil {
+			t.Fatalf("Expected error verifying empty file")
+		}
+	})
+}
@@ -15,31 +15,54 @@ package archive
 
 import (
 	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"os"
 
 	"archive/zip"
+
+	"github.com/nats-io/nkeys"
 )
 
+// tagsExtraFieldID is the header ID of the zip extra field used to store the tags of each artifact alongside its
+// content. This makes each entry self-describing, so that a manifest can be recovered from a partially written archive
+const tagsExtraFieldID uint16 = 0x4e54
+
 // Writer encapsulates a zip writer for the underlying archive file, but also tracks metadata used by the Reader to
 // construct indices
 type Writer struct {
-	path        string
-	fileWriter  *os.File
-	zipWriter   *zip.Writer
-	manifestMap map[string][]*Tag
+	path          string
+	fileWriter    *os.File
+	zipWriter     *zip.Writer
+	manifestMap   map[string][]*Tag
+	digests       map[string][]byte
+	sealRecipient string
+	sealWriter    *sealWriter
+	signer        nkeys.KeyPair
 }
 
 // Close closes the writer
 func (w *Writer) Close() error {
 	// Add manifest file to archive before closing it
 	if w.zipWriter != nil && w.fileWriter != nil {
-		err := w.Add(w.manifestMap, internalTagManifest())
+		err := w.Add(w.manifest(), internalTagManifest())
 		if err != nil {
 			return fmt.Errorf("failed to add manifest: %w", err)
 		}
+
+		// Sign manifest and artifacts, the signature is the last file added
+		if w.signer != nil {
+			err = w.addSignature()
+			if err != nil {
+				return fmt.Errorf("failed to sign archive: %w", err)
+			}
+		}
 	}
 
 	// Close and null the zip writer
@@ -51,6 +74,15 @@ func (w *Writer) Close() error {
 		}
 	}
 
+	// Seal the remaining content as the final chunk
+	if w.sealWriter != nil && w.fileWriter != nil {
+		err := w.sealWriter.Close()
+		w.sealWriter = nil
+		if err != nil {
+			return err
+		}
+	}
+
 	// Close and null the file writer
 	if w.fileWriter != nil {
 		err := w.fileWriter.Close()
@@ -63,6 +95,22 @@ func (w *Writer) Close() error {
 	return nil
 }
 
+// manifest creates the manifest entry (tags and content digest) of each artifact added so far
+func (w *Writer) manifest() map[string]manifestEntry {
+	manifest := make(map[string]manifestEntry, len(w.manifestMap))
+	for name, tags := range w.manifestMap {
+		entry := manifestEntry{
+			Tags:   make([]Tag, len(tags)),
+			SHA256: hex.EncodeToString(w.digests[name]),
+		}
+		for i, tag := range tags {
+			entry.Tags[i] = *tag
+		}
+		manifest[name] = entry
+	}
+	return manifest
+}
+
 // addArtifact low-level API that adds bytes without adding to the index, used for special files
 func (w *Writer) addArtifact(name string, content *bytes.Reader) error {
 	f, err := w.zipWriter.Create(name)
@@ -111,38 +159,175 @@ func (w *Writer) AddRaw(reader *bytes.Reader, extension string, tags ...*Tag) er
 		return fmt.Errorf("artifact %s with identical tags is already present", name)
 	}
 
-	// Open a zip writer
-	f, err := w.zipWriter.Create(name)
-	if err != nil {
-		return fmt.Errorf("failed to create file in archive: %w", err)
-	}
-
-	_, err = io.Copy(f, reader)
+	digest, err := w.writeEntry(name, reader, tags)
 	if err != nil {
-		return fmt.Errorf("failed to copy content: %w", err)
+		return err
 	}
 
 	// Add file and its tags to the manifest
 	w.manifestMap[name] = tags
+	w.digests[name] = digest
 
 	return nil
 }
 
+// writeEntry compresses the given content and writes it into the archive as a file with the given name and tags.
+// Returns the SHA-256 digest of the content.
+func (w *Writer) writeEntry(name string, reader io.Reader, tags []*Tag) ([]byte, error) {
+	// Compress upfront, so that sizes and checksum are known and can be written in the local header, before the
+	// content. An archive with sizes in local headers can be scanned and recovered if it was not closed properly.
+	var compressed bytes.Buffer
+	compressor, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create compressor: %w", err)
+	}
+	checksum := crc32.NewIEEE()
+	digest := sha256.New()
+	uncompressedSize, err := io.Copy(io.MultiWriter(compressor, checksum, digest), reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress content: %w", err)
+	}
+	err = compressor.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress content: %w", err)
+	}
+
+	extra, err := encodeTagsExtraField(tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tags: %w", err)
+	}
+
+	f, err := w.zipWriter.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zip.Deflate,
+		CRC32:              checksum.Sum32(),
+		CompressedSize64:   uint64(compressed.Len()),
+		UncompressedSize64: uint64(uncompressedSize),
+		Extra:              extra,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file in archive: %w", err)
+	}
+
+	_, err = io.Copy(f, &compressed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy content: %w", err)
+	}
+
+	// Push the artifact out of the zip writer buffer, so that everything added so far is on disk and can be recovered
+	// if the process is interrupted before the archive is closed
+	err = w.zipWriter.Flush()
+	if err == nil && w.sealWriter != nil {
+		err = w.sealWriter.Flush()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to flush archive: %w", err)
+	}
+
+	return digest.Sum(nil), nil
+}
+
+// addSignature signs the digests of all files added so far (including the manifest), and adds the signature as a
+// special file, which is not listed in the manifest
+func (w *Writer) addSignature() error {
+	publicKey, err := w.signer.PublicKey()
+	if err != nil {
+		return err
+	}
+	signature, err := w.signer.Sign(signaturePayload(w.digests))
+	if err != nil {
+		return err
+	}
+
+	signatureBytes, err := json.MarshalIndent(archiveSignature{
+		PublicKey: publicKey,
+		Signature: signature,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	signatureTags := []*Tag{internalTagSignature()}
+	name, err := createFilenameFromTags("json", signatureTags)
+	if err != nil {
+		return err
+	}
+	_, err = w.writeEntry(name, bytes.NewReader(signatureBytes), signatureTags)
+	return err
+}
+
+// encodeTagsExtraField serializes the given tags into a zip extra field block
+func encodeTagsExtraField(tags []*Tag) ([]byte, error) {
+	tagsBytes, err := json.Marshal(tags)
+	if err != nil {
+		return nil, err
+	}
+	if len(tagsBytes) > 0xffff-4 {
+		return nil, fmt.Errorf("tags too large (%d bytes)", len(tagsBytes))
+	}
+	extra := make([]byte, 4, 4+len(tagsBytes))
+	binary.LittleEndian.PutUint16(extra[0:2], tagsExtraFieldID)
+	binary.LittleEndian.PutUint16(extra[2:4], uint16(len(tagsBytes)))
+	return append(extra, tagsBytes...), nil
+}
+
+// decodeTagsExtraField finds and deserializes tags in the given zip extra field blocks.
+// Returns false if the extra fields do not contain tags.
+func decodeTagsExtraField(extra []byte) ([]Tag, bool, error) {
+	for len(extra) >= 4 {
+		fieldID := binary.LittleEndian.Uint16(extra[0:2])
+		fieldSize := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+fieldSize {
+			return nil, false, fmt.Errorf("truncated extra field")
+		}
+		if fieldID == tagsExtraFieldID {
+			var tags []Tag
+			err := json.Unmarshal(extra[4:4+fieldSize], &tags)
+			if err != nil {
+				return nil, false, err
+			}
+			return tags, true, nil
+		}
+		extra = extra[4+fieldSize:]
+	}
+	return nil, false, nil
+}
+
 // NewWriter creates a new writer for the file at the given archivePath.
 // Writer creates a ZIP file whose content has additional structure and metadata.
 // If archivePath is an existing file, it will be overwritten.
-func NewWriter(archivePath string) (*Writer, error) {
+// Options can be used to seal (encrypt) and sign the archive.
+func NewWriter(archivePath string, opts ...WriterOption) (*Writer, error) {
+	w := &Writer{
+		path:        archivePath,
+		manifestMap: make(map[string][]*Tag),
+		digests:     make(map[string][]byte),
+	}
+
+	for _, opt := range opts {
+		err := opt(w)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	fileWriter, err := os.Create(archivePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create archive: %w", err)
 	}
+	w.fileWriter = fileWriter
 
-	zipWriter := zip.NewWriter(fileWriter)
+	if w.sealRecipient != "" {
+		// Sealed archives are encrypted as they are written
+		w.sealWriter, err = newSealWriter(fileWriter, w.sealRecipient)
+		if err != nil {
+			fileWriter.Close()
+			return nil, err
+		}
+		w.zipWriter = zip.NewWriter(w.sealWriter)
+	} else {
+		w.zipWriter = zip.NewWriter(fileWriter)
+	}
 
-	return &Writer{
-		path:        archivePath,
-		fileWriter:  fileWriter,
-		zipWriter:   zipWriter,
-		manifestMap: make(map[string][]*Tag),
-	}, nil
+	return w, nil
 }
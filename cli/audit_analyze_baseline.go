@@ -0,0 +1,111 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/ghodss/yaml"
+)
+
+// auditBaseline is a set of accepted issues, that analyze does not report. For example:
+//
+//	issues:
+//	  - check: High subject cardinality streams
+//	    key: ACME/EVENTS
+//	    message: 'ACME/EVENTS: 2000000 subjects'
+//
+// Issues are matched by check name and key, the message is the issue as it was first reported, for reference.
+type auditBaseline struct {
+	Issues []auditBaselineIssue `json:"issues"`
+
+	accepted map[string]map[string]struct{}
+}
+
+type auditBaselineIssue struct {
+	Check   string `json:"check"`
+	Key     string `json:"key"`
+	Message string `json:"message,omitempty"`
+}
+
+// loadAuditBaseline loads a baseline file, as written with --write-baseline
+func loadAuditBaseline(baselineFile string) (*auditBaseline, error) {
+	content, err := os.ReadFile(baselineFile)
+	if err != nil {
+		return nil, err
+	}
+
+	baseline := &auditBaseline{}
+	err = yaml.Unmarshal(content, baseline)
+	if err != nil {
+		return nil, fmt.Errorf("invalid baseline file %s: %w", baselineFile, err)
+	}
+
+	baseline.accepted = make(map[string]map[string]struct{})
+	for i, issue := range baseline.Issues {
+		if issue.Check == "" || issue.Key == "" {
+			return nil, fmt.Errorf("invalid issue #%d in baseline file %s: check and key are required", i+1, baselineFile)
+		}
+		if baseline.accepted[issue.Check] == nil {
+			baseline.accepted[issue.Check] = make(map[string]struct{})
+		}
+		baseline.accepted[issue.Check][issue.Key] = struct{}{}
+	}
+
+	return baseline, nil
+}
+
+// filter removes the issues of the given check that are accepted in the baseline, and returns the number removed
+func (b *auditBaseline) filter(checkName string, issues []auditIssue) ([]auditIssue, int) {
+	acceptedKeys := b.accepted[checkName]
+	if len(acceptedKeys) == 0 {
+		return issues, 0
+	}
+
+	remaining := make([]auditIssue, 0, len(issues))
+	for _, issue := range issues {
+		if _, accepted := acceptedKeys[issue.Key]; !accepted {
+			remaining = append(remaining, issue)
+		}
+	}
+
+	return remaining, len(issues) - len(remaining)
+}
+
+// add accepts the given issues of a check
+func (b *auditBaseline) add(checkName string, issues []auditIssue) {
+	for _, issue := range issues {
+		b.Issues = append(b.Issues, auditBaselineIssue{
+			Check:   checkName,
+			Key:     issue.Key,
+			Message: issue.Message,
+		})
+	}
+}
+
+// write saves the baseline as YAML
+func (b *auditBaseline) write(baselineFile string) error {
+	content, err := yaml.Marshal(b)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(baselineFile, content, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to write baseline file %s: %w", baselineFile, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAuditBaseline(t *testing.T) {
+	baselineFile := filepath.Join(t.TempDir(), "baseline.yaml")
+
+	written := &auditBaseline{}
+	written.add("High subject cardinality streams", []auditIssue{{"ACME/EVENTS", "ACME/EVENTS: 2000000 subjects"}})
+	written.add("Stream limits", []auditIssue{{"ACME/ORDERS/n1/messages", "stream ORDERS (in ACME on n1) using 95.0% of messages limit (95/100)"}})
+	err := written.write(baselineFile)
+	checkErr(t, err, "could not write baseline: %v", err)
+
+	baseline, err := loadAuditBaseline(baselineFile)
+	checkErr(t, err, "could not load baseline: %v", err)
+
+	issues := []auditIssue{
+		{"ACME/EVENTS", "ACME/EVENTS: 2500000 subjects"},
+		{"ACME/LOGS", "ACME/LOGS: 1500000 subjects"},
+	}
+	remaining, accepted := baseline.filter("High subject cardinality streams", issues)
+	if accepted != 1 {
+		t.Fatalf("expected 1 accepted issue, got %d", accepted)
+	}
+	if diff := cmp.Diff(issues[1:], remaining); diff != "" {
+		t.Fatalf("unexpected remaining issues (-want +got):\n%s", diff)
+	}
+
+	// Keys are accepted only for the check they were recorded for
+	remaining, accepted = baseline.filter("Lagging stream replicas", []auditIssue{{"ACME/EVENTS", "lagging"}})
+	if accepted != 0 || len(remaining) != 1 {
+		t.Fatalf("expected no accepted issues, got %d", accepted)
+	}
+}
+
+func TestAuditBaselineInvalid(t *testing.T) {
+	baselineFile := filepath.Join(t.TempDir(), "baseline.yaml")
+	err := os.WriteFile(baselineFile, []byte("issues:\n  - check: Stream limits\n"), 0600)
+	checkErr(t, err, "could not write baseline: %v", err)
+
+	_, err = loadAuditBaseline(baselineFile)
+	if err == nil {
+		t.Fatalf("expected error loading baseline without key")
+	}
+}
@@ -16,8 +16,10 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
 	"sort"
+	"time"
 
 	"github.com/choria-io/fisk"
 	"github.com/nats-io/nats-server/v2/server"
@@ -25,15 +27,27 @@ import (
 )
 
 type auditAnalyzeCmd struct {
-	archivePath          string
-	veryVerbose          bool
-	exampleIssuesLimit   uint
-	exampleIssues        []string
-	omittedExampleIssues int
-	checks               []struct {
-		checkName string
-		checkFunc func(reader *archive.Reader) (auditCheckOutcome, error)
-	}
+	archivePath        string
+	keys               auditArchiveKeys
+	checksFiles        []string
+	baselineFile       string
+	writeBaselineFile  string
+	format             string
+	failOn             string
+	veryVerbose        bool
+	exampleIssuesLimit uint
+	exampleIssues      []auditIssue
+	checkMessages      []string
+	checks             []auditCheck
+	// consumers are loaded once from consumersReader, and shared by all consumer checks
+	consumers       []*consumerReplicas
+	consumersReader *archive.Reader
+}
+
+// auditCheck is a named check run by analyze against an archive
+type auditCheck struct {
+	checkName string
+	checkFunc func(reader *archive.Reader) (auditCheckOutcome, error)
 }
 
 type auditCheckOutcome int
@@ -53,6 +67,22 @@ func (s auditCheckOutcome) badge() string {
 	}
 }
 
+// String returns the name of the outcome used in machine-readable reports
+func (s auditCheckOutcome) String() string {
+	switch s {
+	case Pass:
+		return "pass"
+	case Fail:
+		return "fail"
+	case SomeIssues:
+		return "warn"
+	case Skipped:
+		return "skipped"
+	default:
+		panic(int(s))
+	}
+}
+
 const (
 	Skipped auditCheckOutcome = iota
 	Pass
@@ -60,12 +90,15 @@ const (
 	SomeIssues
 )
 
+const (
+	auditAnalyzeFormatText  = "text"
+	auditAnalyzeFormatJSON  = "json"
+	auditAnalyzeFormatJUnit = "junit"
+)
+
 func configureAuditAnalyzeCommand(srv *fisk.CmdClause) {
 	c := &auditAnalyzeCmd{}
-	c.checks = []struct {
-		checkName string
-		checkFunc func(reader *archive.Reader) (auditCheckOutcome, error)
-	}{
+	c.checks = []auditCheck{
 		{
 			"Server health",
 			c.checkServerHealth,
@@ -110,6 +143,30 @@ func configureAuditAnalyzeCommand(srv *fisk.CmdClause) {
 			"Stream limits",
 			c.checkStreamLimits,
 		},
+		{
+			"Consumers pending messages",
+			c.checkConsumerPendingMessages,
+		},
+		{
+			"Consumers max ack pending",
+			c.checkConsumerMaxAckPending,
+		},
+		{
+			"Consumers redeliveries",
+			c.checkConsumerRedeliveries,
+		},
+		{
+			"Push consumers interest",
+			c.checkPushConsumersInterest,
+		},
+		{
+			"Lagging consumer replicas",
+			c.checkLaggingConsumerReplicas,
+		},
+		{
+			"Inactive consumers",
+			c.checkInactiveConsumers,
+		},
 		{
 			"Meta cluster offline replicas",
 			c.checkMetaClusterOfflineReplicas,
@@ -122,18 +179,88 @@ func configureAuditAnalyzeCommand(srv *fisk.CmdClause) {
 			"Configured gateways",
 			c.checkConfiguredGateways,
 		},
+		{
+			"Cluster configuration drift",
+			c.checkClusterConfigDrift,
+		},
+		{
+			"Server trends",
+			c.checkServerTrends,
+		},
+		{
+			"Server profiles",
+			c.checkServerProfiles,
+		},
 	}
 
+	analyzeHelp := `Additional checks can be defined in YAML files. Each check selects
+artifacts with a tags query (see 'nats audit query --help'), and an
+expression is evaluated for each one. Artifacts where the expression
+is true are reported using the message template.
+
+   checks:
+     - name: Streams with a single replica
+       query: type:stream_info
+       expression: config.num_replicas < 2
+       severity: warning
+       message: "{{ .tags.account }}/{{ .tags.stream }} on {{ .tags.server }}"
+
+Expressions and messages have access to the artifact JSON fields,
+the artifact tags (tags.<label>) and the artifact name (artifact).
+Severity is either warning (default) or critical.
+
+We use the expr language, see https://expr.medv.io/docs/Language-Definition
+
+Known and accepted issues can be recorded with --write-baseline FILE,
+later runs with --baseline FILE only report issues not in the file.
+Issues are matched by check name and subject (server, account/stream,
+etc.), so an accepted issue stays accepted as its values change.
+`
+
 	analyze := srv.Command("analyze", "perform checks against an archive created by the 'gather' subcommand").Action(c.analyze)
+	analyze.HelpLong(analyzeHelp)
 	analyze.Arg("archive", "path to input archive to analyze").Required().ExistingFileVar(&c.archivePath)
 	analyze.Flag("limit", "How many example issues to display for each failed check (Set to 0 to show all)").Default("5").UintVar(&c.exampleIssuesLimit)
+	analyze.Flag("format", "Output format (text, json, junit)").Default(auditAnalyzeFormatText).EnumVar(&c.format, auditAnalyzeFormatText, auditAnalyzeFormatJSON, auditAnalyzeFormatJUnit)
+	analyze.Flag("fail-on", "Exit with an error if any check has this outcome or worse (none, warn, fail)").Default("none").EnumVar(&c.failOn, "none", "warn", "fail")
+	analyze.Flag("baseline", "File of accepted issues to ignore, created with --write-baseline").PlaceHolder("FILE").ExistingFileVar(&c.baselineFile)
+	analyze.Flag("write-baseline", "Write all issues found to a baseline file, accepting them in future runs").PlaceHolder("FILE").StringVar(&c.writeBaselineFile)
+	analyze.Flag("checks", "YAML file with additional checks (can be repeated)").PlaceHolder("FILE").ExistingFilesVar(&c.checksFiles)
+	c.keys.configureFlags(analyze)
 	// Hidden flags
 	analyze.Flag("very-verbose", "Enable debug console messages during analysis").Hidden().BoolVar(&c.veryVerbose)
 }
 
 func (cmd *auditAnalyzeCmd) analyze(_ *fisk.ParseContext) error {
+	// Load user-defined checks, they run after the built-in ones
+	for _, checksFile := range cmd.checksFiles {
+		customChecks, err := loadAuditCustomChecks(checksFile)
+		if err != nil {
+			return err
+		}
+		for _, customCheck := range customChecks {
+			customCheck := customCheck
+			cmd.checks = append(cmd.checks, auditCheck{
+				customCheck.config.Name,
+				func(r *archive.Reader) (auditCheckOutcome, error) {
+					return customCheck.run(cmd, r)
+				},
+			})
+		}
+	}
+
+	var baseline *auditBaseline
+	if cmd.baselineFile != "" {
+		var err error
+		baseline, err = loadAuditBaseline(cmd.baselineFile)
+		if err != nil {
+			return err
+		}
+	}
+	newBaseline := &auditBaseline{Issues: []auditBaselineIssue{}}
+
 	// Open archive
-	ar, err := archive.NewReader(cmd.archivePath)
+	ar, err := cmd.keys.openArchiveQuietly(cmd.archivePath)
 	if err != nil {
 		return err
 	}
@@ -144,26 +271,26 @@ func (cmd *auditAnalyzeCmd) analyze(_ *fisk.ParseContext) error {
 		}
 	}()
 
-	// Prepare table of check and their outcome
-	type checkSummary struct {
-		name    string
-		outcome auditCheckOutcome
+	if cmd.format == auditAnalyzeFormatText {
+		warnRecoveredArchive(ar, cmd.archivePath)
+	}
+
+	if ar.Signer() != "" && cmd.format == auditAnalyzeFormatText {
+		fmt.Printf("Archive signature verified, signed by %s\n", ar.Signer())
+	}
+
+	report := &auditAnalyzeReport{
+		Archive: cmd.archivePath,
+		Signer:  ar.Signer(),
+		Checks:  make([]*auditCheckResult, len(cmd.checks)),
 	}
-	summaryTable := make([]checkSummary, len(cmd.checks))
 	for i, check := range cmd.checks {
 		// Initialize all to skipped
-		summaryTable[i] = checkSummary{
-			name:    check.checkName,
-			outcome: Skipped,
+		report.Checks[i] = &auditCheckResult{
+			Name:    check.checkName,
+			Outcome: Skipped,
 		}
 	}
-	// Always print summary on exit
-	defer func() {
-		fmt.Printf("\nSummary:\n")
-		for _, s := range summaryTable {
-			fmt.Printf("%s: %s\n", s.outcome.badge(), s.name)
-		}
-	}()
 
 	// Run all configured checks
 	for i, check := range cmd.checks {
@@ -171,24 +298,93 @@ func (cmd *auditAnalyzeCmd) analyze(_ *fisk.ParseContext) error {
 		cmd.resetExampleIssues()
 		outcome, err := check.checkFunc(ar)
 		if err != nil {
-			return fmt.Errorf("check '%s' error: %w", check.checkName, err)
-		}
-		if len(cmd.exampleIssues) > 0 {
-			for _, example := range cmd.exampleIssues {
-				// NOTE: Do not use printf here or percentage signs in the string will be (wrongly) interpreted.
-				// Must print string as-is with println or similar.
-				fmt.Println("   - " + example)
+			err = fmt.Errorf("check '%s' error: %w", check.checkName, err)
+			// Emit the checks completed so far, the failed check and those after it are reported as skipped
+			report.Checks[i].Messages = append(cmd.checkMessages, err.Error())
+			if printErr := cmd.printReport(report); printErr != nil {
+				return printErr
 			}
-			if cmd.omittedExampleIssues > 0 {
-				fmt.Printf("   - ...%d more...\n", cmd.omittedExampleIssues)
+			return err
+		}
+		newBaseline.add(check.checkName, cmd.exampleIssues)
+		if baseline != nil {
+			var accepted int
+			cmd.exampleIssues, accepted = baseline.filter(check.checkName, cmd.exampleIssues)
+			if accepted > 0 {
+				cmd.logInfo("Ignoring %d issues accepted in baseline", accepted)
+				report.Checks[i].AcceptedCount = accepted
+				// Downgrade checks whose issues are all accepted
+				if len(cmd.exampleIssues) == 0 && (outcome == SomeIssues || outcome == Fail) {
+					outcome = Pass
+				}
 			}
 		}
-		summaryTable[i].outcome = outcome
+		if cmd.format == auditAnalyzeFormatText {
+			cmd.printExampleIssues()
+		}
+		report.Checks[i].Outcome = outcome
+		report.Checks[i].Issues = cmd.exampleIssues
+		report.Checks[i].IssuesCount = len(cmd.exampleIssues)
+		report.Checks[i].Messages = cmd.checkMessages
+	}
+
+	if cmd.writeBaselineFile != "" {
+		err = newBaseline.write(cmd.writeBaselineFile)
+		if err != nil {
+			return err
+		}
+		if cmd.format == auditAnalyzeFormatText {
+			fmt.Printf("\nWrote %d accepted issues to baseline %s\n", len(newBaseline.Issues), cmd.writeBaselineFile)
+		}
+	}
+
+	err = cmd.printReport(report)
+	if err != nil {
+		return err
+	}
+
+	// Issues are all accepted when writing a baseline
+	if failed := report.failedChecks(cmd.failOn); failed > 0 && cmd.writeBaselineFile == "" {
+		return fmt.Errorf("%d checks reported issues", failed)
 	}
 
 	return nil
 }
 
+// printReport prints the report in the selected output format
+func (cmd *auditAnalyzeCmd) printReport(report *auditAnalyzeReport) error {
+	switch cmd.format {
+	case auditAnalyzeFormatJSON:
+		return printJSON(report.withCounts())
+	case auditAnalyzeFormatJUnit:
+		return report.printJUnit(cmd.failOn)
+	default:
+		cmd.printSummary(report)
+		return nil
+	}
+}
+
+// printExampleIssues prints the issues found by the last check, up to the configured limit
+func (cmd *auditAnalyzeCmd) printExampleIssues() {
+	for i, example := range cmd.exampleIssues {
+		if cmd.exampleIssuesLimit > 0 && i >= int(cmd.exampleIssuesLimit) {
+			fmt.Printf("   - ...%d more...\n", len(cmd.exampleIssues)-i)
+			break
+		}
+		// NOTE: Do not use printf here or percentage signs in the string will be (wrongly) interpreted.
+		// Must print string as-is with println or similar.
+		fmt.Println("   - " + example.Message)
+	}
+}
+
+// printSummary prints the outcome of each check
+func (cmd *auditAnalyzeCmd) printSummary(report *auditAnalyzeReport) {
+	fmt.Printf("\nSummary:\n")
+	for _, check := range report.Checks {
+		fmt.Printf("%s: %s\n", check.Outcome.badge(), check.Name)
+	}
+}
+
 // checkServerVersions verify all known servers are running the same software version
 func (cmd *auditAnalyzeCmd) checkServerVersions(r *archive.Reader) (auditCheckOutcome, error) {
 	versionsToServersMap := make(map[string][]string)
@@ -216,7 +412,7 @@ func (cmd *auditAnalyzeCmd) checkServerVersions(r *archive.Reader) (auditCheckOu
 				// First time encountering this version, create map entry
 				versionsToServersMap[version] = []string{}
 				// Add one example server for each version
-				cmd.addExampleIssue("%s - %s", serverName, version)
+				cmd.addExampleIssue(version, "%s - %s", serverName, version)
 			}
 			// Add this server to the list running this version
 			versionsToServersMap[version] = append(versionsToServersMap[version], serverName)
@@ -264,7 +460,7 @@ func (cmd *auditAnalyzeCmd) checkServerHealth(r *archive.Reader) (auditCheckOutc
 			}
 
 			if health.Status != "ok" {
-				cmd.addExampleIssue("%s: %d - %s", serverName, health.StatusCode, health.Status)
+				cmd.addExampleIssue(clusterName+"/"+serverName, "%s: %d - %s", serverName, health.StatusCode, health.Status)
 				notHealthy += 1
 			} else {
 				healthy += 1
@@ -299,7 +495,7 @@ func (cmd *auditAnalyzeCmd) checkSlowConsumers(r *archive.Reader) (auditCheckOut
 			}
 
 			if slowConsumers := serverVarz.SlowConsumers; slowConsumers > 0 {
-				cmd.addExampleIssue("%s/%s: %d slow consumers", clusterName, serverName, slowConsumers)
+				cmd.addExampleIssue(clusterName+"/"+serverName, "%s/%s: %d slow consumers", clusterName, serverName, slowConsumers)
 				totalSlowConsumers += slowConsumers
 			}
 		}
@@ -357,6 +553,7 @@ func (cmd *auditAnalyzeCmd) checkClusterMemoryUsageOutliers(r *archive.Reader) (
 		for serverName, serverMemoryUsage := range clusterMemoryUsageMap {
 			if serverMemoryUsage > threshold {
 				cmd.addExampleIssue(
+					clusterName+"/"+serverName,
 					"Cluster %s avg: %s, server %s: %s",
 					clusterName,
 					fiBytes(uint64(clusterMemoryUsageMean)),
@@ -480,6 +677,7 @@ func (cmd *auditAnalyzeCmd) checkLaggingStreamReplicas(r *archive.Reader) (audit
 					lastSeq := streamDetail.State.LastSeq
 					if lastSeq < threshold {
 						cmd.addExampleIssue(
+							accountName+"/"+streamName+"/"+serverName,
 							"%s/%s server %s lastSequence: %d is behind highest lastSequence: %d on server: %s",
 							accountName,
 							streamName,
@@ -528,7 +726,7 @@ func (cmd *auditAnalyzeCmd) checkCpuUsage(r *archive.Reader) (auditCheckOutcome,
 			averageCpuUtilization := serverVarz.CPU / float64(serverVarz.Cores)
 
 			if averageCpuUtilization > cpuThreshold {
-				cmd.addExampleIssue("%s - %s: %.1f%%", clusterName, serverName, averageCpuUtilization)
+				cmd.addExampleIssue(clusterName+"/"+serverName, "%s - %s: %.1f%%", clusterName, serverName, averageCpuUtilization)
 			}
 		}
 	}
@@ -568,7 +766,7 @@ func (cmd *auditAnalyzeCmd) checkHighSubjectCardinalityStreams(r *archive.Reader
 				}
 
 				if streamDetails.State.NumSubjects > numSubjectsThreshold {
-					cmd.addExampleIssue("%s/%s: %d subjects", accountName, streamName, streamDetails.State.NumSubjects)
+					cmd.addExampleIssue(accountName+"/"+streamName, "%s/%s: %d subjects", accountName, streamName, streamDetails.State.NumSubjects)
 					continue // no need to check other servers for this stream
 				}
 			}
@@ -605,7 +803,7 @@ func (cmd *auditAnalyzeCmd) checkHighHAAssetCardinality(r *archive.Reader) (audi
 			}
 
 			if serverJSInfo.HAAssets > haAssetsThreshold {
-				cmd.addExampleIssue("%s: %d HA assets", serverName, serverJSInfo.HAAssets)
+				cmd.addExampleIssue(clusterName+"/"+serverName, "%s: %d HA assets", serverName, serverJSInfo.HAAssets)
 			}
 		}
 	}
@@ -643,14 +841,14 @@ func (cmd *auditAnalyzeCmd) checkResourceLimits(r *archive.Reader) (auditCheckOu
 			if serverJSInfo.ReservedMemory > 0 {
 				threshold := uint64(float64(serverJSInfo.ReservedMemory) * usageThreshold)
 				if serverJSInfo.Memory > threshold {
-					cmd.addExampleIssue("%s memory usage: %s of %s", serverName, fiBytes(serverJSInfo.Memory), fiBytes(serverJSInfo.ReservedMemory))
+					cmd.addExampleIssue(clusterName+"/"+serverName+"/memory", "%s memory usage: %s of %s", serverName, fiBytes(serverJSInfo.Memory), fiBytes(serverJSInfo.ReservedMemory))
 				}
 			}
 
 			if serverJSInfo.ReservedStore > 0 {
 				threshold := uint64(float64(serverJSInfo.ReservedStore) * usageThreshold)
 				if serverJSInfo.Store > threshold {
-					cmd.addExampleIssue("%s store usage: %s of %s", serverName, fiBytes(serverJSInfo.Store), fiBytes(serverJSInfo.ReservedStore))
+					cmd.addExampleIssue(clusterName+"/"+serverName+"/store", "%s store usage: %s of %s", serverName, fiBytes(serverJSInfo.Store), fiBytes(serverJSInfo.ReservedStore))
 				}
 			}
 		}
@@ -681,6 +879,7 @@ func (cmd *auditAnalyzeCmd) checkAccountLimits(r *archive.Reader) (auditCheckOut
 		threshold := int64(float64(limit) * percentThreshold)
 		if value > threshold {
 			cmd.addExampleIssue(
+				accountName+"/"+serverName+"/"+limitName,
 				"account %s (on %s) using %.1f%% of %s limit (%d/%d)",
 				accountName,
 				serverName,
@@ -797,6 +996,7 @@ func (cmd *auditAnalyzeCmd) checkStreamLimits(r *archive.Reader) (auditCheckOutc
 		threshold := int64(float64(limit) * percentThreshold)
 		if value > threshold {
 			cmd.addExampleIssue(
+				accountName+"/"+streamName+"/"+serverName+"/"+limitName,
 				"stream %s (in %s on %s) using %.1f%% of %s limit (%d/%d)",
 				streamName,
 				accountName,
@@ -909,6 +1109,7 @@ func (cmd *auditAnalyzeCmd) checkMetaClusterOfflineReplicas(r *archive.Reader) (
 			for _, peerInfo := range serverVarz.JetStream.Meta.Replicas {
 				if peerInfo.Offline {
 					cmd.addExampleIssue(
+						clusterName+"/"+serverName+"/"+peerInfo.Name,
 						"%s - %s reports meta peer %s as offline",
 						clusterName,
 						serverName,
@@ -978,7 +1179,7 @@ func (cmd *auditAnalyzeCmd) checkMetaClusterLeader(r *archive.Reader) (auditChec
 		}
 
 		if len(leaderFollowers) > 1 {
-			cmd.addExampleIssue("Members of %s disagree on meta leader (%v)", clusterName, leaderFollowers)
+			cmd.addExampleIssue(clusterName, "Members of %s disagree on meta leader (%v)", clusterName, leaderFollowers)
 		}
 	}
 
@@ -1061,6 +1262,7 @@ func (cmd *auditAnalyzeCmd) checkConfiguredGateways(r *archive.Reader) (auditChe
 					)
 					if !reflect.DeepEqual(targetClusterNames, previousTargetClusterNames) {
 						cmd.addExampleIssue(
+							clusterName+"/"+t.gatewayType,
 							"Cluster %s, %s gateways server %s: %v != server %s: %v",
 							clusterName,
 							t.gatewayType,
@@ -1084,41 +1286,124 @@ func (cmd *auditAnalyzeCmd) checkConfiguredGateways(r *archive.Reader) (auditChe
 	return Pass, nil
 }
 
+// checkServerTrends compares the first and last snapshot of archives with multiple captures, and warns about servers
+// that accumulated slow consumers or whose CPU usage is rising. Also reports the message ingest rate of each cluster.
+func (cmd *auditAnalyzeCmd) checkServerTrends(r *archive.Reader) (auditCheckOutcome, error) {
+	const (
+		cpuIncreaseThreshold = 25.0 // Warn if CPU usage (per core) increased by more than 25 points
+	)
+
+	snapshots := r.GetSnapshots()
+	if len(snapshots) < 2 {
+		cmd.logInfo("Archive contains a single snapshot, capture more with 'gather --count'")
+		return Skipped, nil
+	}
+
+	firstSnapshotTag, lastSnapshotTag := archive.TagSnapshot(snapshots[0]), archive.TagSnapshot(snapshots[len(snapshots)-1])
+	elapsed := snapshots[len(snapshots)-1].Sub(snapshots[0])
+	serverVarsTag := archive.TagServerVars()
+
+	cmd.logInfo("Comparing %d snapshots captured over %s", len(snapshots), elapsed.Round(time.Second))
+
+	newSlowConsumers, risingCpuServers := int64(0), 0
+
+	for _, clusterName := range r.GetClusterNames() {
+		clusterTag := archive.TagCluster(clusterName)
+		clusterInMsgs := int64(0)
+
+		for _, serverName := range r.GetClusterServerNames(clusterName) {
+			serverTag := archive.TagServer(serverName)
+
+			var firstVarz, lastVarz server.Varz
+			err := r.Load(&firstVarz, clusterTag, serverTag, serverVarsTag, firstSnapshotTag)
+			if err == nil {
+				err = r.Load(&lastVarz, clusterTag, serverTag, serverVarsTag, lastSnapshotTag)
+			}
+			if errors.Is(err, archive.ErrNoMatches) {
+				cmd.logWarning("Artifact 'VARZ' is missing in first or last snapshot for server %s", serverName)
+				continue
+			} else if err != nil {
+				return Skipped, fmt.Errorf("failed to load VARZ for server %s: %w", serverName, err)
+			}
+
+			// Counters reset if the server restarted in between snapshots
+			if lastVarz.Start.After(firstVarz.Start) {
+				cmd.logWarning("Server %s restarted in between snapshots", serverName)
+				continue
+			}
+
+			if slowConsumers := lastVarz.SlowConsumers - firstVarz.SlowConsumers; slowConsumers > 0 {
+				cmd.addExampleIssue(clusterName+"/"+serverName+"/slow_consumers", "%s/%s: %d new slow consumers", clusterName, serverName, slowConsumers)
+				newSlowConsumers += slowConsumers
+			}
+
+			// Example: 100% -> 250% usage with 4 cores => 37.5 points increase
+			cpuIncrease := (lastVarz.CPU - firstVarz.CPU) / float64(max(lastVarz.Cores, 1))
+			if cpuIncrease > cpuIncreaseThreshold {
+				cmd.addExampleIssue(clusterName+"/"+serverName+"/cpu", "%s/%s: CPU usage increased from %.1f%% to %.1f%%", clusterName, serverName, firstVarz.CPU, lastVarz.CPU)
+				risingCpuServers += 1
+			}
+
+			clusterInMsgs += lastVarz.InMsgs - firstVarz.InMsgs
+		}
+
+		cmd.logInfo("Cluster %s ingest rate: %.1f msg/s", clusterName, float64(clusterInMsgs)/elapsed.Seconds())
+	}
+
+	if newSlowConsumers > 0 || risingCpuServers > 0 {
+		cmd.logIssue("New slow consumers: %d, servers with rising CPU usage: %d", newSlowConsumers, risingCpuServers)
+		return SomeIssues, nil
+	}
+
+	return Pass, nil
+}
+
 // logIssue for issues that need attention that need to be addressed
 func (cmd *auditAnalyzeCmd) logIssue(format string, a ...any) {
-	fmt.Printf("(!) "+format+"\n", a...)
+	cmd.logCheckMessage("(!) "+format, a...)
 }
 
 // logInfo for neutral and positive messages
 func (cmd *auditAnalyzeCmd) logInfo(format string, a ...any) {
-	fmt.Printf(format+"\n", a...)
+	cmd.logCheckMessage(format, a...)
 }
 
 // logWarning for issues running the check itself, but not serious enough to terminate with an error
 func (cmd *auditAnalyzeCmd) logWarning(format string, a ...any) {
-	fmt.Printf("Warning: "+format+"\n", a...)
+	cmd.logCheckMessage("Warning: "+format, a...)
+}
+
+// logCheckMessage prints a message of the running check, or saves it for the report if the output is not text
+func (cmd *auditAnalyzeCmd) logCheckMessage(format string, a ...any) {
+	if cmd.format == auditAnalyzeFormatText || cmd.format == "" {
+		fmt.Printf(format+"\n", a...)
+		return
+	}
+	cmd.checkMessages = append(cmd.checkMessages, fmt.Sprintf(format, a...))
 }
 
 // logDebug for very fine grained progress, disabled by default
 func (cmd *auditAnalyzeCmd) logDebug(format string, a ...any) {
 	if cmd.veryVerbose {
-		fmt.Printf("(DEBUG) "+format+"\n", a...)
+		fmt.Fprintf(os.Stderr, "(DEBUG) "+format+"\n", a...)
 	}
 }
 
 func (cmd *auditAnalyzeCmd) resetExampleIssues() {
-	cmd.exampleIssues = make([]string, 0)
-	cmd.omittedExampleIssues = 0
+	cmd.exampleIssues = make([]auditIssue, 0)
+	cmd.checkMessages = make([]string, 0)
 }
 
 func (cmd *auditAnalyzeCmd) examplesIssuesCount() int {
-	return len(cmd.exampleIssues) + cmd.omittedExampleIssues
+	return len(cmd.exampleIssues)
 }
 
-func (cmd *auditAnalyzeCmd) addExampleIssue(format string, a ...any) {
-	if cmd.exampleIssuesLimit == 0 || len(cmd.exampleIssues) < int(cmd.exampleIssuesLimit) {
-		cmd.exampleIssues = append(cmd.exampleIssues, fmt.Sprintf(format, a...))
-	} else {
-		cmd.omittedExampleIssues += 1
-	}
+// addExampleIssue records an issue found by the running check. All issues are recorded, but only some are displayed.
+// The key identifies the subject of the issue (e.g. account/stream/server) and must not change between runs, so the
+// issue can be accepted in a baseline.
+func (cmd *auditAnalyzeCmd) addExampleIssue(key string, format string, a ...any) {
+	cmd.exampleIssues = append(cmd.exampleIssues, auditIssue{
+		Key:     key,
+		Message: fmt.Sprintf(format, a...),
+	})
 }
@@ -0,0 +1,156 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+// auditConfigSettings lists the settings, as named by auditVarzConfig, compared across servers of the same cluster.
+// Settings expected to differ between servers (host, ports, URLs, tags, store directory, ...) are not included
+var auditConfigSettings = []string{
+	"max_payload",
+	"max_connections",
+	"max_subscriptions",
+	"max_pending",
+	"max_control_line",
+	"ping_interval",
+	"max_pings_out",
+	"write_deadline",
+	"auth_required",
+	"auth_timeout",
+	"system_account",
+	"trusted_operators",
+	"tls_required",
+	"tls_verify",
+	"tls_ocsp_peer_verify",
+	"tls_timeout",
+	"jetstream.enabled",
+	"jetstream.config.max_memory",
+	"jetstream.config.max_storage",
+	"jetstream.config.sync_interval",
+	"jetstream.config.sync_always",
+	"jetstream.config.domain",
+	"jetstream.config.compress_ok",
+	"jetstream.config.unique_tag",
+	"cluster.pool_size",
+	"cluster.auth_timeout",
+	"cluster.tls_required",
+	"cluster.tls_verify",
+	"cluster.tls_timeout",
+	"gateway.name",
+	"gateway.gateways",
+	"gateway.reject_unknown",
+	"gateway.auth_timeout",
+	"gateway.tls_required",
+	"gateway.tls_verify",
+	"gateway.tls_timeout",
+	"leafnode.remotes",
+	"leafnode.auth_timeout",
+	"leafnode.tls_required",
+	"leafnode.tls_verify",
+	"leafnode.tls_timeout",
+	"leafnode.tls_ocsp_peer_verify",
+}
+
+// checkClusterConfigDrift verify that servers of the same cluster are configured with the same settings
+func (cmd *auditAnalyzeCmd) checkClusterConfigDrift(r *archive.Reader) (auditCheckOutcome, error) {
+	clustersInspected, settingsWithDrift := 0, 0
+
+	for _, clusterName := range r.GetClusterNames() {
+		clusterTag := archive.TagCluster(clusterName)
+
+		serversConfig := make(map[string]map[string]string)
+		for _, serverName := range r.GetClusterServerNames(clusterName) {
+			serverTag := archive.TagServer(serverName)
+
+			var serverVarz server.Varz
+			err := r.Load(&serverVarz, clusterTag, serverTag, archive.TagServerVars())
+			if errors.Is(err, archive.ErrNoMatches) {
+				cmd.logWarning("Artifact 'VARZ' is missing for server %s", serverName)
+				continue
+			} else if err != nil {
+				return Skipped, fmt.Errorf("failed to load variables for server %s: %w", serverName, err)
+			}
+
+			serverConfig, err := auditVarzConfig(&serverVarz)
+			if err != nil {
+				return Skipped, fmt.Errorf("failed to process configuration of server %s: %w", serverName, err)
+			}
+			serversConfig[serverName] = serverConfig
+		}
+
+		if len(serversConfig) < 2 {
+			cmd.logDebug("Cluster %s has less than 2 servers, skipping", clusterName)
+			continue
+		}
+		clustersInspected += 1
+
+		for _, setting := range auditConfigSettings {
+			// Group servers by value of the setting
+			valueToServers := make(map[string][]string)
+			for serverName, serverConfig := range serversConfig {
+				// JetStream settings do not apply to servers with JetStream disabled
+				if strings.HasPrefix(setting, "jetstream.config.") && serverConfig["jetstream.enabled"] != "true" {
+					continue
+				}
+				// Settings omitted from VARZ are unset
+				value, ok := serverConfig[setting]
+				if !ok || value == "" {
+					value = "unset"
+				}
+				valueToServers[value] = append(valueToServers[value], serverName)
+			}
+
+			if len(valueToServers) < 2 {
+				continue
+			}
+			settingsWithDrift += 1
+
+			values := make([]string, 0, len(valueToServers))
+			for value, serverNames := range valueToServers {
+				sort.Strings(serverNames)
+				values = append(values, fmt.Sprintf("%s on %s", value, strings.Join(serverNames, ", ")))
+			}
+			sort.Strings(values)
+
+			cmd.addExampleIssue(
+				clusterName+"/"+setting,
+				"Cluster %s %s: %s",
+				clusterName,
+				setting,
+				strings.Join(values, "; "),
+			)
+		}
+	}
+
+	if clustersInspected == 0 {
+		cmd.logInfo("No clusters with multiple servers found")
+		return Skipped, nil
+	}
+
+	if settingsWithDrift > 0 {
+		cmd.logIssue("Found %d settings with different values across servers of the same cluster", settingsWithDrift)
+		return SomeIssues, nil
+	}
+
+	cmd.logInfo("Inspected configuration of %d clusters", clustersInspected)
+	return Pass, nil
+}
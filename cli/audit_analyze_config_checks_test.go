@@ -0,0 +1,97 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+func TestAuditAnalyzeClusterConfigDrift(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+
+	varz := func(serverName string, modify func(v *server.Varz)) *server.Varz {
+		v := &server.Varz{
+			Name:          serverName,
+			Host:          serverName + ".example.net",
+			MaxPayload:    1024 * 1024,
+			WriteDeadline: 10 * time.Second,
+			JetStream:     server.JetStreamVarz{Config: &server.JetStreamConfig{MaxMemory: 1024, MaxStore: 4096, StoreDir: "/data/" + serverName}},
+			Gateway: server.GatewayOptsVarz{
+				Name:     "C1",
+				Gateways: []server.RemoteGatewayOptsVarz{{Name: "C2"}, {Name: "C3"}},
+			},
+			LeafNode: server.LeafNodeOptsVarz{
+				Remotes: []server.RemoteLeafOptsVarz{{LocalAccount: "A", URLs: []string{"nats://hub-a:7422", "nats://hub-b:7422"}}},
+			},
+		}
+		if modify != nil {
+			modify(v)
+		}
+		return v
+	}
+
+	servers := map[string]*server.Varz{
+		// C1 servers differ in max payload, JetStream and sync, n3 has the gateways and leafnode remote URLs in a
+		// different order (no drift)
+		"n1": varz("n1", nil),
+		"n2": varz("n2", func(v *server.Varz) {
+			v.MaxPayload = 8 * 1024 * 1024
+			v.JetStream.Config.SyncAlways = true
+		}),
+		"n3": varz("n3", func(v *server.Varz) {
+			v.Gateway.Gateways = []server.RemoteGatewayOptsVarz{{Name: "C3"}, {Name: "C2"}}
+			v.LeafNode.Remotes[0].URLs = []string{"nats://hub-b:7422", "nats://hub-a:7422"}
+		}),
+		"n4": varz("n4", func(v *server.Varz) { v.JetStream.Config = nil }),
+	}
+	for serverName, v := range servers {
+		err = aw.Add(v, archive.TagCluster("C1"), archive.TagServer(serverName), archive.TagServerVars())
+		checkErr(t, err, "could not add varz: %v", err)
+	}
+	// C2 has a single server, nothing to compare
+	err = aw.Add(varz("m1", nil), archive.TagCluster("C2"), archive.TagServer("m1"), archive.TagServerVars())
+	checkErr(t, err, "could not add varz: %v", err)
+
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	ar, err := archive.NewReader(archivePath)
+	checkErr(t, err, "could not open archive: %v", err)
+	defer ar.Close()
+
+	cmd := &auditAnalyzeCmd{}
+	cmd.resetExampleIssues()
+	outcome, err := cmd.checkClusterConfigDrift(ar)
+	checkErr(t, err, "check failed: %v", err)
+	if outcome != SomeIssues {
+		t.Fatalf("expected outcome %s, got %s", SomeIssues.badge(), outcome.badge())
+	}
+
+	expected := []auditIssue{
+		{"C1/max_payload", "Cluster C1 max_payload: 1048576 on n1, n3, n4; 8388608 on n2"},
+		{"C1/jetstream.enabled", "Cluster C1 jetstream.enabled: false on n4; true on n1, n2, n3"},
+		{"C1/jetstream.config.sync_always", "Cluster C1 jetstream.config.sync_always: true on n2; unset on n1, n3"},
+	}
+	if diff := cmp.Diff(expected, cmd.exampleIssues); diff != "" {
+		t.Fatalf("unexpected issues (-want +got):\n%s", diff)
+	}
+}
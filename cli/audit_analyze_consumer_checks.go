@@ -0,0 +1,271 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+// consumerReplicas is the state of a consumer as reported by each of the servers hosting it
+type consumerReplicas struct {
+	accountName  string
+	streamName   string
+	consumerName string
+	// replicas maps server name to the consumer info reported by that server
+	replicas map[string]*server.ConsumerInfo
+}
+
+// key identifies the consumer in issues
+func (c *consumerReplicas) key() string {
+	return c.accountName + "/" + c.streamName + "/" + c.consumerName
+}
+
+// leader returns the consumer info reported by the leader, or by any replica if the leader is unknown
+func (c *consumerReplicas) leader() (string, *server.ConsumerInfo) {
+	serverNames := sortedMapKeys(c.replicas)
+	for _, serverName := range serverNames {
+		consumer := c.replicas[serverName]
+		if consumer.Cluster != nil && consumer.Cluster.Leader == serverName {
+			return serverName, consumer
+		}
+	}
+	return serverNames[0], c.replicas[serverNames[0]]
+}
+
+// loadConsumers loads the consumers of all streams in the archive, consumers are captured as part of the stream
+// details of each stream replica (unless gather is configured to skip them).
+// Consumers are loaded on first use and cached for the other checks of the same archive.
+func (cmd *auditAnalyzeCmd) loadConsumers(r *archive.Reader) ([]*consumerReplicas, error) {
+	if cmd.consumersReader == r {
+		return cmd.consumers, nil
+	}
+
+	typeTag := archive.TagStreamInfo()
+	consumers := make([]*consumerReplicas, 0)
+
+	for _, accountName := range r.GetAccountNames() {
+		accountTag := archive.TagAccount(accountName)
+
+		for _, streamName := range r.GetAccountStreamNames(accountName) {
+			streamTag := archive.TagStream(streamName)
+			streamConsumers := make(map[string]*consumerReplicas)
+
+			for _, serverName := range r.GetStreamServerNames(accountName, streamName) {
+				serverTag := archive.TagServer(serverName)
+				streamDetails := &server.StreamDetail{}
+				err := r.Load(streamDetails, accountTag, streamTag, serverTag, typeTag)
+				if errors.Is(err, archive.ErrNoMatches) {
+					cmd.logWarning(
+						"Artifact not found: %s for stream %s in account %s by server %s",
+						typeTag.Value,
+						streamName,
+						accountName,
+						serverName,
+					)
+					continue
+				} else if err != nil {
+					return nil, fmt.Errorf("failed to lookup stream artifact: %w", err)
+				}
+
+				for _, consumer := range streamDetails.Consumer {
+					if consumer == nil {
+						continue
+					}
+					c, exists := streamConsumers[consumer.Name]
+					if !exists {
+						c = &consumerReplicas{
+							accountName:  accountName,
+							streamName:   streamName,
+							consumerName: consumer.Name,
+							replicas:     make(map[string]*server.ConsumerInfo),
+						}
+						streamConsumers[consumer.Name] = c
+					}
+					c.replicas[serverName] = consumer
+				}
+			}
+
+			consumerNames := make([]string, 0, len(streamConsumers))
+			for consumerName := range streamConsumers {
+				consumerNames = append(consumerNames, consumerName)
+			}
+			sort.Strings(consumerNames)
+			for _, consumerName := range consumerNames {
+				consumers = append(consumers, streamConsumers[consumerName])
+			}
+		}
+	}
+
+	cmd.logDebug("Found %d consumers", len(consumers))
+	cmd.consumers, cmd.consumersReader = consumers, r
+	return consumers, nil
+}
+
+// checkConsumers loads all consumers and runs the given check against each one, the check returns true if the
+// consumer has issues
+func (cmd *auditAnalyzeCmd) checkConsumers(r *archive.Reader, issueDescription string, check func(c *consumerReplicas) bool) (auditCheckOutcome, error) {
+	consumers, err := cmd.loadConsumers(r)
+	if err != nil {
+		return Skipped, err
+	}
+
+	if len(consumers) == 0 {
+		cmd.logInfo("No consumers found in archive")
+		return Skipped, nil
+	}
+
+	consumersWithIssues := 0
+	for _, consumer := range consumers {
+		if check(consumer) {
+			consumersWithIssues += 1
+		}
+	}
+
+	if consumersWithIssues > 0 {
+		cmd.logIssue("Found %d consumers %s", consumersWithIssues, issueDescription)
+		return SomeIssues, nil
+	}
+
+	cmd.logInfo("Inspected %d consumers", len(consumers))
+	return Pass, nil
+}
+
+// checkConsumerPendingMessages verify consumers are keeping up with their stream
+func (cmd *auditAnalyzeCmd) checkConsumerPendingMessages(r *archive.Reader) (auditCheckOutcome, error) {
+	const (
+		numPendingThreshold = 1_000_000
+	)
+
+	return cmd.checkConsumers(r, "with a large number of pending messages", func(c *consumerReplicas) bool {
+		serverName, consumer := c.leader()
+		if consumer.NumPending <= numPendingThreshold {
+			return false
+		}
+		cmd.addExampleIssue(c.key(), "%s (on %s): %d pending messages", c.key(), serverName, consumer.NumPending)
+		return true
+	})
+}
+
+// checkConsumerMaxAckPending verify consumers are not blocked waiting for acknowledgements
+func (cmd *auditAnalyzeCmd) checkConsumerMaxAckPending(r *archive.Reader) (auditCheckOutcome, error) {
+	return cmd.checkConsumers(r, "at the max ack pending limit", func(c *consumerReplicas) bool {
+		serverName, consumer := c.leader()
+		if consumer.Config == nil || consumer.Config.MaxAckPending <= 0 || consumer.NumAckPending < consumer.Config.MaxAckPending {
+			return false
+		}
+		cmd.addExampleIssue(c.key(), "%s (on %s): %d of %d max ack pending", c.key(), serverName, consumer.NumAckPending, consumer.Config.MaxAckPending)
+		return true
+	})
+}
+
+// checkConsumerRedeliveries verify consumers are not redelivering many messages
+func (cmd *auditAnalyzeCmd) checkConsumerRedeliveries(r *archive.Reader) (auditCheckOutcome, error) {
+	const (
+		numRedeliveredThreshold = 10_000
+	)
+
+	return cmd.checkConsumers(r, "with a high number of redeliveries", func(c *consumerReplicas) bool {
+		serverName, consumer := c.leader()
+		if consumer.NumRedelivered <= numRedeliveredThreshold {
+			return false
+		}
+		cmd.addExampleIssue(c.key(), "%s (on %s): %d messages redelivered", c.key(), serverName, consumer.NumRedelivered)
+		return true
+	})
+}
+
+// checkPushConsumersInterest verify push consumers have a subscriber on their deliver subject
+func (cmd *auditAnalyzeCmd) checkPushConsumersInterest(r *archive.Reader) (auditCheckOutcome, error) {
+	return cmd.checkConsumers(r, "without active interest", func(c *consumerReplicas) bool {
+		serverName, consumer := c.leader()
+		if consumer.Config == nil || consumer.Config.DeliverSubject == "" || consumer.PushBound {
+			return false
+		}
+		cmd.addExampleIssue(c.key(), "%s (on %s): no interest on deliver subject %s", c.key(), serverName, consumer.Config.DeliverSubject)
+		return true
+	})
+}
+
+// checkLaggingConsumerReplicas for each consumer check if some replica is too far behind the most advanced replica,
+// using the stream sequence of the ack floor
+func (cmd *auditAnalyzeCmd) checkLaggingConsumerReplicas(r *archive.Reader) (auditCheckOutcome, error) {
+	const (
+		ackFloorLagThreshold = 0.1 // Warn if 10% or more behind highest known ack floor
+	)
+
+	return cmd.checkConsumers(r, "with lagging replicas", func(c *consumerReplicas) bool {
+		highestAckFloor, highestAckFloorServer := uint64(0), ""
+		for _, serverName := range sortedMapKeys(c.replicas) {
+			consumer := c.replicas[serverName]
+			if consumer.AckFloor.Stream > highestAckFloor {
+				highestAckFloor = consumer.AckFloor.Stream
+				highestAckFloorServer = serverName
+			}
+		}
+
+		threshold := highestAckFloor - uint64(float64(highestAckFloor)*ackFloorLagThreshold)
+		lagging := false
+		for _, serverName := range sortedMapKeys(c.replicas) {
+			consumer := c.replicas[serverName]
+			if consumer.AckFloor.Stream < threshold {
+				cmd.addExampleIssue(
+					c.key()+"/"+serverName,
+					"%s server %s ack floor: %d is behind highest ack floor: %d on server: %s",
+					c.key(),
+					serverName,
+					consumer.AckFloor.Stream,
+					highestAckFloor,
+					highestAckFloorServer,
+				)
+				lagging = true
+			}
+		}
+		return lagging
+	})
+}
+
+// checkInactiveConsumers verify consumers have delivered or received acknowledgements recently
+func (cmd *auditAnalyzeCmd) checkInactiveConsumers(r *archive.Reader) (auditCheckOutcome, error) {
+	const (
+		inactivityThreshold = 7 * 24 * time.Hour
+	)
+
+	return cmd.checkConsumers(r, "inactive for a long time", func(c *consumerReplicas) bool {
+		serverName, consumer := c.leader()
+		if consumer.TimeStamp.IsZero() {
+			// Capture time unknown
+			return false
+		}
+
+		lastActive := consumer.Created
+		for _, last := range []*time.Time{consumer.Delivered.Last, consumer.AckFloor.Last} {
+			if last != nil && last.After(lastActive) {
+				lastActive = *last
+			}
+		}
+
+		inactivity := consumer.TimeStamp.Sub(lastActive)
+		if inactivity <= inactivityThreshold {
+			return false
+		}
+		cmd.addExampleIssue(c.key(), "%s (on %s): last active %s ago", c.key(), serverName, f(inactivity))
+		return true
+	})
+}
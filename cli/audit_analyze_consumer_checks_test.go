@@ -0,0 +1,118 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+func TestAuditAnalyzeConsumerChecks(t *testing.T) {
+	captured := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	lastWeek := captured.Add(-8 * 24 * time.Hour)
+	yesterday := captured.Add(-24 * time.Hour)
+
+	// consumer creates the info of a healthy consumer replica, the modifier introduces issues
+	consumer := func(name string, leader string, modify func(ci *server.ConsumerInfo)) *server.ConsumerInfo {
+		ci := &server.ConsumerInfo{
+			Stream:    "ORDERS",
+			Name:      name,
+			Created:   lastWeek,
+			Config:    &server.ConsumerConfig{Durable: name, AckPolicy: server.AckExplicit, MaxAckPending: 1000},
+			Delivered: server.SequenceInfo{Consumer: 1000, Stream: 1000, Last: &yesterday},
+			AckFloor:  server.SequenceInfo{Consumer: 1000, Stream: 1000, Last: &yesterday},
+			Cluster:   &server.ClusterInfo{Name: "C1", Leader: leader},
+			TimeStamp: captured,
+		}
+		if modify != nil {
+			modify(ci)
+		}
+		return ci
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+
+	for _, serverName := range []string{"n1", "n2", "n3"} {
+		stream := &server.StreamDetail{
+			Name:    "ORDERS",
+			Cluster: &server.ClusterInfo{Name: "C1", Leader: "n1"},
+			Config:  &server.StreamConfig{Name: "ORDERS", Replicas: 3, Storage: server.FileStorage},
+			Consumer: []*server.ConsumerInfo{
+				consumer("HEALTHY", "n1", nil),
+				consumer("PENDING", "n1", func(ci *server.ConsumerInfo) { ci.NumPending = 2_000_000 }),
+				consumer("BLOCKED", "n2", func(ci *server.ConsumerInfo) { ci.NumAckPending = 1000 }),
+				consumer("REDELIVERING", "n1", func(ci *server.ConsumerInfo) { ci.NumRedelivered = 50_000 }),
+				consumer("PUSH", "n1", func(ci *server.ConsumerInfo) { ci.Config.DeliverSubject = "deliver.push" }),
+				consumer("LAGGING", "n1", func(ci *server.ConsumerInfo) {
+					if serverName == "n3" {
+						ci.AckFloor.Stream = 10
+					}
+				}),
+				consumer("IDLE", "n1", func(ci *server.ConsumerInfo) {
+					ci.Delivered.Last = nil
+					ci.AckFloor.Last = &lastWeek
+				}),
+			},
+		}
+		err = aw.Add(stream, archive.TagCluster("C1"), archive.TagServer(serverName), archive.TagAccount("ACME"), archive.TagStream("ORDERS"), archive.TagStreamInfo())
+		checkErr(t, err, "could not add stream: %v", err)
+	}
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	ar, err := archive.NewReader(archivePath)
+	checkErr(t, err, "could not open archive: %v", err)
+	defer ar.Close()
+
+	cmd := &auditAnalyzeCmd{}
+	checks := []struct {
+		name      string
+		checkFunc func(r *archive.Reader) (auditCheckOutcome, error)
+		keys      []string
+	}{
+		{"pending", cmd.checkConsumerPendingMessages, []string{"ACME/ORDERS/PENDING"}},
+		{"max ack pending", cmd.checkConsumerMaxAckPending, []string{"ACME/ORDERS/BLOCKED"}},
+		{"redeliveries", cmd.checkConsumerRedeliveries, []string{"ACME/ORDERS/REDELIVERING"}},
+		{"push interest", cmd.checkPushConsumersInterest, []string{"ACME/ORDERS/PUSH"}},
+		{"lagging replicas", cmd.checkLaggingConsumerReplicas, []string{"ACME/ORDERS/LAGGING/n3"}},
+		{"inactive", cmd.checkInactiveConsumers, []string{"ACME/ORDERS/IDLE"}},
+	}
+
+	for _, check := range checks {
+		cmd.resetExampleIssues()
+		outcome, err := check.checkFunc(ar)
+		checkErr(t, err, "check %s failed: %v", check.name, err)
+		if outcome != SomeIssues {
+			t.Fatalf("check %s: expected outcome %s, got %s", check.name, SomeIssues.badge(), outcome.badge())
+		}
+		keys := []string{}
+		for _, issue := range cmd.exampleIssues {
+			keys = append(keys, issue.Key)
+		}
+		if diff := cmp.Diff(check.keys, keys); diff != "" {
+			t.Fatalf("check %s: unexpected issues (-want +got):\n%s", check.name, diff)
+		}
+	}
+
+	if cmd.consumersReader != ar || len(cmd.consumers) != 7 {
+		t.Fatalf("expected the 7 consumers to be loaded once and shared by all checks, got %d", len(cmd.consumers))
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"github.com/nats-io/natscli/archive"
+)
+
+// checkServerProfiles verify that servers in the same cluster have a similar number of goroutines and heap usage,
+// based on the profiles captured by gather. Use 'audit profile' to see the details.
+func (cmd *auditAnalyzeCmd) checkServerProfiles(r *archive.Reader) (auditCheckOutcome, error) {
+	summaries, err := loadAuditProfileSummaries(r)
+	if err != nil {
+		return Skipped, err
+	}
+
+	if len(summaries) == 0 {
+		cmd.logInfo("No server profiles found in archive")
+		return Skipped, nil
+	}
+
+	outliers := auditProfileOutliers(summaries)
+	for _, outlier := range outliers {
+		cmd.addExampleIssue(outlier.Key, "%s", outlier.Message)
+	}
+
+	if len(outliers) > 0 {
+		cmd.logIssue("Found %d servers with goroutines or heap usage much above their cluster median", len(outliers))
+		return SomeIssues, nil
+	}
+
+	cmd.logInfo("Inspected profiles of %d servers", len(summaries))
+	return Pass, nil
+}
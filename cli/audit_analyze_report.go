@@ -0,0 +1,190 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// auditAnalyzeReport is the outcome of all checks run by analyze, used for machine-readable output
+type auditAnalyzeReport struct {
+	Archive string              `json:"archive"`
+	Signer  string              `json:"signer,omitempty"`
+	Checks  []*auditCheckResult `json:"checks"`
+	Counts  auditAnalyzeCounts  `json:"counts"`
+}
+
+// auditAnalyzeCounts is the number of checks with each outcome
+type auditAnalyzeCounts struct {
+	Pass    int `json:"pass"`
+	Warn    int `json:"warn"`
+	Fail    int `json:"fail"`
+	Skipped int `json:"skipped"`
+}
+
+// auditCheckResult is the outcome of a single check, with all the issues it found and the messages it logged
+type auditCheckResult struct {
+	Name          string            `json:"name"`
+	Outcome       auditCheckOutcome `json:"outcome"`
+	IssuesCount   int               `json:"issues_count"`
+	AcceptedCount int               `json:"accepted_count,omitempty"`
+	Issues        []auditIssue      `json:"issues"`
+	Messages      []string          `json:"messages"`
+}
+
+// auditIssue is an issue found by a check
+type auditIssue struct {
+	// Key identifies the subject of the issue, stable across runs
+	Key     string `json:"key"`
+	Message string `json:"message"`
+}
+
+// MarshalText encodes the outcome by name in JSON reports
+func (s auditCheckOutcome) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// withCounts fills in the number of checks with each outcome
+func (r *auditAnalyzeReport) withCounts() *auditAnalyzeReport {
+	r.Counts = auditAnalyzeCounts{}
+	for _, check := range r.Checks {
+		switch check.Outcome {
+		case Pass:
+			r.Counts.Pass += 1
+		case SomeIssues:
+			r.Counts.Warn += 1
+		case Fail:
+			r.Counts.Fail += 1
+		case Skipped:
+			r.Counts.Skipped += 1
+		}
+	}
+	return r
+}
+
+// failedChecks returns the number of checks with an outcome at or above the given threshold (none, warn or fail)
+func (r *auditAnalyzeReport) failedChecks(failOn string) int {
+	failed := 0
+	for _, check := range r.Checks {
+		if check.failed(failOn) {
+			failed += 1
+		}
+	}
+	return failed
+}
+
+func (c *auditCheckResult) failed(failOn string) bool {
+	switch failOn {
+	case "warn":
+		return c.Outcome == Fail || c.Outcome == SomeIssues
+	case "fail":
+		return c.Outcome == Fail
+	default:
+		return false
+	}
+}
+
+type auditJUnitTestSuites struct {
+	XMLName  xml.Name              `xml:"testsuites"`
+	Name     string                `xml:"name,attr"`
+	Tests    int                   `xml:"tests,attr"`
+	Failures int                   `xml:"failures,attr"`
+	Skipped  int                   `xml:"skipped,attr"`
+	Suites   []auditJUnitTestSuite `xml:"testsuite"`
+}
+
+type auditJUnitTestSuite struct {
+	Name     string               `xml:"name,attr"`
+	Tests    int                  `xml:"tests,attr"`
+	Failures int                  `xml:"failures,attr"`
+	Skipped  int                  `xml:"skipped,attr"`
+	Cases    []auditJUnitTestCase `xml:"testcase"`
+}
+
+type auditJUnitTestCase struct {
+	Name      string             `xml:"name,attr"`
+	ClassName string             `xml:"classname,attr"`
+	Failure   *auditJUnitFailure `xml:"failure,omitempty"`
+	Skipped   *struct{}          `xml:"skipped,omitempty"`
+	SystemOut string             `xml:"system-out,omitempty"`
+}
+
+type auditJUnitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Content string `xml:",chardata"`
+}
+
+func (c *auditCheckResult) issueMessages() string {
+	messages := make([]string, len(c.Issues))
+	for i, issue := range c.Issues {
+		messages[i] = issue.Message
+	}
+	return strings.Join(messages, "\n")
+}
+
+// junit converts the report to a JUnit XML document, checks with an outcome at or above the failOn threshold are
+// reported as failures
+func (r *auditAnalyzeReport) junit(failOn string) *auditJUnitTestSuites {
+	suite := auditJUnitTestSuite{
+		Name:  r.Archive,
+		Tests: len(r.Checks),
+		Cases: make([]auditJUnitTestCase, 0, len(r.Checks)),
+	}
+
+	for _, check := range r.Checks {
+		tc := auditJUnitTestCase{
+			Name:      check.Name,
+			ClassName: "audit.analyze",
+			SystemOut: strings.Join(check.Messages, "\n"),
+		}
+
+		switch {
+		case check.Outcome == Skipped:
+			tc.Skipped = &struct{}{}
+			suite.Skipped += 1
+		case check.failed(failOn):
+			tc.Failure = &auditJUnitFailure{
+				Message: fmt.Sprintf("%d issues found", check.IssuesCount),
+				Type:    check.Outcome.String(),
+				Content: check.issueMessages(),
+			}
+			suite.Failures += 1
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	return &auditJUnitTestSuites{
+		Name:     "nats audit analyze",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Suites:   []auditJUnitTestSuite{suite},
+	}
+}
+
+func (r *auditAnalyzeReport) printJUnit(failOn string) error {
+	j, err := xml.MarshalIndent(r.junit(failOn), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(xml.Header)
+	fmt.Println(string(j))
+
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/natscli/archive"
+)
+
+func testAuditAnalyzeReport() *auditAnalyzeReport {
+	return &auditAnalyzeReport{
+		Archive: "archive.zip",
+		Checks: []*auditCheckResult{
+			{Name: "Server health", Outcome: Pass, Issues: []auditIssue{}, Messages: []string{"All servers healthy"}},
+			{Name: "Slow consumers", Outcome: SomeIssues, IssuesCount: 2, Issues: []auditIssue{{"C1/n1", "n1: 3 slow consumers"}, {"C1/n2", "n2: 1 slow consumers"}}},
+			{Name: "Replicas", Outcome: Fail, IssuesCount: 1, Issues: []auditIssue{{"ACME/ORDERS", "ORDERS has 1 replica"}}},
+			{Name: "Gateways", Outcome: Skipped},
+		},
+	}
+}
+
+func TestAuditAnalyzeReportJSON(t *testing.T) {
+	j, err := json.Marshal(testAuditAnalyzeReport().withCounts())
+	checkErr(t, err, "could not encode report: %v", err)
+
+	var report struct {
+		Checks []struct {
+			Name    string `json:"name"`
+			Outcome string `json:"outcome"`
+			Count   int    `json:"issues_count"`
+		} `json:"checks"`
+		Counts map[string]int `json:"counts"`
+	}
+	err = json.Unmarshal(j, &report)
+	checkErr(t, err, "could not decode report: %v", err)
+
+	outcomes := []string{}
+	for _, check := range report.Checks {
+		outcomes = append(outcomes, check.Outcome)
+	}
+	if diff := cmp.Diff([]string{"pass", "warn", "fail", "skipped"}, outcomes); diff != "" {
+		t.Fatalf("unexpected outcomes (-want +got):\n%s", diff)
+	}
+	if report.Checks[1].Count != 2 {
+		t.Fatalf("expected 2 issues, got %d", report.Checks[1].Count)
+	}
+	if diff := cmp.Diff(map[string]int{"pass": 1, "warn": 1, "fail": 1, "skipped": 1}, report.Counts); diff != "" {
+		t.Fatalf("unexpected counts (-want +got):\n%s", diff)
+	}
+}
+
+func TestAuditAnalyzeReportFailOn(t *testing.T) {
+	report := testAuditAnalyzeReport()
+	for failOn, expected := range map[string]int{"none": 0, "warn": 2, "fail": 1} {
+		if failed := report.failedChecks(failOn); failed != expected {
+			t.Fatalf("fail-on %s: expected %d failed checks, got %d", failOn, expected, failed)
+		}
+	}
+}
+
+func TestAuditAnalyzeReportJUnit(t *testing.T) {
+	x, err := xml.Marshal(testAuditAnalyzeReport().junit("warn"))
+	checkErr(t, err, "could not encode report: %v", err)
+
+	var suites auditJUnitTestSuites
+	err = xml.Unmarshal(x, &suites)
+	checkErr(t, err, "could not decode report: %v", err)
+
+	if suites.Tests != 4 || suites.Failures != 2 || suites.Skipped != 1 {
+		t.Fatalf("unexpected totals: %d tests, %d failures, %d skipped", suites.Tests, suites.Failures, suites.Skipped)
+	}
+
+	cases := suites.Suites[0].Cases
+	if cases[0].Failure != nil || cases[0].Skipped != nil || cases[0].SystemOut != "All servers healthy" {
+		t.Fatalf("unexpected passing case: %+v", cases[0])
+	}
+	if cases[1].Failure == nil || cases[1].Failure.Type != "warn" || cases[1].Failure.Content != "n1: 3 slow consumers\nn2: 1 slow consumers" {
+		t.Fatalf("unexpected warning case: %+v", cases[1])
+	}
+	if cases[3].Skipped == nil {
+		t.Fatalf("expected skipped case: %+v", cases[3])
+	}
+}
+
+func TestAuditAnalyzeReportCheckError(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+	err = aw.Add(map[string]string{"name": "n1"}, archive.TagCluster("C1"), archive.TagServer("n1"), archive.TagServerVars())
+	checkErr(t, err, "could not add artifact: %v", err)
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	cmd := &auditAnalyzeCmd{
+		archivePath: archivePath,
+		format:      auditAnalyzeFormatJSON,
+		failOn:      "none",
+		checks: []auditCheck{
+			{"Passing", func(*archive.Reader) (auditCheckOutcome, error) { return Pass, nil }},
+			{"Broken", func(*archive.Reader) (auditCheckOutcome, error) { return Skipped, errors.New("boom") }},
+			{"Never run", func(*archive.Reader) (auditCheckOutcome, error) { return Pass, nil }},
+		},
+	}
+
+	stdout := os.Stdout
+	outReader, outWriter, _ := os.Pipe()
+	os.Stdout = outWriter
+	err = cmd.analyze(nil)
+	os.Stdout = stdout
+	outWriter.Close()
+
+	if err == nil || !strings.Contains(err.Error(), "check 'Broken' error: boom") {
+		t.Fatalf("expected the check error, got %v", err)
+	}
+
+	out, _ := io.ReadAll(outReader)
+	var report struct {
+		Checks []struct {
+			Outcome  string   `json:"outcome"`
+			Messages []string `json:"messages"`
+		} `json:"checks"`
+	}
+	err = json.Unmarshal(out, &report)
+	checkErr(t, err, "expected a partial report, could not decode %q: %v", out, err)
+
+	outcomes := []string{}
+	for _, check := range report.Checks {
+		outcomes = append(outcomes, check.Outcome)
+	}
+	if diff := cmp.Diff([]string{"pass", "skipped", "skipped"}, outcomes); diff != "" {
+		t.Fatalf("unexpected outcomes (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"check 'Broken' error: boom"}, report.Checks[1].Messages); diff != "" {
+		t.Fatalf("unexpected messages (-want +got):\n%s", diff)
+	}
+}
@@ -14,7 +14,12 @@
 package cli
 
 import (
+	"fmt"
+	"os"
 	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/natscli/archive"
 )
 
 func configureAuditCommand(app commandHost) {
@@ -22,6 +27,14 @@ func configureAuditCommand(app commandHost) {
 
 	configureAuditGatherCommand(srv)
 	configureAuditAnalyzeCommand(srv)
+	configureAuditDiffCommand(srv)
+	configureAuditExportCommand(srv)
+	configureAuditProfileCommand(srv)
+	configureAuditQueryCommand(srv)
+	configureAuditRedactCommand(srv)
+	configureAuditReplayCommand(srv)
+	configureAuditTopologyCommand(srv)
+	configureAuditVerifyCommand(srv)
 }
 
 func init() {
@@ -35,4 +48,110 @@ type auditMetadata struct {
 	ConnectURL             string    `json:"connect_url"`
 	UserName               string    `json:"user_name"`
 	CLIVersion             string    `json:"cli_version"`
+	// GatherScope lists the filters the capture was limited to, if any
+	GatherScope []string `json:"gather_scope,omitempty"`
+	// GatherFailures lists the artifacts that could not be captured
+	GatherFailures []auditGatherFailure `json:"gather_failures,omitempty"`
+}
+
+// auditArchiveKeys holds the keys used to open sealed archives and to verify signed archives
+type auditArchiveKeys struct {
+	keyFile        string
+	trustedSigners []string
+}
+
+func (k *auditArchiveKeys) configureFlags(cmd *fisk.CmdClause) {
+	cmd.Flag("key", "XKey seed file used to open sealed archives").PlaceHolder("FILE").ExistingFileVar(&k.keyFile)
+	cmd.Flag("signer", "Require archives to be signed by the given public NKey (can be repeated)").PlaceHolder("KEY").StringsVar(&k.trustedSigners)
+}
+
+// readerOptions creates the archive reader options to decrypt sealed archives and to verify signed archives.
+// The returned function wipes the key from memory, it must be called once the options are no longer in use.
+func (k *auditArchiveKeys) readerOptions() ([]archive.ReaderOption, func(), error) {
+	var opts []archive.ReaderOption
+	wipe := func() {}
+
+	if k.keyFile != "" {
+		seed, err := readKeyFile(k.keyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+		wipe = func() { wipeSlice(seed) }
+		opts = append(opts, archive.WithDecryptionKey(seed))
+	}
+
+	if len(k.trustedSigners) > 0 {
+		opts = append(opts, archive.WithTrustedSigners(k.trustedSigners...))
+	}
+
+	return opts, wipe, nil
+}
+
+// openArchive opens the archive at the given path, decrypting it and verifying its signature if necessary.
+// Warns on stderr if the archive is incomplete and its content was recovered.
+func (k *auditArchiveKeys) openArchive(archivePath string) (*archive.Reader, error) {
+	ar, err := k.openArchiveQuietly(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	warnRecoveredArchive(ar, archivePath)
+	return ar, nil
+}
+
+// openArchiveQuietly is like openArchive without warnings, for commands producing machine-readable output
+func (k *auditArchiveKeys) openArchiveQuietly(archivePath string) (*archive.Reader, error) {
+	opts, wipe, err := k.readerOptions()
+	if err != nil {
+		return nil, err
+	}
+	defer wipe()
+
+	ar, err := archive.NewReader(archivePath, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", archivePath, err)
+	}
+	return ar, nil
+}
+
+// warnRecoveredArchive warns on stderr if the archive was not closed properly and its content was recovered from the
+// artifacts written before it was interrupted
+func warnRecoveredArchive(ar *archive.Reader, archivePath string) {
+	if ar.Recovered() {
+		fmt.Fprintf(os.Stderr, "Warning: archive %s is incomplete, its content was recovered from %d artifacts\n", archivePath, len(ar.ListArtifacts()))
+	}
+}
+
+// auditArchiveProtection holds the options used to seal and sign archives being written
+type auditArchiveProtection struct {
+	sealRecipient string
+	signKeyFile   string
+}
+
+func (p *auditArchiveProtection) configureFlags(cmd *fisk.CmdClause) {
+	cmd.Flag("seal-to", "Encrypt the archive so that it can only be opened by the owner of this public XKey").PlaceHolder("XKEY").StringVar(&p.sealRecipient)
+	cmd.Flag("sign-key", "Sign the archive with the NKey seed in this file").PlaceHolder("FILE").ExistingFileVar(&p.signKeyFile)
+}
+
+// newWriter creates an archive writer for the given path, sealed and signed if requested
+func (p *auditArchiveProtection) newWriter(archivePath string) (*archive.Writer, error) {
+	var opts []archive.WriterOption
+
+	if p.sealRecipient != "" {
+		opts = append(opts, archive.SealTo(p.sealRecipient))
+	}
+
+	if p.signKeyFile != "" {
+		seed, err := readKeyFile(p.signKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		defer wipeSlice(seed)
+		opts = append(opts, archive.SignWith(seed))
+	}
+
+	aw, err := archive.NewWriter(archivePath, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive: %w", err)
+	}
+	return aw, nil
 }
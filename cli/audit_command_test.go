@@ -0,0 +1,80 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nats-io/natscli/archive"
+)
+
+func TestAuditOpenRecoveredArchive(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+	err = aw.Add(map[string]string{"name": "n1"}, archive.TagCluster("C1"), archive.TagServer("n1"), archive.TagServerVars())
+	checkErr(t, err, "could not add artifact: %v", err)
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	// Drop the end of central directory record, as if the writer was interrupted
+	fileInfo, err := os.Stat(archivePath)
+	checkErr(t, err, "could not stat archive: %v", err)
+	err = os.Truncate(archivePath, fileInfo.Size()-22)
+	checkErr(t, err, "could not truncate archive: %v", err)
+
+	// open captures what is written to stdout and stderr while opening the archive
+	open := func(openFunc func(string) (*archive.Reader, error)) (string, string) {
+		t.Helper()
+
+		stdout, stderr := os.Stdout, os.Stderr
+		outReader, outWriter, _ := os.Pipe()
+		errReader, errWriter, _ := os.Pipe()
+		os.Stdout, os.Stderr = outWriter, errWriter
+
+		ar, err := openFunc(archivePath)
+
+		os.Stdout, os.Stderr = stdout, stderr
+		outWriter.Close()
+		errWriter.Close()
+		checkErr(t, err, "could not open archive: %v", err)
+		defer ar.Close()
+		if !ar.Recovered() {
+			t.Fatalf("expected archive to be recovered")
+		}
+
+		out, _ := io.ReadAll(outReader)
+		errOut, _ := io.ReadAll(errReader)
+		return string(out), string(errOut)
+	}
+
+	keys := &auditArchiveKeys{}
+
+	out, errOut := open(keys.openArchive)
+	if out != "" {
+		t.Fatalf("expected nothing on stdout, got %q", out)
+	}
+	if !strings.Contains(errOut, "Warning: archive "+archivePath+" is incomplete") {
+		t.Fatalf("expected a warning on stderr, got %q", errOut)
+	}
+
+	out, errOut = open(keys.openArchiveQuietly)
+	if out != "" || errOut != "" {
+		t.Fatalf("expected no output, got %q and %q", out, errOut)
+	}
+}
@@ -0,0 +1,235 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"text/template"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/ghodss/yaml"
+	"github.com/nats-io/natscli/archive"
+)
+
+// auditCustomChecksFile is the format of a YAML file of user-defined checks, for example:
+//
+//	checks:
+//	  - name: Streams with a single replica
+//	    query: type:stream_info account:PROD
+//	    expression: config.num_replicas < 3
+//	    severity: warning
+//	    message: "{{ .tags.stream }} on {{ .tags.server }} has {{ .config.num_replicas }} replica(s)"
+type auditCustomChecksFile struct {
+	Checks []auditCustomCheckConfig `json:"checks"`
+}
+
+// auditCustomCheckConfig is the definition of a user-defined check.
+// The expression is evaluated for each (JSON) artifact matching the query, and must evaluate to true for artifacts
+// with an issue. The artifact fields are available in the expression and in the message template, as well as the
+// artifact tags (under 'tags', by label).
+type auditCustomCheckConfig struct {
+	Name       string `json:"name"`
+	Query      string `json:"query"`
+	Expression string `json:"expression"`
+	Severity   string `json:"severity"`
+	Message    string `json:"message"`
+}
+
+// auditCustomCheck is a user-defined check, ready to run against an archive
+type auditCustomCheck struct {
+	config  auditCustomCheckConfig
+	query   *archive.Query
+	program *vm.Program
+	message *template.Template
+	outcome auditCheckOutcome
+}
+
+const (
+	auditCustomCheckSeverityWarning  = "warning"
+	auditCustomCheckSeverityCritical = "critical"
+)
+
+// loadAuditCustomChecks loads and compiles the checks defined in the given YAML file
+func loadAuditCustomChecks(checksFile string) ([]*auditCustomCheck, error) {
+	content, err := os.ReadFile(checksFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var checksConfig auditCustomChecksFile
+	err = yaml.Unmarshal(content, &checksConfig)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checks file %s: %w", checksFile, err)
+	}
+
+	checks := make([]*auditCustomCheck, 0, len(checksConfig.Checks))
+	for i, config := range checksConfig.Checks {
+		check, err := newAuditCustomCheck(config)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check #%d in %s: %w", i+1, checksFile, err)
+		}
+		checks = append(checks, check)
+	}
+
+	return checks, nil
+}
+
+func newAuditCustomCheck(config auditCustomCheckConfig) (*auditCustomCheck, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if config.Expression == "" {
+		return nil, fmt.Errorf("check '%s' expression is required", config.Name)
+	}
+
+	check := &auditCustomCheck{
+		config: config,
+	}
+
+	switch config.Severity {
+	case auditCustomCheckSeverityWarning, "":
+		check.outcome = SomeIssues
+	case auditCustomCheckSeverityCritical:
+		check.outcome = Fail
+	default:
+		return nil, fmt.Errorf("check '%s' has unknown severity '%s' (must be %s or %s)", config.Name, config.Severity, auditCustomCheckSeverityWarning, auditCustomCheckSeverityCritical)
+	}
+
+	var err error
+	check.query, err = archive.ParseQuery(config.Query)
+	if err != nil {
+		return nil, fmt.Errorf("check '%s' has invalid query: %w", config.Name, err)
+	}
+
+	check.program, err = expr.Compile(config.Expression, expr.AsBool(), expr.AllowUndefinedVariables())
+	if err != nil {
+		return nil, fmt.Errorf("check '%s' has invalid expression: %w", config.Name, err)
+	}
+
+	message := config.Message
+	if message == "" {
+		message = "{{ .artifact }}"
+	}
+	check.message, err = template.New(config.Name).Option("missingkey=zero").Parse(message)
+	if err != nil {
+		return nil, fmt.Errorf("check '%s' has invalid message: %w", config.Name, err)
+	}
+
+	return check, nil
+}
+
+// run evaluates the check expression for each JSON artifact matching the check query, and adds an example issue for
+// each artifact where it evaluates to true. In archives with multiple snapshots, only the most recent capture of each
+// artifact is evaluated.
+func (c *auditCustomCheck) run(cmd *auditAnalyzeCmd, r *archive.Reader) (auditCheckOutcome, error) {
+	artifacts, err := c.latestArtifacts(cmd, r)
+	if err != nil {
+		return Skipped, err
+	}
+
+	inspected, issues := 0, 0
+	for _, key := range sortedMapKeys(artifacts) {
+		artifact := artifacts[key]
+
+		env := make(map[string]any)
+		err := r.LoadArtifact(&env, artifact.name)
+		if err != nil {
+			return Skipped, fmt.Errorf("failed to load artifact %s: %w", artifact.name, err)
+		}
+		env["tags"] = artifact.tags
+		env["artifact"] = artifact.name
+
+		out, err := expr.Run(c.program, env)
+		if err != nil {
+			cmd.logWarning("Failed to evaluate expression for artifact %s: %s", artifact.name, err)
+			continue
+		}
+		inspected += 1
+
+		if isIssue, ok := out.(bool); !ok || !isIssue {
+			continue
+		}
+
+		var message bytes.Buffer
+		err = c.message.Execute(&message, env)
+		if err != nil {
+			cmd.logWarning("Failed to render message for artifact %s: %s", artifact.name, err)
+			message.Reset()
+			message.WriteString(artifact.name)
+		}
+		cmd.addExampleIssue(key, "%s", strings.TrimSpace(message.String()))
+		issues += 1
+	}
+
+	if inspected == 0 {
+		cmd.logInfo("No artifacts matching: %s", c.query)
+		return Skipped, nil
+	}
+
+	if issues > 0 {
+		cmd.logIssue("Found %d issues in %d artifacts", issues, inspected)
+		return c.outcome, nil
+	}
+
+	cmd.logInfo("Inspected %d artifacts", inspected)
+	return Pass, nil
+}
+
+// auditCustomCheckArtifact is an artifact matching the query of a custom check
+type auditCustomCheckArtifact struct {
+	name     string
+	tags     map[string]string
+	snapshot string
+}
+
+// latestArtifacts returns the JSON artifacts matching the check query, keyed by the values of their tags except the
+// snapshot. Of the artifacts captured in multiple snapshots, the most recent capture is kept, like Reader.Load does.
+func (c *auditCustomCheck) latestArtifacts(cmd *auditAnalyzeCmd, r *archive.Reader) (map[string]*auditCustomCheckArtifact, error) {
+	artifacts := make(map[string]*auditCustomCheckArtifact)
+	for _, artifactName := range r.Query(c.query) {
+		if path.Ext(artifactName) != ".json" {
+			cmd.logDebug("Skipping non-JSON artifact %s", artifactName)
+			continue
+		}
+
+		tags, err := r.GetArtifactTags(artifactName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load artifact %s tags: %w", artifactName, err)
+		}
+
+		artifact := &auditCustomCheckArtifact{name: artifactName, tags: make(map[string]string, len(tags))}
+		keyParts := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			artifact.tags[string(tag.Name)] = tag.Value
+			if archive.IsSnapshotTag(tag) {
+				artifact.snapshot = tag.Value
+			} else {
+				keyParts = append(keyParts, tag.Value)
+			}
+		}
+
+		// Snapshot tag values sort chronologically
+		key := strings.Join(keyParts, "/")
+		if existing, found := artifacts[key]; !found || existing.snapshot < artifact.snapshot {
+			artifacts[key] = artifact
+		}
+	}
+
+	return artifacts, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+func TestAuditCustomChecks(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+
+	for streamName, replicas := range map[string]int{"ORDERS": 3, "EVENTS": 1} {
+		stream := &server.StreamDetail{
+			Name:   streamName,
+			Config: &server.StreamConfig{Name: streamName, Replicas: replicas, Storage: server.FileStorage},
+		}
+		err = aw.Add(stream, archive.TagCluster("C1"), archive.TagServer("n1"), archive.TagAccount("ACME"), archive.TagStream(streamName), archive.TagStreamInfo())
+		checkErr(t, err, "could not add stream: %v", err)
+	}
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	checksFile := filepath.Join(t.TempDir(), "checks.yaml")
+	err = os.WriteFile(checksFile, []byte(`
+checks:
+  - name: Single replica streams
+    query: type:stream_info account:ACME
+    expression: config.num_replicas < 2
+    severity: critical
+    message: "{{ .tags.account }}/{{ .tags.stream }} has {{ .config.num_replicas }} replica"
+  - name: Empty streams
+    query: type:stream_info
+    expression: state.messages == 0 && config.num_replicas > 1
+  - name: No matches
+    query: type:stream_info account:OTHER
+    expression: "true"
+`), 0600)
+	checkErr(t, err, "could not write checks: %v", err)
+
+	checks, err := loadAuditCustomChecks(checksFile)
+	checkErr(t, err, "could not load checks: %v", err)
+	if len(checks) != 3 {
+		t.Fatalf("expected 3 checks, got %d", len(checks))
+	}
+
+	ar, err := archive.NewReader(archivePath)
+	checkErr(t, err, "could not open archive: %v", err)
+	defer ar.Close()
+
+	expected := []struct {
+		outcome auditCheckOutcome
+		issues  []auditIssue
+	}{
+		{Fail, []auditIssue{{"C1/n1/ACME/EVENTS/stream_info", "ACME/EVENTS has 1 replica"}}},
+		{SomeIssues, []auditIssue{{"C1/n1/ACME/ORDERS/stream_info", "capture/accounts/ACME/streams/ORDERS/replicas/C1__n1/stream_info.json"}}},
+		{Skipped, []auditIssue{}},
+	}
+
+	cmd := &auditAnalyzeCmd{}
+	for i, check := range checks {
+		cmd.resetExampleIssues()
+		outcome, err := check.run(cmd, ar)
+		checkErr(t, err, "check %s failed: %v", check.config.Name, err)
+		if outcome != expected[i].outcome {
+			t.Fatalf("check %s: expected outcome %s, got %s", check.config.Name, expected[i].outcome.badge(), outcome.badge())
+		}
+		if diff := cmp.Diff(expected[i].issues, cmd.exampleIssues); diff != "" {
+			t.Fatalf("check %s: unexpected issues (-want +got):\n%s", check.config.Name, diff)
+		}
+	}
+}
+
+func TestAuditCustomChecksSnapshots(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, replicas := range map[int]map[string]int{0: {"ORDERS": 1, "EVENTS": 1}, 1: {"ORDERS": 3, "EVENTS": 1}} {
+		for streamName, r := range replicas {
+			stream := &server.StreamDetail{
+				Name:   streamName,
+				Config: &server.StreamConfig{Name: streamName, Replicas: r, Storage: server.FileStorage},
+			}
+			err = aw.Add(stream, archive.TagCluster("C1"), archive.TagServer("n1"), archive.TagAccount("ACME"), archive.TagStream(streamName), archive.TagStreamInfo(), archive.TagSnapshot(start.Add(time.Duration(i)*time.Minute)))
+			checkErr(t, err, "could not add stream: %v", err)
+		}
+	}
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	ar, err := archive.NewReader(archivePath)
+	checkErr(t, err, "could not open archive: %v", err)
+	defer ar.Close()
+
+	check, err := newAuditCustomCheck(auditCustomCheckConfig{
+		Name:       "Single replica streams",
+		Query:      "type:stream_info",
+		Expression: "config.num_replicas < 2",
+		Message:    "{{ .tags.stream }} has {{ .config.num_replicas }} replica in snapshot {{ .tags.snapshot }}",
+	})
+	checkErr(t, err, "invalid check: %v", err)
+
+	cmd := &auditAnalyzeCmd{}
+	cmd.resetExampleIssues()
+	outcome, err := check.run(cmd, ar)
+	checkErr(t, err, "check failed: %v", err)
+	if outcome != SomeIssues {
+		t.Fatalf("expected outcome %s, got %s", SomeIssues.badge(), outcome.badge())
+	}
+	expected := []auditIssue{{"C1/n1/ACME/EVENTS/stream_info", "EVENTS has 1 replica in snapshot " + archive.TagSnapshot(start.Add(time.Minute)).Value}}
+	if diff := cmp.Diff(expected, cmd.exampleIssues); diff != "" {
+		t.Fatalf("unexpected issues (-want +got):\n%s", diff)
+	}
+}
+
+func TestAuditCustomChecksInvalid(t *testing.T) {
+	cases := map[string]auditCustomCheckConfig{
+		"missing name":       {Query: "type:stream_info", Expression: "true"},
+		"invalid query":      {Name: "x", Query: "type:stream_info and", Expression: "true"},
+		"invalid expression": {Name: "x", Query: "type:stream_info", Expression: "config.num_replicas <"},
+		"non-boolean":        {Name: "x", Query: "type:stream_info", Expression: "1 + 1"},
+		"unknown severity":   {Name: "x", Query: "type:stream_info", Expression: "true", Severity: "meh"},
+		"invalid message":    {Name: "x", Query: "type:stream_info", Expression: "true", Message: "{{ .tags"},
+	}
+
+	for name, config := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := newAuditCustomCheck(config)
+			if err == nil {
+				t.Fatalf("expected error")
+			}
+		})
+	}
+}
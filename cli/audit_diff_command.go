@@ -0,0 +1,508 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+type auditDiffCmd struct {
+	beforeArchivePath string
+	afterArchivePath  string
+	keys              auditArchiveKeys
+}
+
+// auditDiffSnapshot is the subset of an archive content that is compared by the diff command
+type auditDiffSnapshot struct {
+	metadata       *auditMetadata
+	serverClusters map[string]string
+	serverVarz     map[string]*server.Varz
+	streams        map[string]*server.StreamDetail
+	accounts       map[string]*server.AccountInfo
+}
+
+func configureAuditDiffCommand(srv *fisk.CmdClause) {
+	c := &auditDiffCmd{}
+
+	diff := srv.Command("diff", "compare two archives created by the 'gather' subcommand").Action(c.diff)
+	diff.Arg("before", "path to the older archive").Required().ExistingFileVar(&c.beforeArchivePath)
+	diff.Arg("after", "path to the newer archive").Required().ExistingFileVar(&c.afterArchivePath)
+	c.keys.configureFlags(diff)
+}
+
+func (c *auditDiffCmd) diff(_ *fisk.ParseContext) error {
+	before, err := c.loadSnapshot(c.beforeArchivePath)
+	if err != nil {
+		return err
+	}
+
+	after, err := c.loadSnapshot(c.afterArchivePath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Comparing %s (%s) to %s (%s)\n\n", c.beforeArchivePath, before.captureTime(), c.afterArchivePath, after.captureTime())
+
+	c.diffServers(before, after)
+	c.diffServerVersions(before, after)
+	c.diffServerConfigs(before, after)
+	c.diffStreams(before, after)
+	c.diffStreamGrowth(before, after)
+	c.diffConsumerPending(before, after)
+	c.diffAccountLimits(before, after)
+
+	return nil
+}
+
+// loadSnapshot opens the given archive and loads all artifacts needed for comparison
+func (c *auditDiffCmd) loadSnapshot(archivePath string) (*auditDiffSnapshot, error) {
+	ar, err := c.keys.openArchive(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := ar.Close()
+		if err != nil {
+			fmt.Printf("Failed to close archive reader: %s\n", err)
+		}
+	}()
+
+	snapshot := &auditDiffSnapshot{
+		serverClusters: make(map[string]string),
+		serverVarz:     make(map[string]*server.Varz),
+		streams:        make(map[string]*server.StreamDetail),
+		accounts:       make(map[string]*server.AccountInfo),
+	}
+
+	var metadata auditMetadata
+	err = ar.Load(&metadata, archive.TagSpecial("audit_gather_metadata"))
+	if err == nil {
+		snapshot.metadata = &metadata
+	} else if !errors.Is(err, archive.ErrNoMatches) {
+		return nil, fmt.Errorf("failed to load capture metadata from %s: %w", archivePath, err)
+	}
+
+	for _, clusterName := range ar.GetClusterNames() {
+		clusterTag := archive.TagCluster(clusterName)
+		for _, serverName := range ar.GetClusterServerNames(clusterName) {
+			serverTag := archive.TagServer(serverName)
+			snapshot.serverClusters[serverName] = clusterName
+
+			var serverVarz server.Varz
+			err := ar.Load(&serverVarz, clusterTag, serverTag, archive.TagServerVars())
+			if errors.Is(err, archive.ErrNoMatches) {
+				fmt.Printf("Warning: artifact 'VARZ' is missing for server %s in %s\n", serverName, archivePath)
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to load VARZ for server %s in %s: %w", serverName, archivePath, err)
+			}
+			snapshot.serverVarz[serverName] = &serverVarz
+		}
+	}
+
+	// Iterate servers in a stable order, so that repeated diffs of the same archives produce the same result
+	serverNames := make([]string, 0, len(snapshot.serverClusters))
+	for serverName := range snapshot.serverClusters {
+		serverNames = append(serverNames, serverName)
+	}
+	sort.Strings(serverNames)
+
+	for _, accountName := range ar.GetAccountNames() {
+		accountTag := archive.TagAccount(accountName)
+
+		// Account info is captured through each server, any one of them will do
+		for _, serverName := range serverNames {
+			var accountInfo server.AccountInfo
+			err := ar.Load(&accountInfo, accountTag, archive.TagServer(serverName), archive.TagAccountInfo())
+			if errors.Is(err, archive.ErrNoMatches) {
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to load account %s info in %s: %w", accountName, archivePath, err)
+			}
+			snapshot.accounts[accountName] = &accountInfo
+			break
+		}
+
+		for _, streamName := range ar.GetAccountStreamNames(accountName) {
+			streamTag := archive.TagStream(streamName)
+
+			// Use the replica with the most recent state to represent the stream
+			for _, serverName := range ar.GetStreamServerNames(accountName, streamName) {
+				var streamDetails server.StreamDetail
+				err := ar.Load(&streamDetails, accountTag, streamTag, archive.TagServer(serverName), archive.TagStreamInfo())
+				if errors.Is(err, archive.ErrNoMatches) {
+					continue
+				} else if err != nil {
+					return nil, fmt.Errorf("failed to load stream %s/%s info in %s: %w", accountName, streamName, archivePath, err)
+				}
+
+				key := accountName + "/" + streamName
+				current, known := snapshot.streams[key]
+				if !known || streamDetails.State.LastSeq > current.State.LastSeq {
+					snapshot.streams[key] = &streamDetails
+				}
+			}
+		}
+	}
+
+	return snapshot, nil
+}
+
+func (s *auditDiffSnapshot) captureTime() string {
+	if s.metadata == nil {
+		return "unknown capture time"
+	}
+	return s.metadata.Timestamp.Format(time.RFC3339)
+}
+
+func (c *auditDiffCmd) diffServers(before, after *auditDiffSnapshot) {
+	table := newTableWriter("Servers")
+	table.AddHeaders("Server", "Cluster", "Change")
+
+	changes := 0
+	for _, serverName := range sortedMapKeys(before.serverClusters, after.serverClusters) {
+		beforeCluster, inBefore := before.serverClusters[serverName]
+		afterCluster, inAfter := after.serverClusters[serverName]
+		switch {
+		case !inBefore:
+			table.AddRow(serverName, afterCluster, "added")
+		case !inAfter:
+			table.AddRow(serverName, beforeCluster, "removed")
+		case beforeCluster != afterCluster:
+			table.AddRow(serverName, afterCluster, fmt.Sprintf("moved from cluster %s", beforeCluster))
+		default:
+			continue
+		}
+		changes++
+	}
+
+	c.render(table, changes, "No servers added or removed")
+}
+
+func (c *auditDiffCmd) diffServerVersions(before, after *auditDiffSnapshot) {
+	table := newTableWriter("Server versions")
+	table.AddHeaders("Server", "Before", "After")
+
+	changes := 0
+	for _, serverName := range auditDiffCommonKeys(before.serverVarz, after.serverVarz) {
+		beforeVersion, afterVersion := before.serverVarz[serverName].Version, after.serverVarz[serverName].Version
+		if beforeVersion != afterVersion {
+			table.AddRow(serverName, beforeVersion, afterVersion)
+			changes++
+		}
+	}
+
+	c.render(table, changes, "No server version changes")
+}
+
+func (c *auditDiffCmd) diffServerConfigs(before, after *auditDiffSnapshot) {
+	table := newTableWriter("Server configuration")
+	table.AddHeaders("Server", "Setting", "Before", "After")
+
+	changes := 0
+	for _, serverName := range auditDiffCommonKeys(before.serverVarz, after.serverVarz) {
+		beforeConfig, err := auditVarzConfig(before.serverVarz[serverName])
+		if err != nil {
+			fmt.Printf("Warning: failed to process configuration of server %s: %s\n", serverName, err)
+			continue
+		}
+		afterConfig, err := auditVarzConfig(after.serverVarz[serverName])
+		if err != nil {
+			fmt.Printf("Warning: failed to process configuration of server %s: %s\n", serverName, err)
+			continue
+		}
+
+		for _, setting := range sortedMapKeys(beforeConfig, afterConfig) {
+			if beforeConfig[setting] != afterConfig[setting] {
+				table.AddRow(serverName, setting, beforeConfig[setting], afterConfig[setting])
+				changes++
+			}
+		}
+	}
+
+	c.render(table, changes, "No server configuration changes")
+}
+
+func (c *auditDiffCmd) diffStreams(before, after *auditDiffSnapshot) {
+	table := newTableWriter("Streams")
+	table.AddHeaders("Stream", "Change")
+
+	changes := 0
+	for _, stream := range sortedMapKeys(before.streams, after.streams) {
+		_, inBefore := before.streams[stream]
+		_, inAfter := after.streams[stream]
+		switch {
+		case !inBefore:
+			table.AddRow(stream, "created")
+		case !inAfter:
+			table.AddRow(stream, "deleted")
+		default:
+			continue
+		}
+		changes++
+	}
+
+	c.render(table, changes, "No streams created or deleted")
+}
+
+func (c *auditDiffCmd) diffStreamGrowth(before, after *auditDiffSnapshot) {
+	table := newTableWriter("Stream growth")
+	table.AddHeaders("Stream", "Messages", "Messages Δ", "Bytes", "Bytes Δ")
+
+	changes := 0
+	for _, stream := range auditDiffCommonKeys(before.streams, after.streams) {
+		beforeState, afterState := before.streams[stream].State, after.streams[stream].State
+		if beforeState.Msgs == afterState.Msgs && beforeState.Bytes == afterState.Bytes {
+			continue
+		}
+		table.AddRow(
+			stream,
+			f(afterState.Msgs),
+			auditDiffDelta(beforeState.Msgs, afterState.Msgs, false),
+			fiBytes(afterState.Bytes),
+			auditDiffDelta(beforeState.Bytes, afterState.Bytes, true),
+		)
+		changes++
+	}
+
+	c.render(table, changes, "No changes in stream messages or bytes")
+}
+
+func (c *auditDiffCmd) diffConsumerPending(before, after *auditDiffSnapshot) {
+	table := newTableWriter("Consumer pending messages")
+	table.AddHeaders("Stream", "Consumer", "Pending", "Pending Δ", "Ack Pending", "Ack Pending Δ")
+
+	consumersByName := func(stream *server.StreamDetail) map[string]*server.ConsumerInfo {
+		consumers := make(map[string]*server.ConsumerInfo, len(stream.Consumer))
+		for _, consumer := range stream.Consumer {
+			consumers[consumer.Name] = consumer
+		}
+		return consumers
+	}
+
+	changes := 0
+	for _, stream := range auditDiffCommonKeys(before.streams, after.streams) {
+		beforeConsumers, afterConsumers := consumersByName(before.streams[stream]), consumersByName(after.streams[stream])
+		for _, consumerName := range auditDiffCommonKeys(beforeConsumers, afterConsumers) {
+			b, a := beforeConsumers[consumerName], afterConsumers[consumerName]
+			if b.NumPending == a.NumPending && b.NumAckPending == a.NumAckPending {
+				continue
+			}
+			table.AddRow(
+				stream,
+				consumerName,
+				f(a.NumPending),
+				auditDiffDelta(b.NumPending, a.NumPending, false),
+				f(a.NumAckPending),
+				auditDiffDelta(uint64(b.NumAckPending), uint64(a.NumAckPending), false),
+			)
+			changes++
+		}
+	}
+
+	c.render(table, changes, "No changes in consumers pending messages")
+}
+
+func (c *auditDiffCmd) diffAccountLimits(before, after *auditDiffSnapshot) {
+	table := newTableWriter("Account limits")
+	table.AddHeaders("Account", "Limit", "Before", "After")
+
+	accountLimits := func(accountInfo *server.AccountInfo) map[string]string {
+		limits := make(map[string]string)
+		if accountInfo.Claim == nil {
+			return limits
+		}
+		err := auditFlattenJSON(accountInfo.Claim.Limits, limits)
+		if err != nil {
+			fmt.Printf("Warning: failed to process limits of account %s: %s\n", accountInfo.AccountName, err)
+		}
+		return limits
+	}
+
+	changes := 0
+	for _, accountName := range auditDiffCommonKeys(before.accounts, after.accounts) {
+		beforeLimits, afterLimits := accountLimits(before.accounts[accountName]), accountLimits(after.accounts[accountName])
+		for _, limit := range sortedMapKeys(beforeLimits, afterLimits) {
+			if beforeLimits[limit] != afterLimits[limit] {
+				table.AddRow(accountName, limit, beforeLimits[limit], afterLimits[limit])
+				changes++
+			}
+		}
+	}
+
+	c.render(table, changes, "No account limits changes")
+}
+
+func (c *auditDiffCmd) render(table *tbl, changes int, noChangesMessage string) {
+	if changes == 0 {
+		fmt.Println(noChangesMessage)
+		fmt.Println()
+		return
+	}
+	fmt.Println(table.Render())
+}
+
+// auditVarzConfig flattens the configuration-bearing fields of a server VARZ into a map of dotted setting names to
+// values, so that configurations can be compared setting by setting.
+// Lists of URLs, remotes and operators are sorted, the order in which they are reported is not significant.
+// Fields omitted from VARZ when empty are absent from the map.
+func auditVarzConfig(varz *server.Varz) (map[string]string, error) {
+	cluster := varz.Cluster
+	cluster.URLs = sortedCopy(cluster.URLs)
+
+	gateway := varz.Gateway
+	gateway.Gateways = slices.Clone(gateway.Gateways)
+	for i := range gateway.Gateways {
+		gateway.Gateways[i].URLs = sortedCopy(gateway.Gateways[i].URLs)
+	}
+	slices.SortFunc(gateway.Gateways, func(a, b server.RemoteGatewayOptsVarz) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	leafnode := varz.LeafNode
+	leafnode.Remotes = slices.Clone(leafnode.Remotes)
+	for i := range leafnode.Remotes {
+		leafnode.Remotes[i].URLs = sortedCopy(leafnode.Remotes[i].URLs)
+	}
+	slices.SortFunc(leafnode.Remotes, func(a, b server.RemoteLeafOptsVarz) int {
+		if a.LocalAccount != b.LocalAccount {
+			return strings.Compare(a.LocalAccount, b.LocalAccount)
+		}
+		return strings.Compare(strings.Join(a.URLs, ","), strings.Join(b.URLs, ","))
+	})
+
+	trustedOperators := make([]string, 0, len(varz.TrustedOperatorsClaim))
+	for _, operator := range varz.TrustedOperatorsClaim {
+		if operator != nil {
+			trustedOperators = append(trustedOperators, operator.Subject)
+		}
+	}
+	slices.Sort(trustedOperators)
+
+	config := map[string]any{
+		"max_connections":      varz.MaxConn,
+		"max_subscriptions":    varz.MaxSubs,
+		"max_payload":          varz.MaxPayload,
+		"max_pending":          varz.MaxPending,
+		"max_control_line":     varz.MaxControlLine,
+		"max_pings_out":        varz.MaxPingsOut,
+		"ping_interval":        varz.PingInterval.String(),
+		"write_deadline":       varz.WriteDeadline.String(),
+		"auth_required":        varz.AuthRequired,
+		"auth_timeout":         varz.AuthTimeout,
+		"tls_required":         varz.TLSRequired,
+		"tls_verify":           varz.TLSVerify,
+		"tls_timeout":          varz.TLSTimeout,
+		"tls_ocsp_peer_verify": varz.TLSOCSPPeerVerify,
+		"system_account":       varz.SystemAccount,
+		"trusted_operators":    trustedOperators,
+		"cluster":              cluster,
+		"gateway":              gateway,
+		"leafnode":             leafnode,
+		"jetstream": map[string]any{
+			"enabled": varz.JetStream.Config != nil,
+			"config":  varz.JetStream.Config,
+		},
+	}
+
+	settings := make(map[string]string)
+	err := auditFlattenJSON(config, settings)
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// auditFlattenJSON converts the given value to its JSON representation, then flattens nested objects into the given
+// map using dotted keys. Arrays are not flattened, they are stored as JSON strings.
+func auditFlattenJSON(v any, flattened map[string]string) error {
+	jsonBytes, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	// Use json.Number to avoid loss of precision and scientific notation for large numbers
+	decoder := json.NewDecoder(bytes.NewReader(jsonBytes))
+	decoder.UseNumber()
+	var generic any
+	err = decoder.Decode(&generic)
+	if err != nil {
+		return err
+	}
+
+	var flatten func(prefix string, value any)
+	flatten = func(prefix string, value any) {
+		switch value := value.(type) {
+		case map[string]any:
+			for k, nested := range value {
+				if prefix != "" {
+					k = prefix + "." + k
+				}
+				flatten(k, nested)
+			}
+		case []any:
+			arrayBytes, _ := json.Marshal(value)
+			flattened[prefix] = string(arrayBytes)
+		case nil:
+			flattened[prefix] = ""
+		default:
+			flattened[prefix] = fmt.Sprint(value)
+		}
+	}
+	flatten("", generic)
+
+	return nil
+}
+
+// auditDiffDelta formats the difference between two counters
+func auditDiffDelta(before, after uint64, asBytes bool) string {
+	format := func(v uint64) string {
+		if asBytes {
+			return fiBytes(v)
+		}
+		return f(v)
+	}
+
+	switch {
+	case after > before:
+		return "+" + format(after-before)
+	case after < before:
+		return "-" + format(before-after)
+	default:
+		return "0"
+	}
+}
+
+// auditDiffCommonKeys returns the sorted list of keys present in both maps
+func auditDiffCommonKeys[V1, V2 any](a map[string]V1, b map[string]V2) []string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		if _, inB := b[k]; inB {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,199 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+func TestAuditFlattenJSON(t *testing.T) {
+	type nested struct {
+		Name  string   `json:"name"`
+		Count uint64   `json:"count"`
+		URLs  []string `json:"urls"`
+		Limit *int     `json:"limit"`
+	}
+
+	cases := []struct {
+		name   string
+		input  any
+		expect map[string]string
+	}{
+		{
+			name:   "scalars",
+			input:  map[string]any{"a": 1, "b": "x", "c": true, "d": 1.5},
+			expect: map[string]string{"a": "1", "b": "x", "c": "true", "d": "1.5"},
+		},
+		{
+			name:   "large numbers",
+			input:  map[string]any{"max": uint64(math.MaxUint64), "neg": int64(math.MinInt64)},
+			expect: map[string]string{"max": "18446744073709551615", "neg": "-9223372036854775808"},
+		},
+		{
+			name:  "nested objects",
+			input: map[string]any{"top": map[string]any{"mid": map[string]any{"leaf": "v"}}, "other": nested{Name: "n", Count: 10}},
+			expect: map[string]string{
+				"top.mid.leaf": "v",
+				"other.name":   "n",
+				"other.count":  "10",
+				"other.urls":   "",
+				"other.limit":  "",
+			},
+		},
+		{
+			name:   "arrays",
+			input:  map[string]any{"list": []string{"b", "a"}, "objects": []nested{{Name: "x"}}},
+			expect: map[string]string{"list": `["b","a"]`, "objects": `[{"count":0,"limit":null,"name":"x","urls":null}]`},
+		},
+		{
+			name:   "empty object",
+			input:  map[string]any{},
+			expect: map[string]string{},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			flattened := make(map[string]string)
+			err := auditFlattenJSON(c.input, flattened)
+			assertNoError(t, err)
+			if !cmp.Equal(flattened, c.expect) {
+				t.Fatalf("unexpected result: %s", cmp.Diff(c.expect, flattened))
+			}
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		err := auditFlattenJSON(map[string]any{"f": func() {}}, make(map[string]string))
+		if err == nil {
+			t.Fatalf("expected an error flattening a function")
+		}
+	})
+}
+
+func TestAuditVarzConfig(t *testing.T) {
+	newVarz := func(clusterURLs []string, gateways []server.RemoteGatewayOptsVarz, remotes []server.RemoteLeafOptsVarz) *server.Varz {
+		return &server.Varz{
+			MaxPayload: 1024 * 1024,
+			Cluster:    server.ClusterOptsVarz{Name: "C1", URLs: clusterURLs},
+			Gateway:    server.GatewayOptsVarz{Name: "C1", Gateways: gateways},
+			LeafNode:   server.LeafNodeOptsVarz{Remotes: remotes},
+		}
+	}
+
+	varz := newVarz(
+		[]string{"nats://a:6222", "nats://b:6222", "nats://c:6222"},
+		[]server.RemoteGatewayOptsVarz{
+			{Name: "C2", URLs: []string{"nats://c2-a:7222", "nats://c2-b:7222"}},
+			{Name: "C3", URLs: []string{"nats://c3-a:7222"}},
+		},
+		[]server.RemoteLeafOptsVarz{
+			{LocalAccount: "A", URLs: []string{"nats://hub-a:7422", "nats://hub-b:7422"}},
+			{LocalAccount: "B", URLs: []string{"nats://hub-a:7422"}},
+		},
+	)
+	reordered := newVarz(
+		[]string{"nats://c:6222", "nats://a:6222", "nats://b:6222"},
+		[]server.RemoteGatewayOptsVarz{
+			{Name: "C3", URLs: []string{"nats://c3-a:7222"}},
+			{Name: "C2", URLs: []string{"nats://c2-b:7222", "nats://c2-a:7222"}},
+		},
+		[]server.RemoteLeafOptsVarz{
+			{LocalAccount: "B", URLs: []string{"nats://hub-a:7422"}},
+			{LocalAccount: "A", URLs: []string{"nats://hub-b:7422", "nats://hub-a:7422"}},
+		},
+	)
+
+	config, err := auditVarzConfig(varz)
+	assertNoError(t, err)
+	reorderedConfig, err := auditVarzConfig(reordered)
+	assertNoError(t, err)
+
+	if config["max_payload"] != "1048576" {
+		t.Fatalf("expected max_payload 1048576, got %q", config["max_payload"])
+	}
+	if config["cluster.name"] != "C1" {
+		t.Fatalf("expected cluster.name C1, got %q", config["cluster.name"])
+	}
+	if config["jetstream.enabled"] != "false" {
+		t.Fatalf("expected jetstream.enabled false, got %q", config["jetstream.enabled"])
+	}
+	if !cmp.Equal(config, reorderedConfig) {
+		t.Fatalf("expected reordered lists to have the same configuration: %s", cmp.Diff(config, reorderedConfig))
+	}
+
+	// The original VARZ is not modified
+	if reordered.Cluster.URLs[0] != "nats://c:6222" || reordered.Gateway.Gateways[0].Name != "C3" || reordered.LeafNode.Remotes[0].LocalAccount != "B" {
+		t.Fatalf("expected VARZ lists not to be reordered in place")
+	}
+	if reordered.Gateway.Gateways[1].URLs[0] != "nats://c2-b:7222" {
+		t.Fatalf("expected gateway URLs not to be reordered in place")
+	}
+
+	changed := newVarz(
+		[]string{"nats://a:6222", "nats://b:6222", "nats://d:6222"},
+		varz.Gateway.Gateways,
+		varz.LeafNode.Remotes,
+	)
+	changed.MaxPayload = 8 * 1024 * 1024
+	changedConfig, err := auditVarzConfig(changed)
+	assertNoError(t, err)
+
+	var differences []string
+	for _, setting := range sortedMapKeys(config, changedConfig) {
+		if config[setting] != changedConfig[setting] {
+			differences = append(differences, setting)
+		}
+	}
+	assertListEquals(t, differences, "cluster.urls", "max_payload")
+}
+
+func TestAuditDiffDelta(t *testing.T) {
+	cases := []struct {
+		before  uint64
+		after   uint64
+		asBytes bool
+		expect  string
+	}{
+		{before: 10, after: 10, expect: "0"},
+		{before: 10, after: 1510, expect: "+1,500"},
+		{before: 1510, after: 10, expect: "-1,500"},
+		{before: 0, after: 0, asBytes: true, expect: "0"},
+		{before: 1024, after: 3072, asBytes: true, expect: "+2.0 KiB"},
+		{before: 3072, after: 1024, asBytes: true, expect: "-2.0 KiB"},
+	}
+
+	for _, c := range cases {
+		delta := auditDiffDelta(c.before, c.after, c.asBytes)
+		if delta != c.expect {
+			t.Fatalf("expected delta %d -> %d (bytes: %t) to be %q, got %q", c.before, c.after, c.asBytes, c.expect, delta)
+		}
+	}
+}
+
+func TestAuditDiffKeys(t *testing.T) {
+	a := map[string]int{"x": 1, "y": 2, "z": 3}
+	b := map[string]string{"w": "", "y": "", "z": ""}
+
+	common := auditDiffCommonKeys(a, b)
+	if !cmp.Equal(common, []string{"y", "z"}) {
+		t.Fatalf("unexpected common keys: %v", common)
+	}
+
+	assertListIsEmpty(t, auditDiffCommonKeys(a, map[string]bool{}))
+}
@@ -0,0 +1,614 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+type auditExportCmd struct {
+	archivePath string
+	outputDir   string
+	format      string
+	keys        auditArchiveKeys
+}
+
+// auditExportTable describes a flat table exported from an archive.
+// Rows are produced once for each snapshot in the archive (or once, if the archive contains a single capture), the
+// snapshot time is always the first column.
+type auditExportTable struct {
+	name    string
+	columns []string
+	rows    func(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error
+}
+
+func configureAuditExportCommand(srv *fisk.CmdClause) {
+	c := &auditExportCmd{}
+
+	exportHelp := `Each table is written to a separate file in the output directory.
+Tables are: servers, routes, gateways, leafnodes, accounts, streams,
+stream_replicas, consumers and connections.
+
+Columns are stable across versions, new columns are only ever added
+at the end of a table. Archives with multiple snapshots have one row
+per snapshot, the capture time is in the snapshot column.
+
+Load the tables into DuckDB:
+
+   nats audit export archive.zip --output tables
+   duckdb -c "SELECT * FROM 'tables/streams.csv' ORDER BY bytes DESC"
+`
+
+	export := srv.Command("export", "export the content of an archive as flat tables").Action(c.export)
+	export.HelpLong(exportHelp)
+	export.Arg("archive", "path to input archive to export").Required().ExistingFileVar(&c.archivePath)
+	export.Flag("output", "Directory where tables are written").Short('o').Default(".").StringVar(&c.outputDir)
+	export.Flag("format", "Format of exported tables (csv)").Default("csv").EnumVar(&c.format, "csv")
+	c.keys.configureFlags(export)
+}
+
+func (c *auditExportCmd) export(_ *fisk.ParseContext) error {
+	ar, err := c.keys.openArchive(c.archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := ar.Close()
+		if err != nil {
+			fmt.Printf("Failed to close archive reader: %s\n", err)
+		}
+	}()
+
+	if ar.Signer() != "" {
+		fmt.Printf("Archive signature verified, signed by %s\n", ar.Signer())
+	}
+
+	err = os.MkdirAll(c.outputDir, 0700)
+	if err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	tables := []auditExportTable{
+		{
+			"servers",
+			[]string{"snapshot", "cluster", "server", "server_id", "version", "host", "port", "start", "mem", "cores", "cpu", "connections", "total_connections", "routes", "gateways", "leafnodes", "subscriptions", "slow_consumers", "in_msgs", "out_msgs", "in_bytes", "out_bytes", "jetstream"},
+			c.serverRows,
+		},
+		{
+			"routes",
+			[]string{"snapshot", "cluster", "server", "rid", "remote_id", "remote_name", "did_solicit", "is_configured", "ip", "port", "start", "rtt", "pending_bytes", "in_msgs", "out_msgs", "in_bytes", "out_bytes", "subscriptions"},
+			c.routeRows,
+		},
+		{
+			"gateways",
+			[]string{"snapshot", "cluster", "server", "direction", "gateway", "is_configured", "cid", "ip", "port", "start", "rtt", "in_msgs", "out_msgs", "in_bytes", "out_bytes", "subscriptions"},
+			c.gatewayRows,
+		},
+		{
+			"leafnodes",
+			[]string{"snapshot", "cluster", "server", "name", "is_spoke", "account", "ip", "port", "rtt", "in_msgs", "out_msgs", "in_bytes", "out_bytes", "subscriptions"},
+			c.leafnodeRows,
+		},
+		{
+			"accounts",
+			[]string{"snapshot", "account", "cluster", "server", "name_tag", "issuer", "is_system", "jetstream", "expired", "complete", "last_update", "client_connections", "leafnode_connections", "subscriptions"},
+			c.accountRows,
+		},
+		{
+			"streams",
+			[]string{"snapshot", "account", "stream", "cluster", "leader", "replicas", "subjects", "retention", "storage", "created", "messages", "bytes", "first_seq", "last_seq", "num_subjects", "num_deleted", "consumers"},
+			c.streamRows,
+		},
+		{
+			"stream_replicas",
+			[]string{"snapshot", "account", "stream", "cluster", "server", "leader", "current", "offline", "lag", "active_seconds", "messages", "bytes", "first_seq", "last_seq"},
+			c.streamReplicaRows,
+		},
+		{
+			"consumers",
+			[]string{"snapshot", "account", "stream", "consumer", "cluster", "server", "leader", "durable", "ack_policy", "created", "num_pending", "num_ack_pending", "num_redelivered", "num_waiting", "delivered_stream_seq", "ack_floor_stream_seq"},
+			c.consumerRows,
+		},
+		{
+			"connections",
+			[]string{"snapshot", "cluster", "server", "cid", "kind", "type", "name", "account", "ip", "port", "lang", "version", "start", "last_activity", "rtt", "pending_bytes", "in_msgs", "out_msgs", "in_bytes", "out_bytes", "subscriptions"},
+			c.connectionRows,
+		},
+	}
+
+	for _, table := range tables {
+		rowsCount, err := c.exportTable(ar, table)
+		if err != nil {
+			return fmt.Errorf("failed to export table %s: %w", table.name, err)
+		}
+		fmt.Printf("Exported %d rows to %s\n", rowsCount, filepath.Join(c.outputDir, table.name+"."+c.format))
+	}
+
+	return nil
+}
+
+// exportTable writes all rows of the given table (for each snapshot in the archive) to a file in the output directory
+func (c *auditExportCmd) exportTable(ar *archive.Reader, table auditExportTable) (int, error) {
+	f, err := os.Create(filepath.Join(c.outputDir, table.name+"."+c.format))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	err = w.Write(table.columns)
+	if err != nil {
+		return 0, err
+	}
+
+	// Archives with a single capture have no snapshots, export their artifacts once without a snapshot tag
+	type snapshot struct {
+		value any
+		tags  []*archive.Tag
+	}
+	snapshots := []snapshot{{nil, nil}}
+	if captureTimes := ar.GetSnapshots(); len(captureTimes) > 0 {
+		snapshots = make([]snapshot, len(captureTimes))
+		for i, captureTime := range captureTimes {
+			snapshots[i] = snapshot{captureTime, []*archive.Tag{archive.TagSnapshot(captureTime)}}
+		}
+	}
+
+	rowsCount := 0
+	for _, s := range snapshots {
+		var writeErr error
+		err := table.rows(ar, s.tags, func(values ...any) {
+			if writeErr != nil {
+				return
+			}
+			if len(values)+1 != len(table.columns) {
+				panic(fmt.Sprintf("table %s has %d columns, row has %d values", table.name, len(table.columns), len(values)+1))
+			}
+			record := make([]string, 0, len(table.columns))
+			record = append(record, auditExportValue(s.value))
+			for _, value := range values {
+				record = append(record, auditExportValue(value))
+			}
+			writeErr = w.Write(record)
+			rowsCount += 1
+		})
+		if err != nil {
+			return rowsCount, err
+		}
+		if writeErr != nil {
+			return rowsCount, writeErr
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return rowsCount, err
+	}
+
+	return rowsCount, f.Close()
+}
+
+// auditExportServerArtifacts loads the artifact of the given type for each server, and invokes the callback for each one.
+// Servers missing the artifact are skipped.
+func auditExportServerArtifacts[T any](ar *archive.Reader, snapshotTags []*archive.Tag, typeTag *archive.Tag, cb func(clusterName, serverName string, artifact *T)) error {
+	for _, clusterName := range ar.GetClusterNames() {
+		clusterTag := archive.TagCluster(clusterName)
+		for _, serverName := range ar.GetClusterServerNames(clusterName) {
+			var artifact T
+			err := ar.Load(&artifact, append([]*archive.Tag{clusterTag, archive.TagServer(serverName), typeTag}, snapshotTags...)...)
+			if errors.Is(err, archive.ErrNoMatches) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to load %s for server %s: %w", typeTag.Value, serverName, err)
+			}
+			cb(clusterName, serverName, &artifact)
+		}
+	}
+	return nil
+}
+
+// auditExportAccountArtifacts loads the account artifact of the given type captured through each server, and
+// invokes the callback for each one. Servers missing the artifact are skipped.
+func auditExportAccountArtifacts[T any](ar *archive.Reader, snapshotTags []*archive.Tag, accountNames []string, typeTag *archive.Tag, cb func(accountName, clusterName, serverName string, artifact *T)) error {
+	for _, accountName := range accountNames {
+		accountTag := archive.TagAccount(accountName)
+		for _, clusterName := range ar.GetClusterNames() {
+			clusterTag := archive.TagCluster(clusterName)
+			for _, serverName := range ar.GetClusterServerNames(clusterName) {
+				var artifact T
+				err := ar.Load(&artifact, append([]*archive.Tag{accountTag, clusterTag, archive.TagServer(serverName), typeTag}, snapshotTags...)...)
+				if errors.Is(err, archive.ErrNoMatches) {
+					continue
+				} else if err != nil {
+					return fmt.Errorf("failed to load account %s %s from server %s: %w", accountName, typeTag.Value, serverName, err)
+				}
+				cb(accountName, clusterName, serverName, &artifact)
+			}
+		}
+	}
+	return nil
+}
+
+// auditExportStreamReplicas loads the stream details reported by each replica of the given stream, keyed by server name
+func auditExportStreamReplicas(ar *archive.Reader, snapshotTags []*archive.Tag, accountName, streamName string) (map[string]*server.StreamDetail, error) {
+	replicas := make(map[string]*server.StreamDetail)
+	for _, serverName := range ar.GetStreamServerNames(accountName, streamName) {
+		var streamDetails server.StreamDetail
+		err := ar.Load(&streamDetails, append([]*archive.Tag{archive.TagAccount(accountName), archive.TagStream(streamName), archive.TagServer(serverName), archive.TagStreamInfo()}, snapshotTags...)...)
+		if errors.Is(err, archive.ErrNoMatches) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to load stream %s/%s info from server %s: %w", accountName, streamName, serverName, err)
+		}
+		replicas[serverName] = &streamDetails
+	}
+	return replicas, nil
+}
+
+// auditExportStreamLeader returns the name of the server that leads the stream, according to the given replicas.
+// If the leader is unknown, the replica with the most recent state is returned instead.
+func auditExportStreamLeader(replicas map[string]*server.StreamDetail) string {
+	leaderName := ""
+	for _, serverName := range sortedMapKeys(replicas) {
+		streamDetails := replicas[serverName]
+		if streamDetails.Cluster != nil && streamDetails.Cluster.Leader == serverName {
+			return serverName
+		}
+		if leaderName == "" || streamDetails.State.LastSeq > replicas[leaderName].State.LastSeq {
+			leaderName = serverName
+		}
+	}
+	return leaderName
+}
+
+// auditExportStreams invokes the callback with the replicas of each stream in the archive
+func auditExportStreams(ar *archive.Reader, snapshotTags []*archive.Tag, cb func(accountName, streamName string, replicas map[string]*server.StreamDetail)) error {
+	for _, accountName := range ar.GetAccountNames() {
+		for _, streamName := range ar.GetAccountStreamNames(accountName) {
+			replicas, err := auditExportStreamReplicas(ar, snapshotTags, accountName, streamName)
+			if err != nil {
+				return err
+			}
+			if len(replicas) > 0 {
+				cb(accountName, streamName, replicas)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *auditExportCmd) serverRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportServerArtifacts(ar, snapshotTags, archive.TagServerVars(), func(clusterName, serverName string, varz *server.Varz) {
+		addRow(
+			clusterName,
+			serverName,
+			varz.ID,
+			varz.Version,
+			varz.Host,
+			varz.Port,
+			varz.Start,
+			varz.Mem,
+			varz.Cores,
+			varz.CPU,
+			varz.Connections,
+			varz.TotalConnections,
+			varz.Routes,
+			varz.Remotes,
+			varz.Leafs,
+			varz.Subscriptions,
+			varz.SlowConsumers,
+			varz.InMsgs,
+			varz.OutMsgs,
+			varz.InBytes,
+			varz.OutBytes,
+			varz.JetStream.Config != nil,
+		)
+	})
+}
+
+func (c *auditExportCmd) routeRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportServerArtifacts(ar, snapshotTags, archive.TagServerRoutes(), func(clusterName, serverName string, routez *server.Routez) {
+		for _, route := range routez.Routes {
+			addRow(
+				clusterName,
+				serverName,
+				route.Rid,
+				route.RemoteID,
+				route.RemoteName,
+				route.DidSolicit,
+				route.IsConfigured,
+				route.IP,
+				route.Port,
+				route.Start,
+				route.RTT,
+				route.Pending,
+				route.InMsgs,
+				route.OutMsgs,
+				route.InBytes,
+				route.OutBytes,
+				route.NumSubs,
+			)
+		}
+	})
+}
+
+func (c *auditExportCmd) gatewayRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportServerArtifacts(ar, snapshotTags, archive.TagServerGateways(), func(clusterName, serverName string, gatewayz *server.Gatewayz) {
+		addGatewayRow := func(direction, gatewayName string, gateway *server.RemoteGatewayz) {
+			conn := gateway.Connection
+			if conn == nil {
+				addRow(clusterName, serverName, direction, gatewayName, gateway.IsConfigured, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+				return
+			}
+			addRow(
+				clusterName,
+				serverName,
+				direction,
+				gatewayName,
+				gateway.IsConfigured,
+				conn.Cid,
+				conn.IP,
+				conn.Port,
+				conn.Start,
+				conn.RTT,
+				conn.InMsgs,
+				conn.OutMsgs,
+				conn.InBytes,
+				conn.OutBytes,
+				conn.NumSubs,
+			)
+		}
+
+		for _, gatewayName := range sortedMapKeys(gatewayz.OutboundGateways) {
+			addGatewayRow("outbound", gatewayName, gatewayz.OutboundGateways[gatewayName])
+		}
+		for _, gatewayName := range sortedMapKeys(gatewayz.InboundGateways) {
+			for _, gateway := range gatewayz.InboundGateways[gatewayName] {
+				addGatewayRow("inbound", gatewayName, gateway)
+			}
+		}
+	})
+}
+
+func (c *auditExportCmd) leafnodeRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportServerArtifacts(ar, snapshotTags, archive.TagServerLeafs(), func(clusterName, serverName string, leafz *server.Leafz) {
+		for _, leaf := range leafz.Leafs {
+			addRow(
+				clusterName,
+				serverName,
+				leaf.Name,
+				leaf.IsSpoke,
+				leaf.Account,
+				leaf.IP,
+				leaf.Port,
+				leaf.RTT,
+				leaf.InMsgs,
+				leaf.OutMsgs,
+				leaf.InBytes,
+				leaf.OutBytes,
+				leaf.NumSubs,
+			)
+		}
+	})
+}
+
+func (c *auditExportCmd) accountRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportAccountArtifacts(ar, snapshotTags, ar.GetAccountNames(), archive.TagAccountInfo(), func(accountName, clusterName, serverName string, accountInfo *server.AccountInfo) {
+		addRow(
+			accountName,
+			clusterName,
+			serverName,
+			accountInfo.NameTag,
+			accountInfo.IssuerKey,
+			accountInfo.IsSystem,
+			accountInfo.JetStream,
+			accountInfo.Expired,
+			accountInfo.Complete,
+			accountInfo.LastUpdate,
+			accountInfo.ClientCnt,
+			accountInfo.LeafCnt,
+			accountInfo.SubCnt,
+		)
+	})
+}
+
+func (c *auditExportCmd) streamRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportStreams(ar, snapshotTags, func(accountName, streamName string, replicas map[string]*server.StreamDetail) {
+		leaderName := auditExportStreamLeader(replicas)
+		stream := replicas[leaderName]
+
+		var clusterName, leader any
+		if stream.Cluster != nil {
+			clusterName, leader = stream.Cluster.Name, stream.Cluster.Leader
+		}
+
+		var replicasCount, subjects, retention, storage any
+		if stream.Config != nil {
+			replicasCount = stream.Config.Replicas
+			subjects = stream.Config.Subjects
+			retention = stream.Config.Retention
+			storage = stream.Config.Storage
+		}
+
+		addRow(
+			accountName,
+			streamName,
+			clusterName,
+			leader,
+			replicasCount,
+			subjects,
+			retention,
+			storage,
+			stream.Created,
+			stream.State.Msgs,
+			stream.State.Bytes,
+			stream.State.FirstSeq,
+			stream.State.LastSeq,
+			stream.State.NumSubjects,
+			stream.State.NumDeleted,
+			stream.State.Consumers,
+		)
+	})
+}
+
+func (c *auditExportCmd) streamReplicaRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportStreams(ar, snapshotTags, func(accountName, streamName string, replicas map[string]*server.StreamDetail) {
+		// Replicas state (lag, activity) is as reported by the leader
+		peers := make(map[string]*server.PeerInfo)
+		if leader := replicas[auditExportStreamLeader(replicas)]; leader.Cluster != nil {
+			for _, peer := range leader.Cluster.Replicas {
+				peers[peer.Name] = peer
+			}
+		}
+
+		for _, serverName := range sortedMapKeys(replicas) {
+			stream := replicas[serverName]
+
+			var clusterName any
+			isLeader := false
+			if stream.Cluster != nil {
+				clusterName = stream.Cluster.Name
+				isLeader = stream.Cluster.Leader == serverName
+			}
+
+			var current, offline, lag, active any
+			if peer, found := peers[serverName]; found {
+				current, offline, lag, active = peer.Current, peer.Offline, peer.Lag, peer.Active
+			}
+
+			addRow(
+				accountName,
+				streamName,
+				clusterName,
+				serverName,
+				isLeader,
+				current,
+				offline,
+				lag,
+				active,
+				stream.State.Msgs,
+				stream.State.Bytes,
+				stream.State.FirstSeq,
+				stream.State.LastSeq,
+			)
+		}
+	})
+}
+
+func (c *auditExportCmd) consumerRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportStreams(ar, snapshotTags, func(accountName, streamName string, replicas map[string]*server.StreamDetail) {
+		// Each server reports the consumers it hosts
+		for _, serverName := range sortedMapKeys(replicas) {
+			consumers := replicas[serverName].Consumer
+			sort.Slice(consumers, func(i, j int) bool { return consumers[i].Name < consumers[j].Name })
+
+			for _, consumer := range consumers {
+				var clusterName any
+				isLeader := false
+				if consumer.Cluster != nil {
+					clusterName = consumer.Cluster.Name
+					isLeader = consumer.Cluster.Leader == serverName
+				}
+
+				var durable, ackPolicy any
+				if consumer.Config != nil {
+					durable = consumer.Config.Durable != ""
+					ackPolicy = consumer.Config.AckPolicy
+				}
+
+				addRow(
+					accountName,
+					streamName,
+					consumer.Name,
+					clusterName,
+					serverName,
+					isLeader,
+					durable,
+					ackPolicy,
+					consumer.Created,
+					consumer.NumPending,
+					consumer.NumAckPending,
+					consumer.NumRedelivered,
+					consumer.NumWaiting,
+					consumer.Delivered.Stream,
+					consumer.AckFloor.Stream,
+				)
+			}
+		}
+	})
+}
+
+func (c *auditExportCmd) connectionRows(ar *archive.Reader, snapshotTags []*archive.Tag, addRow func(values ...any)) error {
+	return auditExportServerArtifacts(ar, snapshotTags, archive.TagServerConnections(), func(clusterName, serverName string, connz *server.Connz) {
+		for _, conn := range connz.Conns {
+			addRow(
+				clusterName,
+				serverName,
+				conn.Cid,
+				conn.Kind,
+				conn.Type,
+				conn.Name,
+				conn.Account,
+				conn.IP,
+				conn.Port,
+				conn.Lang,
+				conn.Version,
+				conn.Start,
+				conn.LastActivity,
+				conn.RTT,
+				conn.Pending,
+				conn.InMsgs,
+				conn.OutMsgs,
+				conn.InBytes,
+				conn.OutBytes,
+				conn.NumSubs,
+			)
+		}
+	})
+}
+
+// auditExportValue formats a value for a table cell. Times are formatted as RFC3339 (UTC), durations as seconds, lists
+// are space-separated. Unknown values (nil) and zero times are left empty.
+func auditExportValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.UTC().Format(time.RFC3339Nano)
+	case time.Duration:
+		return fmt.Sprintf("%.3f", v.Seconds())
+	case []string:
+		return strings.Join(v, " ")
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+func readExportedTable(t *testing.T, dir, table string) []map[string]string {
+	t.Helper()
+
+	f, err := os.Open(filepath.Join(dir, table+".csv"))
+	checkErr(t, err, "could not open table %s: %v", table, err)
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	checkErr(t, err, "could not read table %s: %v", table, err)
+
+	if len(records) < 1 {
+		t.Fatalf("table %s has no header", table)
+	}
+
+	rows := make([]map[string]string, 0, len(records)-1)
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(record))
+		for i, column := range records[0] {
+			row[column] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func TestAuditExport(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	snapshots := []time.Time{start, start.Add(time.Minute)}
+
+	for i, snapshot := range snapshots {
+		snapshotTag := archive.TagSnapshot(snapshot)
+
+		for _, serverName := range []string{"n1", "n2"} {
+			serverTags := []*archive.Tag{archive.TagCluster("C1"), archive.TagServer(serverName), snapshotTag}
+
+			varz := &server.Varz{ID: "NID" + serverName, Name: serverName, Version: "2.10.0", InMsgs: int64(100 * (i + 1))}
+			err = aw.Add(varz, append(serverTags, archive.TagServerVars())...)
+			checkErr(t, err, "could not add varz: %v", err)
+
+			connz := &server.Connz{Conns: []*server.ConnInfo{{Cid: 1, Name: "app", Account: "ACME"}}}
+			err = aw.Add(connz, append(serverTags, archive.TagServerConnections())...)
+			checkErr(t, err, "could not add connz: %v", err)
+
+			stream := &server.StreamDetail{
+				Name:    "ORDERS",
+				Cluster: &server.ClusterInfo{Name: "C1", Leader: "n1", Replicas: []*server.PeerInfo{{Name: "n2", Current: true, Lag: 3}}},
+				Config:  &server.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>", "returns.>"}, Storage: server.FileStorage, Replicas: 2},
+				State:   server.StreamState{Msgs: 5, LastSeq: 5},
+				Consumer: []*server.ConsumerInfo{
+					{Stream: "ORDERS", Name: "PROCESSOR", Cluster: &server.ClusterInfo{Name: "C1", Leader: "n2"}, Config: &server.ConsumerConfig{Durable: "PROCESSOR", AckPolicy: server.AckExplicit}, NumPending: 2},
+				},
+			}
+			err = aw.Add(stream, append(serverTags, archive.TagAccount("ACME"), archive.TagStream("ORDERS"), archive.TagStreamInfo())...)
+			checkErr(t, err, "could not add stream: %v", err)
+		}
+	}
+
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	outputDir := filepath.Join(t.TempDir(), "tables")
+	cmd := &auditExportCmd{archivePath: archivePath, outputDir: outputDir, format: "csv"}
+	err = cmd.export(nil)
+	checkErr(t, err, "export failed: %v", err)
+
+	servers := readExportedTable(t, outputDir, "servers")
+	if len(servers) != 4 {
+		t.Fatalf("expected 4 server rows, got %d", len(servers))
+	}
+	if servers[3]["snapshot"] != "2024-05-01T10:01:00Z" || servers[3]["server"] != "n2" || servers[3]["in_msgs"] != "200" {
+		t.Fatalf("unexpected server row: %v", servers[3])
+	}
+
+	streams := readExportedTable(t, outputDir, "streams")
+	if len(streams) != 2 {
+		t.Fatalf("expected 2 stream rows, got %d", len(streams))
+	}
+	expectedStream := map[string]string{
+		"snapshot":     "2024-05-01T10:00:00Z",
+		"account":      "ACME",
+		"stream":       "ORDERS",
+		"cluster":      "C1",
+		"leader":       "n1",
+		"replicas":     "2",
+		"subjects":     "orders.> returns.>",
+		"retention":    "Limits",
+		"storage":      "File",
+		"created":      "",
+		"messages":     "5",
+		"bytes":        "0",
+		"first_seq":    "0",
+		"last_seq":     "5",
+		"num_subjects": "0",
+		"num_deleted":  "0",
+		"consumers":    "0",
+	}
+	if diff := cmp.Diff(expectedStream, streams[0]); diff != "" {
+		t.Fatalf("unexpected stream row (-want +got):\n%s", diff)
+	}
+
+	replicas := readExportedTable(t, outputDir, "stream_replicas")
+	if len(replicas) != 4 {
+		t.Fatalf("expected 4 stream replica rows, got %d", len(replicas))
+	}
+	if replicas[0]["server"] != "n1" || replicas[0]["leader"] != "true" || replicas[0]["lag"] != "" {
+		t.Fatalf("unexpected leader replica row: %v", replicas[0])
+	}
+	if replicas[1]["server"] != "n2" || replicas[1]["leader"] != "false" || replicas[1]["lag"] != "3" || replicas[1]["current"] != "true" {
+		t.Fatalf("unexpected follower replica row: %v", replicas[1])
+	}
+
+	consumers := readExportedTable(t, outputDir, "consumers")
+	if len(consumers) != 4 {
+		t.Fatalf("expected 4 consumer rows, got %d", len(consumers))
+	}
+	if consumers[1]["server"] != "n2" || consumers[1]["leader"] != "true" || consumers[1]["ack_policy"] != "explicit" || consumers[1]["num_pending"] != "2" {
+		t.Fatalf("unexpected consumer row: %v", consumers[1])
+	}
+
+	connections := readExportedTable(t, outputDir, "connections")
+	if len(connections) != 4 || connections[0]["name"] != "app" || connections[0]["account"] != "ACME" {
+		t.Fatalf("unexpected connections: %v", connections)
+	}
+
+	// Tables without any artifacts only have a header
+	for _, table := range []string{"routes", "gateways", "leafnodes", "accounts"} {
+		if rows := readExportedTable(t, outputDir, table); len(rows) != 0 {
+			t.Fatalf("expected empty table %s, got %v", table, rows)
+		}
+	}
+}
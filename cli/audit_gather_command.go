@@ -18,10 +18,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"os/user"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/choria-io/fisk"
@@ -32,7 +38,17 @@ import (
 
 type auditGatherCmd struct {
 	archiveFilePath string
+	protection      auditArchiveProtection
 	progress        bool
+	count           uint
+	interval        time.Duration
+	snapshotTag     *archive.Tag
+	workers         int
+	requestTimeout  time.Duration
+	retries         int
+	retryBackoff    time.Duration
+	monitorURLs     []string
+	monitor         *auditMonitorClient
 	include         struct {
 		serverEndpoints  bool
 		serverProfiles   bool
@@ -40,10 +56,36 @@ type auditGatherCmd struct {
 		streams          bool
 		consumers        bool
 	}
+	filter struct {
+		clusters   []string
+		serverName *regexp.Regexp
+		accounts   []string
+		streamName *regexp.Regexp
+	}
+	// excludedServers are the ids of servers discovered but not matching the filters, their responses are ignored
+	excludedServers        map[string]struct{}
 	captureLogWriter       io.Writer
 	serverEndpointConfigs  []auditEndpointCaptureConfig
 	accountEndpointConfigs []auditEndpointCaptureConfig
 	serverProfileNames     []string
+	// mu serializes writes to the archive, the capture log and the failures list across workers
+	mu       sync.Mutex
+	failures []auditGatherFailure
+}
+
+// auditGatherFailure is an artifact that could not be captured, after exhausting retries
+type auditGatherFailure struct {
+	Artifact string `json:"artifact"`
+	Source   string `json:"source"`
+	Snapshot string `json:"snapshot,omitempty"`
+	Error    string `json:"error"`
+}
+
+func (f auditGatherFailure) String() string {
+	if f.Snapshot != "" {
+		return fmt.Sprintf("%s from %s (snapshot %s): %s", f.Artifact, f.Source, f.Snapshot, f.Error)
+	}
+	return fmt.Sprintf("%s from %s: %s", f.Artifact, f.Source, f.Error)
 }
 
 // auditEndpointCaptureConfig configuration for capturing and tagging server and account endpoints
@@ -51,6 +93,10 @@ type auditEndpointCaptureConfig struct {
 	apiSuffix     string
 	responseValue any
 	typeTag       *archive.Tag
+	// monitorPath is the equivalent HTTP monitoring endpoint, and monitorField the field of its response holding the
+	// same data returned by the system API (if not the entire response)
+	monitorPath  string
+	monitorField string
 }
 
 // serverAPIResponseNoData is a modified version of server.ServerAPIResponse that inhibits deserialization of the
@@ -62,53 +108,72 @@ type serverAPIResponseNoData struct {
 	Error  *server.ApiError   `json:"error,omitempty"`
 }
 
-func configureAuditGatherCommand(srv *fisk.CmdClause) {
-	c := &auditGatherCmd{
+// newAuditGatherCmd creates a gather command with the default set of endpoints and profiles to capture
+func newAuditGatherCmd() *auditGatherCmd {
+	return &auditGatherCmd{
 		serverEndpointConfigs: []auditEndpointCaptureConfig{
 			{
 				"VARZ",
 				server.Varz{},
 				archive.TagServerVars(),
+				"varz",
+				"",
 			},
 			{
 				"CONNZ",
 				server.Connz{},
 				archive.TagServerConnections(),
+				"connz",
+				"",
 			},
 			{
 				"ROUTEZ",
 				server.Routez{},
 				archive.TagServerRoutes(),
+				"routez",
+				"",
 			},
 			{
 				"GATEWAYZ",
 				server.Gatewayz{},
 				archive.TagServerGateways(),
+				"gatewayz",
+				"",
 			},
 			{
 				"LEAFZ",
 				server.Leafz{},
 				archive.TagServerLeafs(),
+				"leafz",
+				"",
 			},
 			{
 				"SUBSZ",
 				server.Subsz{},
 				archive.TagServerSubs(),
+				"subsz",
+				"",
 			},
 			{
 				"JSZ",
 				server.JSInfo{},
 				archive.TagServerJetStream(),
+				"jsz",
+				"",
 			},
 			{
 				"ACCOUNTZ",
 				server.Accountz{},
 				archive.TagServerAccounts(),
+				"accountz",
+				"",
 			},
 			{
 				"HEALTHZ",
 				server.HealthStatus{},
 				archive.TagServerHealth(),
+				"healthz",
+				"",
 			},
 		},
 		accountEndpointConfigs: []auditEndpointCaptureConfig{
@@ -116,26 +181,36 @@ func configureAuditGatherCommand(srv *fisk.CmdClause) {
 				"CONNZ",
 				server.Connz{},
 				archive.TagAccountConnections(),
+				"connz",
+				"",
 			},
 			{
 				"LEAFZ",
 				server.Leafz{},
 				archive.TagAccountLeafs(),
+				"leafz",
+				"",
 			},
 			{
 				"SUBSZ",
 				server.Subsz{},
 				archive.TagAccountSubs(),
+				"subsz",
+				"",
 			},
 			{
 				"INFO",
 				server.AccountInfo{},
 				archive.TagAccountInfo(),
+				"accountz",
+				"account_detail",
 			},
 			{
 				"JSZ",
 				server.JetStreamStats{},
 				archive.TagAccountJetStream(),
+				"jsz",
+				"account_details",
 			},
 		},
 		serverProfileNames: []string{
@@ -144,26 +219,70 @@ func configureAuditGatherCommand(srv *fisk.CmdClause) {
 			"allocs",
 		},
 	}
+}
+
+func configureAuditGatherCommand(srv *fisk.CmdClause) {
+	c := newAuditGatherCmd()
 
 	gather := srv.Command("gather", "capture a variety of data from a deployment into an archive file").Action(c.gather)
 	gather.Flag("output", "output file path of generated archive").Short('o').StringVar(&c.archiveFilePath)
+	c.protection.configureFlags(gather)
 	gather.Flag("progress", "Display progress messages during gathering").Default("true").BoolVar(&c.progress)
+	gather.Flag("count", "Number of snapshots to capture into the archive").Default("1").UintVar(&c.count)
+	gather.Flag("interval", "Time between the start of consecutive snapshots").Default("1m").DurationVar(&c.interval)
+	gather.Flag("workers", "Number of servers and accounts to query concurrently").Default("8").IntVar(&c.workers)
+	gather.Flag("request-timeout", "Time to wait for responses to each request, defaults to --timeout").PlaceHolder("DURATION").DurationVar(&c.requestTimeout)
+	gather.Flag("retries", "Number of times a failed request is retried").Default("2").IntVar(&c.retries)
+	gather.Flag("retry-backoff", "Time to wait before the first retry, doubled on each subsequent retry").Default("1s").DurationVar(&c.retryBackoff)
+	gather.Flag("monitor-url", "Gather from the HTTP monitoring endpoint at this URL, rather than through the system account (can be repeated)").PlaceHolder("URL").StringsVar(&c.monitorURLs)
 	gather.Flag("server-endpoints", "Capture monitoring endpoints for each server").Default("true").BoolVar(&c.include.serverEndpoints)
 	gather.Flag("server-profiles", "Capture profiles for each server").Default("true").BoolVar(&c.include.serverProfiles)
 	gather.Flag("account-endpoints", "Capture monitoring endpoints for each account").Default("true").BoolVar(&c.include.accountEndpoints)
 	gather.Flag("streams", "Capture state of each stream").Default("true").BoolVar(&c.include.streams)
 	gather.Flag("consumers", "Capture state of each stream consumers").Default("true").BoolVar(&c.include.consumers)
+	gather.Flag("cluster", "Only capture servers in this cluster (can be repeated)").PlaceHolder("NAME").StringsVar(&c.filter.clusters)
+	gather.Flag("server-name", "Only capture servers with a name matching this regular expression").PlaceHolder("REGEX").RegexpVar(&c.filter.serverName)
+	gather.Flag("account", "Only capture this account (can be repeated)").PlaceHolder("ACCOUNT").StringsVar(&c.filter.accounts)
+	gather.Flag("stream", "Only capture streams with a name matching this regular expression").PlaceHolder("REGEX").RegexpVar(&c.filter.streamName)
 }
 
 const auditServerProfilesFileExtension = "prof"
 
+// auditGatherLogTag tags the output of the gather command, included in the archive
+var auditGatherLogTag = archive.TagSpecial("audit_gather_log")
+
 func (c *auditGatherCmd) gather(_ *fisk.ParseContext) error {
+	if c.count < 1 {
+		return fmt.Errorf("count must be at least 1")
+	}
+	if c.count > 1 && c.interval <= 0 {
+		return fmt.Errorf("interval must be positive when capturing multiple snapshots")
+	}
+	if c.workers < 1 {
+		return fmt.Errorf("workers must be at least 1")
+	}
+	if c.requestTimeout == 0 {
+		c.requestTimeout = opts.Timeout
+	}
+	if c.requestTimeout <= 0 {
+		return fmt.Errorf("request timeout must be positive")
+	}
+	if c.retries < 0 {
+		return fmt.Errorf("retries cannot be negative")
+	}
 
-	nc, err := newNatsConn("", natsOpts()...)
-	if err != nil {
-		return err
+	// Without system account credentials, data can be gathered from the HTTP monitoring endpoint of each server
+	var nc *nats.Conn
+	if len(c.monitorURLs) > 0 {
+		c.monitor = newAuditMonitorClient(c.monitorURLs)
+	} else {
+		var err error
+		nc, err = newNatsConn("", natsOpts()...)
+		if err != nil {
+			return err
+		}
+		defer nc.Close()
 	}
-	defer nc.Close()
 
 	// If no output path is specified, create one
 	if c.archiveFilePath == "" {
@@ -175,15 +294,15 @@ func (c *auditGatherCmd) gather(_ *fisk.ParseContext) error {
 	var captureLogBuffer bytes.Buffer
 	c.captureLogWriter = &captureLogBuffer
 
-	// Create an archive writer
-	aw, err := archive.NewWriter(c.archiveFilePath)
+	// Create an archive writer, optionally sealed and signed
+	aw, err := c.protection.newWriter(c.archiveFilePath)
 	if err != nil {
-		return fmt.Errorf("failed to create archive: %w", err)
+		return err
 	}
 	defer func() {
 		// Add the output of this command (so far) to the archive as additional log artifact
 		if c.captureLogWriter != nil {
-			err = aw.AddRaw(bytes.NewReader(captureLogBuffer.Bytes()), "log", archive.TagSpecial("audit_gather_log"))
+			err = aw.AddRaw(bytes.NewReader(captureLogBuffer.Bytes()), "log", auditGatherLogTag)
 			if err != nil {
 				fmt.Printf("Failed to add capture log: %s\n", err)
 			}
@@ -209,18 +328,72 @@ func (c *auditGatherCmd) gather(_ *fisk.ParseContext) error {
 		return fmt.Errorf("failed to discover accounts: %w", err)
 	}
 
+	// Narrow down servers and accounts to the ones matching the filters (if any)
+	if scope := c.scope(); len(scope) > 0 {
+		c.logProgress("Capture limited to: %s", strings.Join(scope, ", "))
+	}
+	serverInfoMap = c.filterServers(serverInfoMap)
+	if len(serverInfoMap) == 0 {
+		return fmt.Errorf("no servers matching the given filters")
+	}
+	accountIdsToServersCountMap = c.filterAccounts(accountIdsToServersCountMap)
+	if len(accountIdsToServersCountMap) == 0 && len(c.filter.accounts) > 0 {
+		return fmt.Errorf("no accounts matching the given filters")
+	}
+
+	// Capture one or more snapshots of the servers and accounts discovered above
+	captureStart := time.Now()
+	for snapshot := uint(0); snapshot < c.count; snapshot++ {
+		if snapshot > 0 {
+			nextCapture := captureStart.Add(time.Duration(snapshot) * c.interval)
+			c.logProgress("Waiting %s for next snapshot...", time.Until(nextCapture).Round(time.Second))
+			select {
+			case <-time.After(time.Until(nextCapture)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		// When capturing multiple snapshots, each artifact is tagged with the time its snapshot started
+		if c.count > 1 {
+			captureTime := time.Now()
+			c.snapshotTag = archive.TagSnapshot(captureTime)
+			c.logProgress("Capturing snapshot %d/%d (%s)", snapshot+1, c.count, captureTime.Format(time.RFC3339))
+		}
+
+		err = c.captureSnapshot(nc, serverInfoMap, accountIdsToServersCountMap, systemAccount, aw)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.logFailuresSummary()
+
+	// Capture metadata
+	err = c.captureMetadata(nc, aw)
+	if err != nil {
+		return fmt.Errorf("failed to capture metadata: %w", err)
+	}
+
+	return nil
+}
+
+// Capture a single snapshot of the given servers and accounts
+func (c *auditGatherCmd) captureSnapshot(nc *nats.Conn, serverInfoMap map[string]*server.ServerInfo, accountIdsToServersCountMap map[string]int, systemAccount string, aw *archive.Writer) error {
 	// Capture server endpoints
 	if c.include.serverEndpoints {
 		err := c.captureServerEndpoints(nc, serverInfoMap, aw)
 		if err != nil {
-			return fmt.Errorf("failed to capture server endpoints")
+			return fmt.Errorf("failed to capture server endpoints: %w", err)
 		}
 	} else {
 		c.logProgress("Skipping servers endpoints data gathering")
 	}
 
-	// Capture server profiles
-	if c.include.serverProfiles {
+	// Capture server profiles, not available through the monitoring endpoints
+	if c.include.serverProfiles && c.monitor != nil {
+		c.logProgress("Skipping server profiles gathering, not available through monitoring endpoints")
+	} else if c.include.serverProfiles {
 		err := c.captureServerProfiles(nc, serverInfoMap, aw)
 		if err != nil {
 			return fmt.Errorf("failed to capture server profiles: %w", err)
@@ -242,31 +415,242 @@ func (c *auditGatherCmd) gather(_ *fisk.ParseContext) error {
 	// Discover and capture streams in each account
 	if c.include.streams {
 		c.logProgress("Gathering streams data...")
+		tasks := make([]func() error, 0, len(accountIdsToServersCountMap))
 		for accountId, numServers := range accountIdsToServersCountMap {
 			// Skip system account, JetStream is probably not enabled
 			if accountId == systemAccount {
 				continue
 			}
-			err := c.captureAccountStreams(nc, serverInfoMap, accountId, numServers, aw)
-			if err != nil {
-				c.logWarning("Failed to capture streams for account %s", accountId)
-			}
+			accountId, numServers := accountId, numServers
+			tasks = append(tasks, func() error {
+				err := c.captureAccountStreams(nc, serverInfoMap, accountId, numServers, aw)
+				if err != nil {
+					c.recordFailure("streams", "account "+accountId, err)
+				}
+				return nil
+			})
 		}
+		_ = c.parallel(tasks)
 	} else {
 		c.logProgress("Skipping streams data gathering")
 	}
 
-	// Capture metadata
-	err = c.captureMetadata(nc, aw)
-	if err != nil {
-		return fmt.Errorf("failed to capture metadata: %w", err)
+	return nil
+}
+
+// snapshotTags adds the current snapshot tag (if any) to the given artifact tags
+func (c *auditGatherCmd) snapshotTags(tags []*archive.Tag) []*archive.Tag {
+	if c.snapshotTag == nil {
+		return tags
 	}
+	return append(tags, c.snapshotTag)
+}
 
-	return nil
+// scope describes the filters applied to the capture, if any
+func (c *auditGatherCmd) scope() []string {
+	scope := make([]string, 0)
+	for _, clusterName := range c.filter.clusters {
+		scope = append(scope, "cluster="+clusterName)
+	}
+	if c.filter.serverName != nil {
+		scope = append(scope, "server-name="+c.filter.serverName.String())
+	}
+	for _, accountId := range c.filter.accounts {
+		scope = append(scope, "account="+accountId)
+	}
+	if c.filter.streamName != nil {
+		scope = append(scope, "stream="+c.filter.streamName.String())
+	}
+	return scope
+}
+
+// filterServers returns the servers in the selected clusters and with a name matching the selected pattern (if any).
+// Servers excluded are tracked, so that their responses to broadcast requests can be ignored.
+func (c *auditGatherCmd) filterServers(serverInfoMap map[string]*server.ServerInfo) map[string]*server.ServerInfo {
+	if len(c.filter.clusters) == 0 && c.filter.serverName == nil {
+		return serverInfoMap
+	}
+
+	c.excludedServers = make(map[string]struct{})
+	selectedServers := make(map[string]*server.ServerInfo)
+	for serverId, serverInfo := range serverInfoMap {
+		if len(c.filter.clusters) > 0 && !slices.Contains(c.filter.clusters, serverInfo.Cluster) {
+			c.excludedServers[serverId] = struct{}{}
+			continue
+		}
+		if c.filter.serverName != nil && !c.filter.serverName.MatchString(serverInfo.Name) {
+			c.excludedServers[serverId] = struct{}{}
+			continue
+		}
+		selectedServers[serverId] = serverInfo
+	}
+
+	c.logProgress("Selected %d of %d servers matching filters", len(selectedServers), len(serverInfoMap))
+	return selectedServers
+}
+
+// filterAccounts returns the selected accounts, if any
+func (c *auditGatherCmd) filterAccounts(accountIdsToServersCountMap map[string]int) map[string]int {
+	if len(c.filter.accounts) == 0 {
+		return accountIdsToServersCountMap
+	}
+
+	selectedAccounts := make(map[string]int)
+	for _, accountId := range c.filter.accounts {
+		serversCount, found := accountIdsToServersCountMap[accountId]
+		if !found {
+			// Accounts without connections are not discovered
+			c.logWarning("Account %s not found, it may not have any connections", accountId)
+			continue
+		}
+		selectedAccounts[accountId] = serversCount
+	}
+
+	c.logProgress("Selected %d of %d accounts matching filters", len(selectedAccounts), len(accountIdsToServersCountMap))
+	return selectedAccounts
+}
+
+// streamSelected returns true if the stream name matches the selected pattern (if any)
+func (c *auditGatherCmd) streamSelected(streamName string) bool {
+	return c.filter.streamName == nil || c.filter.streamName.MatchString(streamName)
+}
+
+// serverExcluded returns true if the server was discovered, but does not match the filters
+func (c *auditGatherCmd) serverExcluded(serverId string) bool {
+	_, excluded := c.excludedServers[serverId]
+	return excluded
+}
+
+// parallel runs the given tasks using up to the configured number of workers, and returns the first error encountered.
+// All tasks are run regardless of errors.
+func (c *auditGatherCmd) parallel(tasks []func() error) error {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		queue    = make(chan func() error)
+	)
+
+	for i := 0; i < min(max(c.workers, 1), len(tasks)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range queue {
+				err := task()
+				if err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		queue <- task
+	}
+	close(queue)
+	wg.Wait()
+
+	return firstErr
+}
+
+// retry calls fn until it succeeds, up to the configured number of retries, waiting between attempts with
+// exponential backoff. Returns the error of the last attempt.
+func (c *auditGatherCmd) retry(fn func() error) error {
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= c.retries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+// request sends a request and waits for the given number of responses, up to the request timeout. Requests that fail
+// or receive fewer responses than expected are retried. Returns the responses of the most complete attempt, or an
+// error if no responses were received.
+func (c *auditGatherCmd) request(req any, subject string, waitFor int, nc *nats.Conn) ([][]byte, error) {
+	var responses [][]byte
+	err := c.retry(func() error {
+		attemptResponses, err := doReqWithTimeout(req, subject, waitFor, c.requestTimeout, nc)
+		if len(attemptResponses) > len(responses) {
+			responses = attemptResponses
+		}
+		if err != nil {
+			return err
+		}
+		if len(attemptResponses) < waitFor {
+			return fmt.Errorf("received %d of %d expected responses within %s", len(attemptResponses), waitFor, c.requestTimeout)
+		}
+		return nil
+	})
+	if len(responses) == 0 {
+		return nil, err
+	}
+	return responses, nil
+}
+
+// addArtifact adds an artifact to the archive, tagged with the current snapshot (if any)
+func (c *auditGatherCmd) addArtifact(aw *archive.Writer, artifact any, tags ...*archive.Tag) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return aw.Add(artifact, c.snapshotTags(tags)...)
+}
+
+// addRawArtifact adds a raw artifact to the archive, tagged with the current snapshot (if any)
+func (c *auditGatherCmd) addRawArtifact(aw *archive.Writer, data []byte, extension string, tags ...*archive.Tag) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return aw.AddRaw(bytes.NewReader(data), extension, c.snapshotTags(tags)...)
+}
+
+// recordFailure logs an artifact that could not be captured, and tracks it to be included in the summary and metadata
+func (c *auditGatherCmd) recordFailure(artifact string, source string, err error) {
+	failure := auditGatherFailure{
+		Artifact: artifact,
+		Source:   source,
+		Error:    err.Error(),
+	}
+	if c.snapshotTag != nil {
+		failure.Snapshot = c.snapshotTag.Value
+	}
+
+	c.logWarning("Failed to capture %s", failure)
+
+	c.mu.Lock()
+	c.failures = append(c.failures, failure)
+	c.mu.Unlock()
+}
+
+// logFailuresSummary lists all artifacts that could not be captured
+func (c *auditGatherCmd) logFailuresSummary() {
+	if len(c.failures) == 0 {
+		c.logProgress("All artifacts captured successfully")
+		return
+	}
+
+	c.logWarning("Failed to capture %d artifacts:", len(c.failures))
+	for _, failure := range c.failures {
+		c.logWarning("  %s", failure)
+	}
 }
 
 // Discover servers by broadcasting a PING and then collecting responses
 func (c *auditGatherCmd) discoverServers(nc *nats.Conn) (map[string]*server.ServerInfo, error) {
+	if c.monitor != nil {
+		return c.discoverMonitoredServers()
+	}
+
 	var serverInfoMap = make(map[string]*server.ServerInfo)
 	c.logProgress("Broadcasting PING to discover servers... (this may take a few seconds)")
 	err := doReqAsync(nil, "$SYS.REQ.SERVER.PING", 0, nc, func(b []byte) {
@@ -296,12 +680,9 @@ func (c *auditGatherCmd) discoverServers(nc *nats.Conn) (map[string]*server.Serv
 
 // Discover accounts by broadcasting a PING and then collecting responses
 func (c *auditGatherCmd) discoverAccounts(nc *nats.Conn, serverInfoMap map[string]*server.ServerInfo) (map[string]int, string, error) {
-	// Broadcast PING.ACCOUNTZ to discover (active) accounts
-	// N.B. Inactive accounts (no connections) cannot be discovered this way
-	c.logProgress("Broadcasting PING to discover accounts... ")
 	var accountIdsToServersCountMap = make(map[string]int)
 	var systemAccount = ""
-	err := doReqAsync(nil, "$SYS.REQ.SERVER.PING.ACCOUNTZ", len(serverInfoMap), nc, func(b []byte) {
+	handleResponse := func(b []byte) {
 		var apiResponse serverAPIResponseNoData
 		err := json.Unmarshal(b, &apiResponse)
 		if err != nil {
@@ -327,6 +708,9 @@ func (c *auditGatherCmd) discoverAccounts(nc *nats.Conn, serverInfoMap map[strin
 		}
 
 		c.logProgress("Discovered %d accounts on server %s", len(accountsResponse.Accounts), serverName)
+		if c.monitor != nil {
+			c.monitor.setAccounts(serverId, accountsResponse.Accounts)
+		}
 
 		// Track how many servers known any given account
 		for _, accountId := range accountsResponse.Accounts {
@@ -347,9 +731,26 @@ func (c *auditGatherCmd) discoverAccounts(nc *nats.Conn, serverInfoMap map[strin
 			// This should not happen under normal circumstances!
 			c.logWarning("Multiple system accounts detected (%s, %s)", systemAccount, accountsResponse.SystemAccount)
 		}
-	})
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to discover accounts: %w", err)
+	}
+
+	if c.monitor != nil {
+		c.logProgress("Querying monitoring endpoints to discover accounts...")
+		for serverId, serverInfo := range serverInfoMap {
+			response, err := c.monitorRequest(serverId, "accountz", "", nil)
+			if err != nil {
+				c.logWarning("Failed to discover accounts on server %s: %s", serverInfo.Name, err)
+				continue
+			}
+			handleResponse(response)
+		}
+	} else {
+		// Broadcast PING.ACCOUNTZ to discover (active) accounts
+		// N.B. Inactive accounts (no connections) cannot be discovered this way
+		c.logProgress("Broadcasting PING to discover accounts... ")
+		err := doReqAsync(nil, "$SYS.REQ.SERVER.PING.ACCOUNTZ", len(serverInfoMap), nc, handleResponse)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to discover accounts: %w", err)
+		}
 	}
 	c.logProgress("Discovered %d accounts over %d servers", len(accountIdsToServersCountMap), len(serverInfoMap))
 	return accountIdsToServersCountMap, systemAccount, nil
@@ -358,216 +759,282 @@ func (c *auditGatherCmd) discoverAccounts(nc *nats.Conn, serverInfoMap map[strin
 // Capture configured endpoints for each known server
 func (c *auditGatherCmd) captureServerEndpoints(nc *nats.Conn, serverInfoMap map[string]*server.ServerInfo, aw *archive.Writer) error {
 	c.logProgress("Querying %d endpoints on %d known servers...", len(c.serverEndpointConfigs), len(serverInfoMap))
-	capturedCount := 0
+	var capturedCount atomic.Int64
+	tasks := make([]func() error, 0, len(serverInfoMap))
 	for serverId, serverInfo := range serverInfoMap {
-		serverName := serverInfo.Name
-		for _, endpoint := range c.serverEndpointConfigs {
-
-			subject := fmt.Sprintf("$SYS.REQ.SERVER.%s.%s", serverId, endpoint.apiSuffix)
-
-			endpointResponse := reflect.New(reflect.TypeOf(endpoint.responseValue)).Interface()
+		serverId, serverInfo := serverId, serverInfo
+		tasks = append(tasks, func() error {
+			serverName := serverInfo.Name
+			for _, endpoint := range c.serverEndpointConfigs {
+				endpointResponse, err := c.requestServerEndpoint(nc, serverId, endpoint)
+				if err != nil {
+					c.recordFailure(endpoint.apiSuffix, "server "+serverName, err)
+					continue
+				}
 
-			responses, err := doReq(nil, subject, 1, nc)
-			if err != nil {
-				c.logWarning("Failed to request %s from server %s: %s", endpoint.apiSuffix, serverName, err)
-				continue
-			}
+				tags := []*archive.Tag{
+					archive.TagServer(serverName), // Source server
+					endpoint.typeTag,              // Type of artifact
+				}
 
-			if len(responses) != 1 {
-				c.logWarning("Unexpected number of responses to %s from server %s: %d", endpoint.apiSuffix, serverName, len(responses))
-				continue
-			}
+				if serverInfo.Cluster != "" {
+					tags = append(tags, archive.TagCluster(serverInfo.Cluster))
+				} else {
+					tags = append(tags, archive.TagNoCluster())
+				}
 
-			responseBytes := responses[0]
+				err = c.addArtifact(aw, endpointResponse, tags...)
+				if err != nil {
+					return fmt.Errorf("failed to add endpoint %s response to archive: %w", endpoint.apiSuffix, err)
+				}
 
-			var apiResponse serverAPIResponseNoData
-			if err = json.Unmarshal(responseBytes, &apiResponse); err != nil {
-				c.logWarning("Failed to deserialize %s response from server %s: %s", endpoint.apiSuffix, serverName, err)
-				continue
-			}
-
-			err = json.Unmarshal(apiResponse.Data, endpointResponse)
-			if err != nil {
-				c.logWarning("Failed to deserialize %s response data from server %s: %s", endpoint.apiSuffix, serverName, err)
-				continue
+				capturedCount.Add(1)
 			}
+			return nil
+		})
+	}
+	err := c.parallel(tasks)
+	if err != nil {
+		return err
+	}
+	c.logProgress("Captured %d endpoint responses from %d servers", capturedCount.Load(), len(serverInfoMap))
+	return nil
+}
 
-			tags := []*archive.Tag{
-				archive.TagServer(serverName), // Source server
-				endpoint.typeTag,              // Type of artifact
-			}
+// requestServerEndpoint requests an endpoint from the given server and deserializes the response
+func (c *auditGatherCmd) requestServerEndpoint(nc *nats.Conn, serverId string, endpoint auditEndpointCaptureConfig) (any, error) {
+	var responses [][]byte
+	var err error
+	if c.monitor != nil {
+		var response []byte
+		response, err = c.monitorRequest(serverId, endpoint.monitorPath, endpoint.monitorField, nil)
+		responses = [][]byte{response}
+	} else {
+		subject := fmt.Sprintf("$SYS.REQ.SERVER.%s.%s", serverId, endpoint.apiSuffix)
+		responses, err = c.request(nil, subject, 1, nc)
+	}
+	if err != nil {
+		return nil, err
+	}
 
-			if serverInfo.Cluster != "" {
-				tags = append(tags, archive.TagCluster(serverInfo.Cluster))
-			} else {
-				tags = append(tags, archive.TagNoCluster())
-			}
+	if len(responses) != 1 {
+		return nil, fmt.Errorf("unexpected number of responses: %d", len(responses))
+	}
 
-			err = aw.Add(endpointResponse, tags...)
-			if err != nil {
-				return fmt.Errorf("failed to add endpoint %s response to archive: %w", subject, err)
-			}
+	var apiResponse serverAPIResponseNoData
+	if err = json.Unmarshal(responses[0], &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to deserialize response: %w", err)
+	}
+	if apiResponse.Error != nil {
+		return nil, fmt.Errorf("request failed: %s", apiResponse.Error.Description)
+	}
 
-			capturedCount += 1
-		}
+	endpointResponse := reflect.New(reflect.TypeOf(endpoint.responseValue)).Interface()
+	err = json.Unmarshal(apiResponse.Data, endpointResponse)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize response data: %w", err)
 	}
-	c.logProgress("Captured %d endpoint responses from %d servers", capturedCount, len(serverInfoMap))
-	return nil
+
+	return endpointResponse, nil
 }
 
 // Capture configured profiles for each known server
 func (c *auditGatherCmd) captureServerProfiles(nc *nats.Conn, serverInfoMap map[string]*server.ServerInfo, aw *archive.Writer) error {
 	c.logProgress("Capturing %d profiles on %d known servers...", len(c.serverProfileNames), len(serverInfoMap))
-	capturedCount := 0
+	var capturedCount atomic.Int64
+	tasks := make([]func() error, 0, len(serverInfoMap))
 	for serverId, serverInfo := range serverInfoMap {
-		serverName := serverInfo.Name
-		clusterTag := archive.TagNoCluster()
-		if serverInfo.Cluster != "" {
-			clusterTag = archive.TagCluster(serverInfo.Cluster)
-		}
+		serverId, serverInfo := serverId, serverInfo
+		tasks = append(tasks, func() error {
+			serverName := serverInfo.Name
+			clusterTag := archive.TagNoCluster()
+			if serverInfo.Cluster != "" {
+				clusterTag = archive.TagCluster(serverInfo.Cluster)
+			}
 
-		for _, profileName := range c.serverProfileNames {
+			for _, profileName := range c.serverProfileNames {
+				profileDataBytes, err := c.requestServerProfile(nc, serverId, profileName)
+				if err != nil {
+					c.recordFailure(profileName+" profile", "server "+serverName, err)
+					continue
+				}
 
-			subject := fmt.Sprintf("$SYS.REQ.SERVER.%s.PROFILEZ", serverId)
-			payload := server.ProfilezOptions{
-				Name:  profileName,
-				Debug: 0,
-			}
+				tags := []*archive.Tag{
+					archive.TagServer(serverName),
+					archive.TagServerProfile(),
+					archive.TagProfileName(profileName),
+					clusterTag,
+				}
 
-			responses, err := doReq(payload, subject, 1, nc)
-			if err != nil {
-				c.logWarning("Failed to request %s profile from server %s: %s", profileName, serverName, err)
-				continue
-			}
+				err = c.addRawArtifact(aw, profileDataBytes, auditServerProfilesFileExtension, tags...)
+				if err != nil {
+					return fmt.Errorf("failed to add %s profile from to archive: %w", profileName, err)
+				}
 
-			if len(responses) != 1 {
-				c.logWarning("Unexpected number of responses to %s profile from server %s: %d", profileName, serverName, len(responses))
-				continue
+				capturedCount.Add(1)
 			}
+			return nil
+		})
+	}
+	err := c.parallel(tasks)
+	if err != nil {
+		return err
+	}
+	c.logProgress("Captured %d server profiles from %d servers", capturedCount.Load(), len(serverInfoMap))
+	return nil
+}
 
-			responseBytes := responses[0]
+// requestServerProfile requests a profile from the given server and returns the profile data
+func (c *auditGatherCmd) requestServerProfile(nc *nats.Conn, serverId string, profileName string) ([]byte, error) {
+	subject := fmt.Sprintf("$SYS.REQ.SERVER.%s.PROFILEZ", serverId)
+	payload := server.ProfilezOptions{
+		Name:  profileName,
+		Debug: 0,
+	}
 
-			var apiResponse struct {
-				Server *server.ServerInfo     `json:"server"`
-				Data   *server.ProfilezStatus `json:"data,omitempty"`
-				Error  *server.ApiError       `json:"error,omitempty"`
-			}
-			if err = json.Unmarshal(responseBytes, &apiResponse); err != nil {
-				c.logWarning("Failed to deserialize %s profile response from server %s: %s", profileName, serverName, err)
-				continue
-			}
-			if apiResponse.Error != nil {
-				c.logWarning("Failed to retrieve %s profile from server %s: %s", profileName, serverName, apiResponse.Error.Description)
-				continue
-			}
+	responses, err := c.request(payload, subject, 1, nc)
+	if err != nil {
+		return nil, err
+	}
 
-			profileStatus := apiResponse.Data
-			if profileStatus.Error != "" {
-				c.logWarning("Failed to retrieve %s profile from server %s: %s", profileName, serverName, profileStatus.Error)
-				continue
-			}
+	if len(responses) != 1 {
+		return nil, fmt.Errorf("unexpected number of responses: %d", len(responses))
+	}
 
-			tags := []*archive.Tag{
-				archive.TagServer(serverName),
-				archive.TagServerProfile(),
-				archive.TagProfileName(profileName),
-				clusterTag,
-			}
+	var apiResponse struct {
+		Server *server.ServerInfo     `json:"server"`
+		Data   *server.ProfilezStatus `json:"data,omitempty"`
+		Error  *server.ApiError       `json:"error,omitempty"`
+	}
+	if err = json.Unmarshal(responses[0], &apiResponse); err != nil {
+		return nil, fmt.Errorf("failed to deserialize response: %w", err)
+	}
+	if apiResponse.Error != nil {
+		return nil, fmt.Errorf("request failed: %s", apiResponse.Error.Description)
+	}
+	if apiResponse.Data == nil {
+		return nil, fmt.Errorf("response has no data")
+	}
+	if apiResponse.Data.Error != "" {
+		return nil, fmt.Errorf("profiling failed: %s", apiResponse.Data.Error)
+	}
 
-			profileDataBytes := apiResponse.Data.Profile
+	return apiResponse.Data.Profile, nil
+}
 
-			err = aw.AddRaw(bytes.NewReader(profileDataBytes), auditServerProfilesFileExtension, tags...)
-			if err != nil {
-				return fmt.Errorf("failed to add %s profile from to archive: %w", profileName, err)
+// Capture configured endpoints for each known account
+func (c *auditGatherCmd) captureAccountEndpoints(nc *nats.Conn, serverInfoMap map[string]*server.ServerInfo, accountIdsToServersCountMap map[string]int, aw *archive.Writer) error {
+	c.logProgress("Querying %d endpoints for %d known accounts...", len(c.accountEndpointConfigs), len(accountIdsToServersCountMap))
+	var capturedCount atomic.Int64
+	tasks := make([]func() error, 0, len(accountIdsToServersCountMap))
+	for accountId, serversCount := range accountIdsToServersCountMap {
+		accountId, serversCount := accountId, serversCount
+		tasks = append(tasks, func() error {
+			for _, endpoint := range c.accountEndpointConfigs {
+				captured, err := c.captureAccountEndpoint(nc, serverInfoMap, accountId, serversCount, endpoint, aw)
+				if err != nil {
+					return err
+				}
+				capturedCount.Add(int64(captured))
 			}
-
-			capturedCount += 1
-		}
+			return nil
+		})
+	}
+	err := c.parallel(tasks)
+	if err != nil {
+		return err
 	}
-	c.logProgress("Captured %d server profiles from %d servers", capturedCount, len(serverInfoMap))
+	c.logProgress("Captured %d endpoint responses from %d accounts", capturedCount.Load(), len(accountIdsToServersCountMap))
 	return nil
 }
 
-// Capture configured endpoints for each known account
-func (c *auditGatherCmd) captureAccountEndpoints(nc *nats.Conn, serverInfoMap map[string]*server.ServerInfo, accountIdsToServersCountMap map[string]int, aw *archive.Writer) error {
+// captureAccountEndpoint requests an endpoint for the given account from all servers that know it, and adds each
+// response to the archive. Returns the number of responses captured.
+func (c *auditGatherCmd) captureAccountEndpoint(nc *nats.Conn, serverInfoMap map[string]*server.ServerInfo, accountId string, serversCount int, endpoint auditEndpointCaptureConfig, aw *archive.Writer) (int, error) {
 	type Responder struct {
 		ClusterName string
 		ServerName  string
 	}
-	capturedCount := 0
-	c.logProgress("Querying %d endpoints for %d known accounts...", len(c.accountEndpointConfigs), len(accountIdsToServersCountMap))
-	for accountId, serversCount := range accountIdsToServersCountMap {
-		for _, endpoint := range c.accountEndpointConfigs {
-			subject := fmt.Sprintf("$SYS.REQ.ACCOUNT.%s.%s", accountId, endpoint.apiSuffix)
-			endpointResponses := make(map[Responder]any, serversCount)
 
-			err := doReqAsync(nil, subject, serversCount, nc, func(b []byte) {
-				var apiResponse serverAPIResponseNoData
-				err := json.Unmarshal(b, &apiResponse)
-				if err != nil {
-					c.logWarning("Failed to deserialize %s response for account %s: %s", endpoint.apiSuffix, accountId, err)
-					return
-				}
+	subject := fmt.Sprintf("$SYS.REQ.ACCOUNT.%s.%s", accountId, endpoint.apiSuffix)
+	endpointResponses := make(map[Responder]any, serversCount)
 
-				serverId := apiResponse.Server.ID
+	var responses [][]byte
+	if c.monitor != nil {
+		responses = c.monitorAccountRequest(serverInfoMap, accountId, endpoint.apiSuffix, endpoint.monitorPath, endpoint.monitorField, url.Values{"acc": {accountId}})
+	} else {
+		var err error
+		responses, err = c.request(nil, subject, serversCount, nc)
+		if err != nil {
+			c.recordFailure(endpoint.apiSuffix, "account "+accountId, err)
+			return 0, nil
+		}
+		if len(responses) < serversCount {
+			c.recordFailure(endpoint.apiSuffix, "account "+accountId, fmt.Errorf("received %d of %d expected responses", len(responses), serversCount))
+		}
+	}
 
-				// Ignore responses from servers not discovered earlier.
-				// We are discarding useful data, but limiting additional collection to a fixed set of nodes
-				// simplifies querying and analysis. Could always re-run gather if a new server just joined.
-				if _, serverKnown := serverInfoMap[serverId]; !serverKnown {
-					c.logWarning("Ignoring account %s response from unknown server: %s", endpoint.apiSuffix, serverId)
-					return
-				}
+	for _, b := range responses {
+		var apiResponse serverAPIResponseNoData
+		err := json.Unmarshal(b, &apiResponse)
+		if err != nil {
+			c.logWarning("Failed to deserialize %s response for account %s: %s", endpoint.apiSuffix, accountId, err)
+			continue
+		}
 
-				endpointResponse := reflect.New(reflect.TypeOf(endpoint.responseValue)).Interface()
-				err = json.Unmarshal(apiResponse.Data, endpointResponse)
-				if err != nil {
-					c.logWarning("Failed to deserialize %s response for account %s: %s", endpoint.apiSuffix, accountId, err)
-					return
-				}
+		serverId := apiResponse.Server.ID
 
-				responder := Responder{
-					ClusterName: apiResponse.Server.Cluster,
-					ServerName:  apiResponse.Server.Name,
-				}
+		// Ignore responses from servers not discovered earlier.
+		// We are discarding useful data, but limiting additional collection to a fixed set of nodes
+		// simplifies querying and analysis. Could always re-run gather if a new server just joined.
+		if _, serverKnown := serverInfoMap[serverId]; !serverKnown {
+			if !c.serverExcluded(serverId) {
+				c.logWarning("Ignoring account %s response from unknown server: %s", endpoint.apiSuffix, serverId)
+			}
+			continue
+		}
 
-				if _, isDuplicateResponse := endpointResponses[responder]; isDuplicateResponse {
-					c.logWarning("Ignoring duplicate account %s response from server %s", endpoint.apiSuffix, responder.ServerName)
-					return
-				}
+		endpointResponse := reflect.New(reflect.TypeOf(endpoint.responseValue)).Interface()
+		err = json.Unmarshal(apiResponse.Data, endpointResponse)
+		if err != nil {
+			c.logWarning("Failed to deserialize %s response for account %s: %s", endpoint.apiSuffix, accountId, err)
+			continue
+		}
 
-				endpointResponses[responder] = endpointResponse
-			})
-			if err != nil {
-				c.logWarning("Failed to request %s for account %s: %s", endpoint.apiSuffix, accountId, err)
-				continue
-			}
+		responder := Responder{
+			ClusterName: apiResponse.Server.Cluster,
+			ServerName:  apiResponse.Server.Name,
+		}
 
-			// Store all responses for this account endpoint
-			for responder, endpointResponse := range endpointResponses {
-				clusterTag := archive.TagNoCluster()
-				if responder.ClusterName != "" {
-					clusterTag = archive.TagCluster(responder.ClusterName)
-				}
+		if _, isDuplicateResponse := endpointResponses[responder]; isDuplicateResponse {
+			c.logWarning("Ignoring duplicate account %s response from server %s", endpoint.apiSuffix, responder.ServerName)
+			continue
+		}
 
-				tags := []*archive.Tag{
-					archive.TagAccount(accountId),
-					archive.TagServer(responder.ServerName),
-					clusterTag,
-					endpoint.typeTag,
-				}
+		endpointResponses[responder] = endpointResponse
+	}
 
-				err = aw.Add(endpointResponse, tags...)
-				if err != nil {
-					return fmt.Errorf("failed to add response to %s to archive: %w", subject, err)
-				}
+	// Store all responses for this account endpoint
+	for responder, endpointResponse := range endpointResponses {
+		clusterTag := archive.TagNoCluster()
+		if responder.ClusterName != "" {
+			clusterTag = archive.TagCluster(responder.ClusterName)
+		}
 
-				capturedCount += 1
-			}
+		tags := []*archive.Tag{
+			archive.TagAccount(accountId),
+			archive.TagServer(responder.ServerName),
+			clusterTag,
+			endpoint.typeTag,
+		}
+
+		err := c.addArtifact(aw, endpointResponse, tags...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to add response to %s to archive: %w", subject, err)
 		}
 	}
-	c.logProgress("Captured %d endpoint responses from %d accounts", capturedCount, len(accountIdsToServersCountMap))
-	return nil
+
+	return len(endpointResponses), nil
 }
 
 // Discover streams in given account, and capture info for each one
@@ -581,13 +1048,31 @@ func (c *auditGatherCmd) captureAccountStreams(nc *nats.Conn, serverInfoMap map[
 		RaftGroups: true,
 	}
 
+	var responses [][]byte
+	var err error
+	if c.monitor != nil {
+		query := url.Values{
+			"acc":       {accountId},
+			"streams":   {"true"},
+			"consumers": {fmt.Sprint(jszOptions.Consumer)},
+			"config":    {"true"},
+			"raft":      {"true"},
+		}
+		responses = c.monitorAccountRequest(serverInfoMap, accountId, "streams", "jsz", "", query)
+	} else {
+		responses, err = c.request(jszOptions, "$SYS.REQ.SERVER.PING.JSZ", numServers, nc)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve account %s streams: %w", accountId, err)
+		}
+	}
+
 	jsInfoResponses := make(map[string]*server.JSInfo, numServers)
-	err := doReqAsync(jszOptions, "$SYS.REQ.SERVER.PING.JSZ", numServers, nc, func(b []byte) {
+	for _, b := range responses {
 		var apiResponse serverAPIResponseNoData
 		err := json.Unmarshal(b, &apiResponse)
 		if err != nil {
 			c.logWarning("Failed to deserialize JS info response for account %s: %s", accountId, err)
-			return
+			continue
 		}
 
 		serverId, serverName := apiResponse.Server.ID, apiResponse.Server.Name
@@ -596,37 +1081,36 @@ func (c *auditGatherCmd) captureAccountStreams(nc *nats.Conn, serverInfoMap map[
 		// We are discarding useful data, but limiting additional collection to a fixed set of nodes
 		// simplifies querying and analysis. Could always re-run gather if a new server just joined.
 		if _, serverKnown := serverInfoMap[serverId]; !serverKnown {
-			c.logWarning("Ignoring JS info response from unknown server: %s", serverName)
-			return
+			if !c.serverExcluded(serverId) {
+				c.logWarning("Ignoring JS info response from unknown server: %s", serverName)
+			}
+			continue
 		}
 
 		if _, isDuplicateResponse := jsInfoResponses[serverName]; isDuplicateResponse {
 			c.logWarning("Ignoring duplicate JS info response for account %s from server %s", accountId, serverName)
-			return
+			continue
 		}
 
 		jsInfoResponse := &server.JSInfo{}
 		err = json.Unmarshal(apiResponse.Data, jsInfoResponse)
 		if err != nil {
 			c.logWarning("Failed to deserialize JS info response data for account %s: %s", accountId, err)
-			return
+			continue
 		}
 
 		if len(jsInfoResponse.AccountDetails) == 0 {
 			// No account details in response, don't bother saving this
 			//c.logWarning("🐛 Skip JSZ response from %s, no accounts details", serverName)
-			return
+			continue
 		} else if len(jsInfoResponse.AccountDetails) > 1 {
 			// Server will respond with multiple accounts if the one specified in the request is not found
 			// https://github.com/nats-io/nats-server/pull/5229
 			//c.logWarning("🐛 Skip JSZ response from %s, account not found", serverName)
-			return
+			continue
 		}
 
 		jsInfoResponses[serverName] = jsInfoResponse
-	})
-	if err != nil {
-		return fmt.Errorf("failed to retrieve account %s streams: %w", accountId, err)
 	}
 
 	streamNamesSet := make(map[string]any)
@@ -638,6 +1122,9 @@ func (c *auditGatherCmd) captureAccountStreams(nc *nats.Conn, serverInfoMap map[
 
 		for _, streamInfo := range accountDetail.Streams {
 			streamName := streamInfo.Name
+			if !c.streamSelected(streamName) {
+				continue
+			}
 
 			_, streamKnown := streamNamesSet[streamName]
 			if !streamKnown {
@@ -657,7 +1144,7 @@ func (c *auditGatherCmd) captureAccountStreams(nc *nats.Conn, serverInfoMap map[
 				archive.TagStreamInfo(),
 			}
 
-			err = aw.Add(streamInfo, tags...)
+			err = c.addArtifact(aw, streamInfo, tags...)
 			if err != nil {
 				return fmt.Errorf("failed to add stream %s info to archive: %w", streamName, err)
 			}
@@ -682,12 +1169,19 @@ func (c *auditGatherCmd) captureMetadata(nc *nats.Conn, aw *archive.Writer) erro
 		}
 
 		metadata := &auditMetadata{
-			Timestamp:              time.Now(),
-			ConnectedServerName:    nc.ConnectedServerName(),
-			ConnectedServerVersion: nc.ConnectedServerVersion(),
-			ConnectURL:             nc.ConnectedUrl(),
-			UserName:               username,
-			CLIVersion:             Version,
+			Timestamp:      time.Now(),
+			UserName:       username,
+			CLIVersion:     Version,
+			GatherScope:    c.scope(),
+			GatherFailures: c.failures,
+		}
+
+		if c.monitor != nil {
+			metadata.ConnectURL = strings.Join(c.monitor.urls, ",")
+		} else {
+			metadata.ConnectedServerName = nc.ConnectedServerName()
+			metadata.ConnectedServerVersion = nc.ConnectedServerVersion()
+			metadata.ConnectURL = nc.ConnectedUrl()
 		}
 
 		err = aw.Add(&metadata, archive.TagSpecial("audit_gather_metadata"))
@@ -701,6 +1195,9 @@ func (c *auditGatherCmd) captureMetadata(nc *nats.Conn, aw *archive.Writer) erro
 // logProgress prints updates to the gathering process. It can be turned off to make capture less verbose.
 // Updates are also tee'd to the capture log
 func (c *auditGatherCmd) logProgress(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	if c.progress {
 		fmt.Printf(format+"\n", args...)
 	}
@@ -711,6 +1208,9 @@ func (c *auditGatherCmd) logProgress(format string, args ...any) {
 
 // logWarning prints non-fatal errors during the gathering process. Messages are also tee'd to the capture log
 func (c *auditGatherCmd) logWarning(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	fmt.Printf("(!) "+format+"\n", args...)
 	if c.captureLogWriter != nil {
 		_, _ = fmt.Fprintf(c.captureLogWriter, format+"\n", args...)
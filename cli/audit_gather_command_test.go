@@ -0,0 +1,171 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+func TestAuditGatherParallel(t *testing.T) {
+	c := &auditGatherCmd{workers: 3}
+
+	var running, maxRunning, completed atomic.Int32
+	tasks := make([]func() error, 20)
+	for i := range tasks {
+		i := i
+		tasks[i] = func() error {
+			n := running.Add(1)
+			defer running.Add(-1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			completed.Add(1)
+			if i == 5 {
+				return errors.New("task failed")
+			}
+			return nil
+		}
+	}
+
+	err := c.parallel(tasks)
+	if err == nil || err.Error() != "task failed" {
+		t.Fatalf("expected task error, got: %v", err)
+	}
+	if completed.Load() != 20 {
+		t.Fatalf("expected all tasks to complete, got %d", completed.Load())
+	}
+	if maxRunning.Load() > 3 {
+		t.Fatalf("expected at most 3 concurrent tasks, got %d", maxRunning.Load())
+	}
+
+	err = c.parallel(nil)
+	checkErr(t, err, "unexpected error with no tasks: %v", err)
+}
+
+func TestAuditGatherRetry(t *testing.T) {
+	SetContext(context.Background())
+	c := &auditGatherCmd{retries: 2, retryBackoff: time.Millisecond}
+
+	attempts := 0
+	err := c.retry(func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("timeout")
+		}
+		return nil
+	})
+	checkErr(t, err, "expected success on last retry: %v", err)
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+
+	attempts = 0
+	err = c.retry(func() error {
+		attempts++
+		return errors.New("timeout")
+	})
+	if err == nil || err.Error() != "timeout" {
+		t.Fatalf("expected last attempt error, got: %v", err)
+	}
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestAuditGatherFailureString(t *testing.T) {
+	failure := auditGatherFailure{Artifact: "VARZ", Source: "server n1", Error: "nats: timeout"}
+	if failure.String() != "VARZ from server n1: nats: timeout" {
+		t.Fatalf("unexpected failure description: %s", failure)
+	}
+
+	failure.Snapshot = "2024-01-01T00:00:00Z"
+	if failure.String() != "VARZ from server n1 (snapshot 2024-01-01T00:00:00Z): nats: timeout" {
+		t.Fatalf("unexpected failure description: %s", failure)
+	}
+}
+
+func TestAuditGatherFilters(t *testing.T) {
+	serverInfoMap := map[string]*server.ServerInfo{
+		"ID1": {ID: "ID1", Name: "east-1", Cluster: "EAST"},
+		"ID2": {ID: "ID2", Name: "east-2", Cluster: "EAST"},
+		"ID3": {ID: "ID3", Name: "west-1", Cluster: "WEST"},
+		"ID4": {ID: "ID4", Name: "leaf-1"},
+	}
+	accounts := map[string]int{"ACME": 3, "OTHER": 2, "$SYS": 4}
+
+	selectedServers := func(m map[string]*server.ServerInfo) []string {
+		names := make([]string, 0, len(m))
+		for _, serverInfo := range m {
+			names = append(names, serverInfo.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("no filters", func(t *testing.T) {
+		c := &auditGatherCmd{}
+		if diff := cmp.Diff([]string{"east-1", "east-2", "leaf-1", "west-1"}, selectedServers(c.filterServers(serverInfoMap))); diff != "" {
+			t.Fatalf("unexpected servers (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(accounts, c.filterAccounts(accounts)); diff != "" {
+			t.Fatalf("unexpected accounts (-want +got):\n%s", diff)
+		}
+		if !c.streamSelected("ORDERS") || c.serverExcluded("ID4") || len(c.scope()) != 0 {
+			t.Fatalf("expected everything to be selected")
+		}
+	})
+
+	t.Run("cluster and server name", func(t *testing.T) {
+		c := &auditGatherCmd{}
+		c.filter.clusters = []string{"EAST", "WEST"}
+		c.filter.serverName = regexp.MustCompile(`-1$`)
+		if diff := cmp.Diff([]string{"east-1", "west-1"}, selectedServers(c.filterServers(serverInfoMap))); diff != "" {
+			t.Fatalf("unexpected servers (-want +got):\n%s", diff)
+		}
+		if !c.serverExcluded("ID2") || !c.serverExcluded("ID4") || c.serverExcluded("ID1") || c.serverExcluded("UNKNOWN") {
+			t.Fatalf("unexpected excluded servers: %v", c.excludedServers)
+		}
+		if diff := cmp.Diff([]string{"cluster=EAST", "cluster=WEST", "server-name=-1$"}, c.scope()); diff != "" {
+			t.Fatalf("unexpected scope (-want +got):\n%s", diff)
+		}
+	})
+
+	t.Run("account and stream", func(t *testing.T) {
+		c := &auditGatherCmd{}
+		c.filter.accounts = []string{"ACME", "MISSING"}
+		c.filter.streamName = regexp.MustCompile(`^ORDERS`)
+		if diff := cmp.Diff(map[string]int{"ACME": 3}, c.filterAccounts(accounts)); diff != "" {
+			t.Fatalf("unexpected accounts (-want +got):\n%s", diff)
+		}
+		if !c.streamSelected("ORDERS_EU") || c.streamSelected("EVENTS") {
+			t.Fatalf("unexpected stream selection")
+		}
+		if diff := cmp.Diff([]string{"account=ACME", "account=MISSING", "stream=^ORDERS"}, c.scope()); diff != "" {
+			t.Fatalf("unexpected scope (-want +got):\n%s", diff)
+		}
+	})
+}
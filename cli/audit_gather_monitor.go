@@ -0,0 +1,247 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+)
+
+// errAuditMonitorNoData is returned when a response does not include the requested field, e.g. JetStream details of an
+// account without JetStream enabled
+var errAuditMonitorNoData = errors.New("no data in response")
+
+// auditMonitorClient retrieves data from the HTTP monitoring endpoint of each server, for deployments where system
+// account credentials are not available
+type auditMonitorClient struct {
+	client *http.Client
+	urls   []string
+	// servers maps the id of each discovered server to its info and monitoring URL
+	servers map[string]*auditMonitoredServer
+}
+
+type auditMonitoredServer struct {
+	url      string
+	info     *server.ServerInfo
+	accounts map[string]struct{}
+}
+
+func newAuditMonitorClient(urls []string) *auditMonitorClient {
+	return &auditMonitorClient{
+		client:  &http.Client{},
+		urls:    urls,
+		servers: make(map[string]*auditMonitoredServer),
+	}
+}
+
+// setAccounts records the accounts known by a server, to direct account requests only to those servers
+func (m *auditMonitorClient) setAccounts(serverId string, accounts []string) {
+	monitored, found := m.servers[serverId]
+	if !found {
+		return
+	}
+	monitored.accounts = make(map[string]struct{}, len(accounts))
+	for _, accountId := range accounts {
+		monitored.accounts[accountId] = struct{}{}
+	}
+}
+
+// get requests a monitoring endpoint, and returns the response body
+func (m *auditMonitorClient) get(baseURL string, path string, query url.Values, timeout time.Duration) ([]byte, error) {
+	u, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/" + path)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = query.Encode()
+
+	reqCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// The health endpoint reports unhealthy servers with an error status, the body is still the health report
+	if resp.StatusCode != http.StatusOK && path != "healthz" {
+		return nil, fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	return body, nil
+}
+
+// auditMonitorUnwrap returns the given field of a response, or the first element if the field is a list
+func auditMonitorUnwrap(data []byte, field string) ([]byte, error) {
+	if field == "" {
+		return data, nil
+	}
+
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	value, found := fields[field]
+	if !found {
+		return nil, fmt.Errorf("%w: missing %s", errAuditMonitorNoData, field)
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(value)), "[") {
+		var values []json.RawMessage
+		err = json.Unmarshal(value, &values)
+		if err != nil {
+			return nil, err
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("%w: empty %s", errAuditMonitorNoData, field)
+		}
+		value = values[0]
+	}
+
+	return value, nil
+}
+
+// discoverMonitoredServers requests the variables of each monitoring URL to identify the servers
+func (c *auditGatherCmd) discoverMonitoredServers() (map[string]*server.ServerInfo, error) {
+	c.logProgress("Querying %d monitoring endpoints to discover servers...", len(c.monitor.urls))
+	serverInfoMap := make(map[string]*server.ServerInfo)
+	for _, monitorURL := range c.monitor.urls {
+		var data []byte
+		err := c.retry(func() error {
+			var err error
+			data, err = c.monitor.get(monitorURL, "varz", nil, c.requestTimeout)
+			return err
+		})
+		if err != nil {
+			c.logWarning("Failed to query monitoring endpoint %s: %s", monitorURL, err)
+			continue
+		}
+
+		var varz server.Varz
+		err = json.Unmarshal(data, &varz)
+		if err != nil {
+			c.logWarning("Failed to deserialize variables from monitoring endpoint %s: %s", monitorURL, err)
+			continue
+		}
+
+		if _, exists := serverInfoMap[varz.ID]; exists {
+			c.logWarning("Duplicate server %s (%s) at monitoring endpoint %s, ignoring", varz.ID, varz.Name, monitorURL)
+			continue
+		}
+
+		serverInfo := &server.ServerInfo{
+			Name:      varz.Name,
+			Host:      varz.Host,
+			ID:        varz.ID,
+			Cluster:   varz.Cluster.Name,
+			Version:   varz.Version,
+			JetStream: varz.JetStream.Config != nil,
+		}
+		if varz.JetStream.Config != nil {
+			serverInfo.Domain = varz.JetStream.Config.Domain
+		}
+
+		serverInfoMap[varz.ID] = serverInfo
+		c.monitor.servers[varz.ID] = &auditMonitoredServer{url: monitorURL, info: serverInfo}
+		c.logProgress("Discovered server '%s' (%s) at %s", varz.Name, varz.ID, monitorURL)
+	}
+
+	if len(serverInfoMap) == 0 {
+		return nil, fmt.Errorf("no monitoring endpoint responded")
+	}
+	c.logProgress("Discovered %d servers", len(serverInfoMap))
+	return serverInfoMap, nil
+}
+
+// monitorRequest requests a monitoring endpoint of the given server, retrying on failure. The response is wrapped in
+// the same envelope used by the system API, so that it can be handled in the same way.
+func (c *auditGatherCmd) monitorRequest(serverId string, path string, field string, query url.Values) ([]byte, error) {
+	monitored, found := c.monitor.servers[serverId]
+	if !found {
+		return nil, fmt.Errorf("unknown server %s", serverId)
+	}
+
+	var data []byte
+	err := c.retry(func() error {
+		var err error
+		data, err = c.monitor.get(monitored.url, path, query, c.requestTimeout)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = auditMonitorUnwrap(data, field)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(serverAPIResponseNoData{Server: monitored.info, Data: data})
+}
+
+// monitorAccountRequest requests a monitoring endpoint filtered by account from each of the given servers that know
+// the account, the equivalent of a system API request to all servers. Servers without data for the account are skipped,
+// failures are recorded for each server.
+func (c *auditGatherCmd) monitorAccountRequest(serverInfoMap map[string]*server.ServerInfo, accountId string, artifact string, path string, field string, query url.Values) [][]byte {
+	serverIds := make([]string, 0, len(serverInfoMap))
+	for serverId := range serverInfoMap {
+		serverIds = append(serverIds, serverId)
+	}
+	sort.Strings(serverIds)
+
+	responses := make([][]byte, 0)
+	for _, serverId := range serverIds {
+		monitored, found := c.monitor.servers[serverId]
+		if !found {
+			continue
+		}
+		if _, knowsAccount := monitored.accounts[accountId]; !knowsAccount {
+			continue
+		}
+
+		response, err := c.monitorRequest(serverId, path, field, query)
+		if errors.Is(err, errAuditMonitorNoData) {
+			// The account has no data of this kind on this server
+			continue
+		} else if err != nil {
+			c.recordFailure(artifact, fmt.Sprintf("account %s on server %s", accountId, monitored.info.Name), err)
+			continue
+		}
+		responses = append(responses, response)
+	}
+
+	return responses
+}
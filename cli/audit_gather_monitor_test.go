@@ -0,0 +1,150 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/natscli/archive"
+)
+
+func TestAuditMonitorUnwrap(t *testing.T) {
+	data := []byte(`{"server_id":"ID1","account_detail":{"account_name":"ACME"},"account_details":[{"name":"ACME"},{"name":"OTHER"}]}`)
+
+	for field, expected := range map[string]string{
+		"":                string(data),
+		"account_detail":  `{"account_name":"ACME"}`,
+		"account_details": `{"name":"ACME"}`,
+	} {
+		value, err := auditMonitorUnwrap(data, field)
+		checkErr(t, err, "could not unwrap %q: %v", field, err)
+		if string(value) != expected {
+			t.Fatalf("expected %q to unwrap to %s, got %s", field, expected, value)
+		}
+	}
+
+	_, err := auditMonitorUnwrap(data, "missing")
+	if !errors.Is(err, errAuditMonitorNoData) {
+		t.Fatalf("expected no data error unwrapping missing field, got: %v", err)
+	}
+	_, err = auditMonitorUnwrap([]byte(`{"account_details":[]}`), "account_details")
+	if !errors.Is(err, errAuditMonitorNoData) {
+		t.Fatalf("expected no data error unwrapping empty list, got: %v", err)
+	}
+}
+
+func TestAuditGatherMonitor(t *testing.T) {
+	SetContext(context.Background())
+
+	srv, err := server.NewServer(&server.Options{
+		ServerName: "n1",
+		Port:       -1,
+		HTTPPort:   -1,
+		StoreDir:   t.TempDir(),
+		JetStream:  true,
+	})
+	checkErr(t, err, "could not start server: %v", err)
+	go srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(10 * time.Second) {
+		t.Fatalf("nats server did not start")
+	}
+
+	nc, err := nats.Connect(srv.ClientURL())
+	checkErr(t, err, "could not connect: %v", err)
+	defer nc.Close()
+	js, err := nc.JetStream()
+	checkErr(t, err, "could not create JetStream context: %v", err)
+	_, err = js.AddStream(&nats.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+	checkErr(t, err, "could not create stream: %v", err)
+	_, err = js.AddStream(&nats.StreamConfig{Name: "EVENTS", Subjects: []string{"events.>"}})
+	checkErr(t, err, "could not create stream: %v", err)
+
+	// The request timeout defaults to the global timeout
+	timeout := opts.Timeout
+	opts.Timeout = 5 * time.Second
+	defer func() { opts.Timeout = timeout }()
+
+	c := newAuditGatherCmd()
+	c.archiveFilePath = filepath.Join(t.TempDir(), "archive.zip")
+	c.count = 1
+	c.workers = 2
+	c.retryBackoff = time.Millisecond
+	c.monitorURLs = []string{fmt.Sprintf("http://%s", srv.MonitorAddr())}
+	c.include.serverEndpoints = true
+	c.include.serverProfiles = true
+	c.include.accountEndpoints = true
+	c.include.streams = true
+	c.include.consumers = true
+
+	err = c.gather(nil)
+	checkErr(t, err, "gather failed: %v", err)
+	if len(c.failures) != 0 {
+		t.Fatalf("unexpected failures: %v", c.failures)
+	}
+	if c.requestTimeout != opts.Timeout {
+		t.Fatalf("expected request timeout %v, got %v", opts.Timeout, c.requestTimeout)
+	}
+
+	ar, err := archive.NewReader(c.archiveFilePath)
+	checkErr(t, err, "could not open archive: %v", err)
+	defer ar.Close()
+
+	var varz server.Varz
+	err = ar.Load(&varz, archive.TagServer("n1"), archive.TagServerVars())
+	checkErr(t, err, "could not load server variables: %v", err)
+	if varz.Name != "n1" {
+		t.Fatalf("unexpected server variables: %s", varz.Name)
+	}
+
+	var health server.HealthStatus
+	err = ar.Load(&health, archive.TagServer("n1"), archive.TagServerHealth())
+	checkErr(t, err, "could not load server health: %v", err)
+
+	var accountInfo server.AccountInfo
+	err = ar.Load(&accountInfo, archive.TagAccount("$G"), archive.TagServer("n1"), archive.TagAccountInfo())
+	checkErr(t, err, "could not load account info: %v", err)
+	if accountInfo.AccountName != "$G" {
+		t.Fatalf("unexpected account info: %s", accountInfo.AccountName)
+	}
+
+	var jsStats server.JetStreamStats
+	err = ar.Load(&jsStats, archive.TagAccount("$G"), archive.TagServer("n1"), archive.TagAccountJetStream())
+	checkErr(t, err, "could not load account JetStream stats: %v", err)
+	if jsStats.Store != 0 || jsStats.API.Total == 0 {
+		t.Fatalf("unexpected account JetStream stats: %+v", jsStats)
+	}
+
+	streamNames := ar.GetAccountStreamNames("$G")
+	sort.Strings(streamNames)
+	if diff := cmp.Diff([]string{"EVENTS", "ORDERS"}, streamNames); diff != "" {
+		t.Fatalf("unexpected streams (-want +got):\n%s", diff)
+	}
+
+	var metadata auditMetadata
+	err = ar.Load(&metadata, archive.TagSpecial("audit_gather_metadata"))
+	checkErr(t, err, "could not load metadata: %v", err)
+	if metadata.ConnectURL != c.monitorURLs[0] {
+		t.Fatalf("unexpected metadata connect URL: %s", metadata.ConnectURL)
+	}
+}
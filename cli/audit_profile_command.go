@@ -0,0 +1,326 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/choria-io/fisk"
+	"github.com/google/pprof/profile"
+	"github.com/nats-io/natscli/archive"
+)
+
+type auditProfileCmd struct {
+	archivePath string
+	serverName  string
+	top         int
+	keys        auditArchiveKeys
+}
+
+// auditProfileSummary is the summary of the profiles captured for a server
+type auditProfileSummary struct {
+	clusterName string
+	serverName  string
+	// goroutines is the number of goroutines, goroutineFrames counts them by top frame
+	goroutines      int64
+	goroutineFrames []auditProfileEntry
+	// heapInUse is the number of bytes in use, heapFunctions the bytes allocated by each function
+	heapInUse     int64
+	heapFunctions []auditProfileEntry
+	// allocated is the number of bytes allocated since the server started, allocsFunctions by each function
+	allocated       int64
+	allocsFunctions []auditProfileEntry
+	// profiles lists the names of profiles found for the server
+	profiles []string
+}
+
+// auditProfileEntry is the value of a profile sample type, aggregated by function
+type auditProfileEntry struct {
+	function string
+	value    int64
+}
+
+const (
+	// Outliers are servers reporting more than this multiple of the cluster median
+	auditProfileOutlierFactor = 2.0
+	// Minimum difference from the cluster median to be considered an outlier, to ignore small, idle deployments
+	auditProfileGoroutinesOutlierMin = 1_000
+	auditProfileHeapOutlierMin       = 64 * 1024 * 1024
+)
+
+func configureAuditProfileCommand(srv *fisk.CmdClause) {
+	c := &auditProfileCmd{}
+
+	profile := srv.Command("profile", "summarize the server profiles captured by the 'gather' subcommand").Action(c.profile)
+	profile.Arg("archive", "path to input archive").Required().ExistingFileVar(&c.archivePath)
+	profile.Flag("server-name", "Only summarize profiles of this server").PlaceHolder("NAME").StringVar(&c.serverName)
+	profile.Flag("top", "Number of functions to show for each profile").Default("10").IntVar(&c.top)
+	c.keys.configureFlags(profile)
+}
+
+func (c *auditProfileCmd) profile(_ *fisk.ParseContext) error {
+	ar, err := c.keys.openArchive(c.archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := ar.Close()
+		if err != nil {
+			fmt.Printf("Failed to close archive reader: %s\n", err)
+		}
+	}()
+
+	if ar.Signer() != "" {
+		fmt.Printf("Archive signature verified, signed by %s\n", ar.Signer())
+	}
+
+	summaries, err := loadAuditProfileSummaries(ar)
+	if err != nil {
+		return err
+	}
+	if len(summaries) == 0 {
+		fmt.Println("No server profiles found in archive")
+		return nil
+	}
+
+	found := false
+	for _, summary := range summaries {
+		if c.serverName != "" && summary.serverName != c.serverName {
+			continue
+		}
+		found = true
+
+		fmt.Printf("\nServer %s (cluster %s), profiles: %s\n", summary.serverName, summary.clusterName, strings.Join(summary.profiles, ", "))
+		c.renderEntries(summary.goroutineFrames, summary.goroutines, "Goroutines by top frame (%s goroutines)", auditProfileCount)
+		c.renderEntries(summary.heapFunctions, summary.heapInUse, "Heap in use by function (%s)", auditProfileBytes)
+		c.renderEntries(summary.allocsFunctions, summary.allocated, "Allocations by function (%s allocated)", auditProfileBytes)
+	}
+	if !found {
+		return fmt.Errorf("no profiles found for server %s", c.serverName)
+	}
+
+	outliers := auditProfileOutliers(summaries)
+	if len(outliers) > 0 {
+		fmt.Printf("\nOutliers:\n")
+		for _, outlier := range outliers {
+			fmt.Println("(!) " + outlier.Message)
+		}
+	}
+
+	return nil
+}
+
+// renderEntries prints a table of the top entries of a profile, if any
+func (c *auditProfileCmd) renderEntries(entries []auditProfileEntry, total int64, title string, format func(v int64) string) {
+	if len(entries) == 0 {
+		return
+	}
+
+	table := newTableWriter(title, format(total))
+	table.AddHeaders("Function", "Value", "%")
+	for i, entry := range entries {
+		if c.top > 0 && i >= c.top {
+			break
+		}
+		percent := 0.0
+		if total > 0 {
+			percent = float64(entry.value) * 100 / float64(total)
+		}
+		table.AddRow(entry.function, format(entry.value), fmt.Sprintf("%.1f", percent))
+	}
+	fmt.Println(table.Render())
+}
+
+func auditProfileCount(v int64) string {
+	return f(v)
+}
+
+func auditProfileBytes(v int64) string {
+	return fiBytes(uint64(max(v, 0)))
+}
+
+// loadAuditProfileSummaries parses the goroutine, heap and allocs profiles of each server in the archive, servers
+// without profiles are omitted. In archives with multiple snapshots, profiles of the most recent one are used.
+func loadAuditProfileSummaries(ar *archive.Reader) ([]*auditProfileSummary, error) {
+	summaries := make([]*auditProfileSummary, 0)
+
+	clusterNames := ar.GetClusterNames()
+	sort.Strings(clusterNames)
+	for _, clusterName := range clusterNames {
+		serverNames := ar.GetClusterServerNames(clusterName)
+		sort.Strings(serverNames)
+		for _, serverName := range serverNames {
+			summary := &auditProfileSummary{clusterName: clusterName, serverName: serverName}
+
+			profiles := []struct {
+				name       string
+				sampleType string
+				total      *int64
+				entries    *[]auditProfileEntry
+			}{
+				{"goroutine", "goroutine", &summary.goroutines, &summary.goroutineFrames},
+				{"heap", "inuse_space", &summary.heapInUse, &summary.heapFunctions},
+				{"allocs", "alloc_space", &summary.allocated, &summary.allocsFunctions},
+			}
+
+			for _, p := range profiles {
+				data, err := ar.LoadRaw(archive.TagCluster(clusterName), archive.TagServer(serverName), archive.TagServerProfile(), archive.TagProfileName(p.name))
+				if errors.Is(err, archive.ErrNoMatches) {
+					continue
+				} else if err != nil {
+					return nil, fmt.Errorf("failed to load %s profile of server %s: %w", p.name, serverName, err)
+				}
+
+				*p.total, *p.entries, err = summarizeAuditProfile(data, p.sampleType)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse %s profile of server %s: %w", p.name, serverName, err)
+				}
+				summary.profiles = append(summary.profiles, p.name)
+			}
+
+			if len(summary.profiles) > 0 {
+				summaries = append(summaries, summary)
+			}
+		}
+	}
+
+	return summaries, nil
+}
+
+// summarizeAuditProfile parses a pprof profile and aggregates the values of the given sample type by top frame.
+// Returns the total and the aggregated values, sorted from the largest.
+func summarizeAuditProfile(data []byte, sampleType string) (int64, []auditProfileEntry, error) {
+	p, err := profile.Parse(bytes.NewReader(data))
+	if err != nil {
+		return 0, nil, err
+	}
+
+	valueIndex := -1
+	for i, st := range p.SampleType {
+		if st.Type == sampleType {
+			valueIndex = i
+			break
+		}
+	}
+	if valueIndex < 0 {
+		return 0, nil, fmt.Errorf("sample type %s not found", sampleType)
+	}
+
+	total := int64(0)
+	byFunction := make(map[string]int64)
+	for _, sample := range p.Sample {
+		value := sample.Value[valueIndex]
+		total += value
+		byFunction[auditProfileTopFrame(sample)] += value
+	}
+
+	entries := make([]auditProfileEntry, 0, len(byFunction))
+	for function, value := range byFunction {
+		if value == 0 {
+			continue
+		}
+		entries = append(entries, auditProfileEntry{function: function, value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].value == entries[j].value {
+			return entries[i].function < entries[j].function
+		}
+		return entries[i].value > entries[j].value
+	})
+
+	return total, entries, nil
+}
+
+// auditProfileTopFrame returns the innermost function of a sample that is not part of the Go runtime (e.g. the
+// function that is blocked, rather than runtime.gopark), or the innermost function if all frames are in the runtime
+func auditProfileTopFrame(sample *profile.Sample) string {
+	top := ""
+	for _, location := range sample.Location {
+		for _, line := range location.Line {
+			if line.Function == nil {
+				continue
+			}
+			if top == "" {
+				top = line.Function.Name
+			}
+			if !strings.HasPrefix(line.Function.Name, "runtime.") {
+				return line.Function.Name
+			}
+		}
+	}
+	if top == "" {
+		return "unknown"
+	}
+	return top
+}
+
+// auditProfileOutliers compares goroutines and heap usage of servers in the same cluster, and returns an issue for each
+// server much above the cluster median
+func auditProfileOutliers(summaries []*auditProfileSummary) []auditIssue {
+	clusters := make(map[string][]*auditProfileSummary)
+	for _, summary := range summaries {
+		clusters[summary.clusterName] = append(clusters[summary.clusterName], summary)
+	}
+
+	metrics := []struct {
+		name    string
+		value   func(s *auditProfileSummary) int64
+		min     int64
+		format  func(v int64) string
+		profile string
+	}{
+		{"goroutines", func(s *auditProfileSummary) int64 { return s.goroutines }, auditProfileGoroutinesOutlierMin, auditProfileCount, "goroutine"},
+		{"heap in use", func(s *auditProfileSummary) int64 { return s.heapInUse }, auditProfileHeapOutlierMin, auditProfileBytes, "heap"},
+	}
+
+	issues := make([]auditIssue, 0)
+	for _, clusterName := range sortedMapKeys(clusters) {
+		for _, metric := range metrics {
+			values := make([]int64, 0)
+			for _, summary := range clusters[clusterName] {
+				if slices.Contains(summary.profiles, metric.profile) {
+					values = append(values, metric.value(summary))
+				}
+			}
+			if len(values) < 2 {
+				continue
+			}
+			sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+			median := values[len(values)/2]
+			if len(values)%2 == 0 {
+				median = (values[len(values)/2-1] + values[len(values)/2]) / 2
+			}
+
+			for _, summary := range clusters[clusterName] {
+				if !slices.Contains(summary.profiles, metric.profile) {
+					continue
+				}
+				value := metric.value(summary)
+				if float64(value) > float64(median)*auditProfileOutlierFactor && value-median > metric.min {
+					issues = append(issues, auditIssue{
+						Key:     clusterName + "/" + summary.serverName + "/" + metric.profile,
+						Message: fmt.Sprintf("%s/%s %s: %s, cluster median: %s", clusterName, summary.serverName, metric.name, metric.format(value), metric.format(median)),
+					})
+				}
+			}
+		}
+	}
+
+	return issues
+}
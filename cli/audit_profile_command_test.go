@@ -0,0 +1,115 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/pprof/profile"
+	"github.com/nats-io/natscli/archive"
+)
+
+// testAuditProfile creates a profile with a single sample type, and one sample for each stack (innermost function
+// first) with the given value
+func testAuditProfile(t *testing.T, sampleTypes []string, stacks map[string][]string, values map[string]int64) []byte {
+	t.Helper()
+
+	p := &profile.Profile{}
+	for _, sampleType := range sampleTypes {
+		p.SampleType = append(p.SampleType, &profile.ValueType{Type: sampleType, Unit: "count"})
+	}
+
+	functions := make(map[string]*profile.Function)
+	for stackName, stack := range stacks {
+		sample := &profile.Sample{}
+		for _, functionName := range stack {
+			function, found := functions[functionName]
+			if !found {
+				function = &profile.Function{ID: uint64(len(functions) + 1), Name: functionName}
+				functions[functionName] = function
+				p.Function = append(p.Function, function)
+			}
+			location := &profile.Location{ID: uint64(len(p.Location) + 1), Line: []profile.Line{{Function: function}}}
+			p.Location = append(p.Location, location)
+			sample.Location = append(sample.Location, location)
+		}
+		for range sampleTypes {
+			sample.Value = append(sample.Value, values[stackName])
+		}
+		p.Sample = append(p.Sample, sample)
+	}
+
+	var buf bytes.Buffer
+	err := p.Write(&buf)
+	checkErr(t, err, "could not write profile: %v", err)
+	return buf.Bytes()
+}
+
+func TestAuditProfileSummaries(t *testing.T) {
+	stacks := map[string][]string{
+		"read":    {"runtime.gopark", "runtime.netpollblock", "github.com/nats-io/nats-server/v2/server.(*client).readLoop"},
+		"flush":   {"runtime.gopark", "github.com/nats-io/nats-server/v2/server.(*client).flushSignal"},
+		"runtime": {"runtime.gopark", "runtime.bgsweep"},
+	}
+
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+
+	for serverName, readers := range map[string]int64{"n1": 100, "n2": 120, "n3": 5_000} {
+		goroutines := testAuditProfile(t, []string{"goroutine"}, stacks, map[string]int64{"read": readers, "flush": 10, "runtime": 1})
+		err = aw.AddRaw(bytes.NewReader(goroutines), "prof", archive.TagCluster("C1"), archive.TagServer(serverName), archive.TagServerProfile(), archive.TagProfileName("goroutine"))
+		checkErr(t, err, "could not add profile: %v", err)
+
+		heap := testAuditProfile(t, []string{"alloc_space", "inuse_space"}, stacks, map[string]int64{"read": 1024, "flush": 2048})
+		err = aw.AddRaw(bytes.NewReader(heap), "prof", archive.TagCluster("C1"), archive.TagServer(serverName), archive.TagServerProfile(), archive.TagProfileName("heap"))
+		checkErr(t, err, "could not add profile: %v", err)
+	}
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	ar, err := archive.NewReader(archivePath)
+	checkErr(t, err, "could not open archive: %v", err)
+	defer ar.Close()
+
+	summaries, err := loadAuditProfileSummaries(ar)
+	checkErr(t, err, "could not load profiles: %v", err)
+	if len(summaries) != 3 {
+		t.Fatalf("expected 3 summaries, got %d", len(summaries))
+	}
+
+	n1 := summaries[0]
+	if n1.serverName != "n1" || n1.goroutines != 111 || n1.heapInUse != 3072 {
+		t.Fatalf("unexpected summary: %+v", n1)
+	}
+	expectedFrames := []auditProfileEntry{
+		{"github.com/nats-io/nats-server/v2/server.(*client).readLoop", 100},
+		{"github.com/nats-io/nats-server/v2/server.(*client).flushSignal", 10},
+		{"runtime.gopark", 1},
+	}
+	if diff := cmp.Diff(expectedFrames, n1.goroutineFrames, cmp.AllowUnexported(auditProfileEntry{})); diff != "" {
+		t.Fatalf("unexpected goroutine frames (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"goroutine", "heap"}, n1.profiles); diff != "" {
+		t.Fatalf("unexpected profiles (-want +got):\n%s", diff)
+	}
+
+	outliers := auditProfileOutliers(summaries)
+	if diff := cmp.Diff([]auditIssue{{"C1/n3/goroutine", "C1/n3 goroutines: 5,011, cluster median: 131"}}, outliers); diff != "" {
+		t.Fatalf("unexpected outliers (-want +got):\n%s", diff)
+	}
+}
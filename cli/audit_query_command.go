@@ -0,0 +1,138 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/natscli/archive"
+)
+
+type auditQueryCmd struct {
+	archivePath string
+	query       string
+	dump        bool
+	keys        auditArchiveKeys
+}
+
+func configureAuditQueryCommand(srv *fisk.CmdClause) {
+	c := &auditQueryCmd{}
+
+	queryHelp := `Queries are composed of tag terms in the form label:value,
+combined with and, or, not and parentheses. Adjacent terms are
+combined with and. Values may contain * wildcards.
+
+Available labels are:
+
+   server, cluster, account, stream, artifact_type (or type),
+   profile_name (or profile), special
+
+List all stream artifacts of account A, except on servers n1-*:
+
+   nats audit query archive.zip 'type:stream_info account:A not server:n1-*'
+
+Print the variables of all servers in clusters east or west:
+
+   nats audit query archive.zip --dump 'type:variables (cluster:east or cluster:west)'
+`
+
+	query := srv.Command("query", "list or print the artifacts of an archive that match a tags query").Action(c.queryAction)
+	query.HelpLong(queryHelp)
+	query.Arg("archive", "path to input archive to query").Required().ExistingFileVar(&c.archivePath)
+	query.Arg("query", "tags query expression").Required().StringVar(&c.query)
+	query.Flag("dump", "Print the content of each matching artifact").UnNegatableBoolVar(&c.dump)
+	c.keys.configureFlags(query)
+}
+
+func (c *auditQueryCmd) queryAction(_ *fisk.ParseContext) error {
+	query, err := archive.ParseQuery(c.query)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+
+	ar, err := c.keys.openArchive(c.archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := ar.Close()
+		if err != nil {
+			fmt.Printf("Failed to close archive reader: %s\n", err)
+		}
+	}()
+
+	artifactNames := ar.Query(query)
+	if len(artifactNames) == 0 {
+		fmt.Println("No artifacts matched the query")
+		return nil
+	}
+
+	if c.dump {
+		return c.dumpArtifacts(ar, artifactNames)
+	}
+
+	table := newTableWriter("%d artifacts matching: %s", len(artifactNames), query)
+	table.AddHeaders("Artifact", "Tags")
+	for _, name := range artifactNames {
+		tags, err := ar.GetArtifactTags(name)
+		if err != nil {
+			return fmt.Errorf("failed to get artifact %s tags: %w", name, err)
+		}
+		table.AddRow(name, auditFormatTags(tags))
+	}
+	fmt.Println(table.Render())
+
+	return nil
+}
+
+func (c *auditQueryCmd) dumpArtifacts(ar *archive.Reader, artifactNames []string) error {
+	for _, name := range artifactNames {
+		tags, err := ar.GetArtifactTags(name)
+		if err != nil {
+			return fmt.Errorf("failed to get artifact %s tags: %w", name, err)
+		}
+
+		fmt.Printf("### %s [%s]\n", name, auditFormatTags(tags))
+
+		content, err := ar.ReadArtifact(name)
+		if err != nil {
+			return fmt.Errorf("failed to read artifact %s: %w", name, err)
+		}
+
+		switch {
+		case path.Ext(name) == ".json" && json.Valid(content):
+			fmt.Println(strings.TrimSpace(string(content)))
+		case isPrintable(strings.ReplaceAll(strings.ReplaceAll(string(content), "\n", ""), "\t", "")):
+			fmt.Println(strings.TrimSpace(string(content)))
+		default:
+			fmt.Printf("(binary content, %s)\n", fiBytes(uint64(len(content))))
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// auditFormatTags renders a list of tags as a compact, comma-separated string of label:value pairs
+func auditFormatTags(tags []archive.Tag) string {
+	formatted := make([]string, len(tags))
+	for i, tag := range tags {
+		formatted[i] = fmt.Sprintf("%s:%s", tag.Name, tag.Value)
+	}
+	return strings.Join(formatted, ", ")
+}
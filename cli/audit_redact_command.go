@@ -0,0 +1,436 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/natscli/archive"
+)
+
+type auditRedactCmd struct {
+	inputPath            string
+	outputPath           string
+	profileName          string
+	salt                 string
+	subjectTokenPatterns []string
+	keys                 auditArchiveKeys
+	protection           auditArchiveProtection
+}
+
+// auditRedactionAction is the transformation applied to the value of a sensitive field
+type auditRedactionAction int
+
+const (
+	auditRedactKeep auditRedactionAction = iota
+	// Replace each string with a salted hash, equal values are replaced with equal hashes
+	auditRedactHash
+	// Remove the field
+	auditRedactDrop
+	// Redact the tokens of subjects matching the configured patterns
+	auditRedactSubjectTokens
+	// Redact the tokens of the keys of an object keyed by subject
+	auditRedactSubjectKeys
+)
+
+// auditRedactionProfile defines what happens to the sensitive fields of JSON artifacts.
+// Fields are identified by their key, or by their key qualified with the key of the containing object or array
+// (e.g. `connections.name`), which takes precedence.
+type auditRedactionProfile struct {
+	fields map[string]auditRedactionAction
+}
+
+// Fields that identify hosts, users and clients
+var auditRedactIdentityFields = []string{
+	// Network addresses of clients and servers
+	"ip", "host", "hostname", "http_host", "url", "urls", "connect_url", "connect_urls", "ws_connect_urls",
+	// Users and JWT-derived names
+	"authorized_user", "user", "username", "user_name", "name_tag", "issuer_key", "mqtt_client",
+	// Client connection name and tags
+	"connections.name", "connections.tags",
+}
+
+// Free text that may contain addresses and host names (e.g. the errors of requests that failed during gather),
+// hashed or dropped like identity fields
+var auditRedactFreeTextFields = []string{
+	"gather_failures.error",
+}
+
+// Fields that may contain credentials or secrets, always dropped
+var auditRedactSecretFields = []string{
+	"jwt", "trusted_operators_jwt", "trusted_operators_claim", "tls_peer_certs",
+	"password", "pass", "token", "auth_token", "seed", "private_key",
+}
+
+// Names and issuers of decoded account JWTs, always hashed.
+// The rest of the claim is kept, it holds the account limits.
+var auditRedactClaimFields = []string{
+	"decoded_jwt.name", "decoded_jwt.iss",
+}
+
+// Fields that contain subjects, or objects with subject fields (e.g. the subscription details of SUBSZ)
+var auditRedactSubjectFields = []string{
+	"subject", "subjects", "subscriptions_list", "filter_subject", "filter_subjects", "deliver_subject",
+}
+
+// Objects keyed by subject (e.g. the per-subject message count of a stream)
+var auditRedactSubjectKeyedFields = []string{
+	"state.subjects",
+}
+
+func newAuditRedactionProfile(identityAction auditRedactionAction) *auditRedactionProfile {
+	p := &auditRedactionProfile{
+		fields: make(map[string]auditRedactionAction),
+	}
+	for _, field := range auditRedactIdentityFields {
+		p.fields[field] = identityAction
+	}
+	for _, field := range auditRedactFreeTextFields {
+		p.fields[field] = identityAction
+	}
+	for _, field := range auditRedactSecretFields {
+		p.fields[field] = auditRedactDrop
+	}
+	for _, field := range auditRedactClaimFields {
+		p.fields[field] = auditRedactHash
+	}
+	for _, field := range auditRedactSubjectFields {
+		p.fields[field] = auditRedactSubjectTokens
+	}
+	for _, field := range auditRedactSubjectKeyedFields {
+		p.fields[field] = auditRedactSubjectKeys
+	}
+	return p
+}
+
+var auditRedactionProfiles = map[string]*auditRedactionProfile{
+	// Replace addresses, users and names with salted hashes, drop secrets
+	"hash": newAuditRedactionProfile(auditRedactHash),
+	// Drop addresses, users, names and secrets
+	"drop": newAuditRedactionProfile(auditRedactDrop),
+}
+
+// action returns the action for the given field, contained in the object or array with the given key
+func (p *auditRedactionProfile) action(parentKey, key string) auditRedactionAction {
+	if action, found := p.fields[parentKey+"."+key]; found {
+		return action
+	}
+	return p.fields[key]
+}
+
+// auditRedactor rewrites JSON artifacts according to a redaction profile
+type auditRedactor struct {
+	profile              *auditRedactionProfile
+	salt                 string
+	subjectTokenPatterns []*regexp.Regexp
+	redactedCount        int
+	droppedCount         int
+}
+
+func configureAuditRedactCommand(srv *fisk.CmdClause) {
+	c := &auditRedactCmd{}
+
+	redactHelp := `Creates a copy of an archive with sensitive information removed.
+
+Client and server addresses, user names, client names, JWT-derived names
+and tags are hashed (or dropped, with the 'drop' profile). JWTs,
+certificates and other secrets are always dropped. Names and issuers of
+decoded account JWTs are hashed, their limits are kept. Tokens of subjects
+that fully match one of the given --subject-token regular expressions
+are also redacted. The gather log is not included in the redacted archive,
+and errors of failed requests are hashed (or dropped), since free text
+cannot be redacted reliably.
+
+Server, cluster, account and stream names are used to organize and tag
+artifacts, and are not redacted, so that the redacted archive can be
+analyzed like the original.
+
+Hashes are salted, use the same --salt when redacting multiple archives
+to be able to correlate (or diff) them.
+
+Redact client addresses and customer ids in subjects (e.g. orders.C-1234.new):
+
+   nats audit redact archive.zip redacted.zip --subject-token 'C-[0-9]+'
+`
+
+	redact := srv.Command("redact", "create a copy of an archive with sensitive information hashed or removed").Action(c.redact)
+	redact.HelpLong(redactHelp)
+	redact.Arg("input", "path to the archive to redact").Required().ExistingFileVar(&c.inputPath)
+	redact.Arg("output", "path of the redacted archive to create").Required().StringVar(&c.outputPath)
+	redact.Flag("profile", "Redaction profile (hash, drop)").Default("hash").EnumVar(&c.profileName, "hash", "drop")
+	redact.Flag("subject-token", "Regular expression matching subject tokens to redact (can be repeated)").PlaceHolder("REGEX").StringsVar(&c.subjectTokenPatterns)
+	redact.Flag("salt", "Salt used to hash values (default: random)").StringVar(&c.salt)
+	c.keys.configureFlags(redact)
+	c.protection.configureFlags(redact)
+}
+
+func (c *auditRedactCmd) redact(_ *fisk.ParseContext) error {
+	redactor, err := c.newRedactor()
+	if err != nil {
+		return err
+	}
+
+	ar, err := c.keys.openArchive(c.inputPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := ar.Close()
+		if err != nil {
+			fmt.Printf("Failed to close archive reader: %s\n", err)
+		}
+	}()
+
+	aw, err := c.protection.newWriter(c.outputPath)
+	if err != nil {
+		return err
+	}
+
+	artifactNames := ar.ListArtifacts()
+	for _, name := range artifactNames {
+		err = c.copyArtifact(ar, aw, redactor, name)
+		if err != nil {
+			aw.Close()
+			return fmt.Errorf("failed to redact %s: %w", name, err)
+		}
+	}
+
+	err = aw.Close()
+	if err != nil {
+		return fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	fmt.Printf("Redacted %d values in %d artifacts using profile '%s'\n", redactor.redactedCount, len(artifactNames)-redactor.droppedCount, c.profileName)
+	if redactor.droppedCount > 0 {
+		fmt.Printf("Dropped %d artifacts that cannot be redacted\n", redactor.droppedCount)
+	}
+	fmt.Printf("Redacted archive created at: %s\n", c.outputPath)
+
+	return nil
+}
+
+func (c *auditRedactCmd) newRedactor() (*auditRedactor, error) {
+	profile, found := auditRedactionProfiles[c.profileName]
+	if !found {
+		return nil, fmt.Errorf("unknown redaction profile: %s", c.profileName)
+	}
+
+	redactor := &auditRedactor{
+		profile: profile,
+		salt:    c.salt,
+	}
+
+	if redactor.salt == "" {
+		saltBytes := make([]byte, 16)
+		_, err := rand.Read(saltBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate salt: %w", err)
+		}
+		redactor.salt = hex.EncodeToString(saltBytes)
+	}
+
+	for _, pattern := range c.subjectTokenPatterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("invalid subject token pattern '%s': %w", pattern, err)
+		}
+		redactor.subjectTokenPatterns = append(redactor.subjectTokenPatterns, re)
+	}
+
+	return redactor, nil
+}
+
+// copyArtifact copies an artifact into the new archive with the same tags, redacting its content if it is JSON.
+// The gather log is free text that cannot be redacted reliably, it is dropped. Server profiles hold no addresses
+// or names and are copied as they are. Any other kind of artifact is rejected, rather than copied unredacted.
+func (c *auditRedactCmd) copyArtifact(ar *archive.Reader, aw *archive.Writer, redactor *auditRedactor, name string) error {
+	tags, err := ar.GetArtifactTags(name)
+	if err != nil {
+		return err
+	}
+
+	for _, tag := range tags {
+		if tag == *auditGatherLogTag {
+			redactor.droppedCount += 1
+			return nil
+		}
+	}
+
+	content, err := ar.ReadArtifact(name)
+	if err != nil {
+		return err
+	}
+
+	extension := strings.TrimPrefix(path.Ext(name), ".")
+	switch extension {
+	case "json":
+		content, err = redactor.redactJSON(content)
+		if err != nil {
+			return err
+		}
+	case auditServerProfilesFileExtension:
+	default:
+		return fmt.Errorf("unsupported artifact type '%s'", extension)
+	}
+
+	tagPointers := make([]*archive.Tag, len(tags))
+	for i := range tags {
+		tagPointers[i] = &tags[i]
+	}
+
+	return aw.AddRaw(bytes.NewReader(content), extension, tagPointers...)
+}
+
+// redactJSON decodes the given JSON document, redacts it and encodes it again
+func (r *auditRedactor) redactJSON(content []byte) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	// Preserve precision of large numbers
+	decoder.UseNumber()
+
+	var document any
+	err := decoder.Decode(&document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+
+	document = r.redactValue("", document)
+
+	// Same formatting as archive.Writer
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// redactValue walks the given value and redacts the sensitive fields of each object found in it
+func (r *auditRedactor) redactValue(parentKey string, v any) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, fieldValue := range value {
+			switch r.profile.action(parentKey, key) {
+			case auditRedactDrop:
+				delete(value, key)
+				r.redactedCount += 1
+			case auditRedactHash:
+				value[key] = r.hashStrings(fieldValue)
+			case auditRedactSubjectTokens:
+				value[key] = r.redactSubjects(key, fieldValue)
+			case auditRedactSubjectKeys:
+				value[key] = r.redactSubjectKeys(key, fieldValue)
+			default:
+				value[key] = r.redactValue(key, fieldValue)
+			}
+		}
+		return value
+	case []any:
+		for i := range value {
+			value[i] = r.redactValue(parentKey, value[i])
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// hashStrings replaces all non-empty strings found in the given value with their salted hash
+func (r *auditRedactor) hashStrings(v any) any {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return value
+		}
+		r.redactedCount += 1
+		return r.hash(value)
+	case []any:
+		for i := range value {
+			value[i] = r.hashStrings(value[i])
+		}
+		return value
+	case map[string]any:
+		for key := range value {
+			value[key] = r.hashStrings(value[key])
+		}
+		return value
+	default:
+		return v
+	}
+}
+
+// redactSubjects redacts matching tokens of all subjects found in the given value.
+// Objects (e.g. subscription details) are redacted according to the profile, which redacts their subject fields.
+func (r *auditRedactor) redactSubjects(key string, v any) any {
+	switch value := v.(type) {
+	case string:
+		return r.redactSubject(value)
+	case []any:
+		for i := range value {
+			value[i] = r.redactSubjects(key, value[i])
+		}
+		return value
+	default:
+		return r.redactValue(key, v)
+	}
+}
+
+// redactSubjectKeys redacts matching tokens of the keys of an object keyed by subject
+func (r *auditRedactor) redactSubjectKeys(key string, v any) any {
+	value, ok := v.(map[string]any)
+	if !ok {
+		return r.redactSubjects(key, v)
+	}
+
+	redacted := make(map[string]any, len(value))
+	for subject, subjectValue := range value {
+		redacted[r.redactSubject(subject)] = r.redactValue(key, subjectValue)
+	}
+	return redacted
+}
+
+// redactSubject replaces each token of the subject that matches one of the patterns
+func (r *auditRedactor) redactSubject(subject string) string {
+	if len(r.subjectTokenPatterns) == 0 {
+		return subject
+	}
+
+	tokens := strings.Split(subject, ".")
+	for i, token := range tokens {
+		for _, pattern := range r.subjectTokenPatterns {
+			if pattern.MatchString(token) {
+				tokens[i] = r.hash(token)
+				r.redactedCount += 1
+				break
+			}
+		}
+	}
+	return strings.Join(tokens, ".")
+}
+
+// hash returns a short salted hash of the given value
+func (r *auditRedactor) hash(value string) string {
+	sum := sha256.Sum256([]byte(r.salt + value))
+	return "redacted-" + hex.EncodeToString(sum[:6])
+}
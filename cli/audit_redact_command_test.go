@@ -0,0 +1,493 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+const auditRedactConnzFixture = `{
+  "server_id": "NSERVER",
+  "num_connections": 1,
+  "connections": [
+    {
+      "cid": 5,
+      "kind": "Client",
+      "ip": "10.0.0.1",
+      "port": 54321,
+      "name": "orders-service",
+      "lang": "go",
+      "authorized_user": "alice",
+      "account": "ACME",
+      "jwt": "eyJhbGciOi",
+      "issuer_key": "AISSUER",
+      "name_tag": "alice-tag",
+      "tags": ["team-a"],
+      "subscriptions_list": ["orders.C-1234.new", "_INBOX.abc"],
+      "subscriptions_list_detail": [{"account": "ACME", "subject": "orders.C-1234.new", "sid": "1", "msgs": 10, "cid": 5}]
+    }
+  ]
+}`
+
+const auditRedactVarzFixture = `{
+  "server_id": "NSERVER",
+  "server_name": "n1",
+  "host": "0.0.0.0",
+  "port": 4222,
+  "http_host": "monitor.example.net",
+  "max_payload": 1048576,
+  "cluster": {"name": "C1", "urls": ["nats://10.0.0.2:6222", "nats://10.0.0.3:6222"]},
+  "trusted_operators_jwt": ["eyJhbGciOi"],
+  "system_account": "SYS"
+}`
+
+const auditRedactSubszFixture = `{
+  "server_id": "NSERVER",
+  "num_subscriptions": 2,
+  "total": 2,
+  "subscriptions_list": [
+    {"account": "ACME", "subject": "orders.C-1234.new", "qgroup": "workers", "sid": "1", "msgs": 3, "cid": 5},
+    {"account": "ACME", "subject": "orders.*.new", "sid": "2", "msgs": 0, "cid": 6}
+  ]
+}`
+
+const auditRedactStreamFixture = `{
+  "name": "ORDERS",
+  "cluster": {"name": "C1", "leader": "n1"},
+  "config": {"name": "ORDERS", "subjects": ["orders.*.new"]},
+  "state": {"messages": 10, "subjects": {"orders.C-1234.new": 7, "orders.C-99.new": 3}},
+  "consumer_detail": [
+    {"name": "PROCESSOR", "config": {"filter_subject": "orders.C-1234.new", "deliver_subject": "_INBOX.xyz"}}
+  ]
+}`
+
+const auditRedactAccountFixture = `{
+  "account_name": "ACME",
+  "jwt": "eyJhbGciOi",
+  "decoded_jwt": {
+    "name": "Acme Corp",
+    "iss": "OOPERATOR",
+    "sub": "ACME",
+    "nats": {"limits": {"conn": 100, "subs": -1, "payload": 1048576}}
+  }
+}`
+
+const auditRedactMetadataFixture = `{
+  "connect_url": "nats://10.0.0.1:4222",
+  "user_name": "alice",
+  "gather_failures": [
+    {"artifact": "varz", "source": "server n2", "error": "Get \"http://10.0.0.2:8222/varz\": connection refused"}
+  ]
+}`
+
+// auditRedactLookup returns the value found at the given path of map keys and array indexes
+func auditRedactLookup(doc any, path ...any) (any, bool) {
+	for _, p := range path {
+		switch p := p.(type) {
+		case string:
+			m, ok := doc.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			doc, ok = m[p]
+			if !ok {
+				return nil, false
+			}
+		case int:
+			a, ok := doc.([]any)
+			if !ok || p >= len(a) {
+				return nil, false
+			}
+			doc = a[p]
+		}
+	}
+	return doc, true
+}
+
+func TestAuditRedactor(t *testing.T) {
+	newRedactor := func(profile string) *auditRedactor {
+		return &auditRedactor{
+			profile:              auditRedactionProfiles[profile],
+			salt:                 "salt",
+			subjectTokenPatterns: []*regexp.Regexp{regexp.MustCompile("^(?:C-[0-9]+)$")},
+		}
+	}
+	h := newRedactor("hash").hash
+
+	type expectation struct {
+		path   []any
+		expect any
+		absent bool
+	}
+
+	cases := []struct {
+		name         string
+		fixture      string
+		profile      string
+		expectations []expectation
+	}{
+		{
+			name: "connz hash", fixture: auditRedactConnzFixture, profile: "hash",
+			expectations: []expectation{
+				{path: []any{"server_id"}, expect: "NSERVER"},
+				{path: []any{"connections", 0, "ip"}, expect: h("10.0.0.1")},
+				{path: []any{"connections", 0, "name"}, expect: h("orders-service")},
+				{path: []any{"connections", 0, "authorized_user"}, expect: h("alice")},
+				{path: []any{"connections", 0, "name_tag"}, expect: h("alice-tag")},
+				{path: []any{"connections", 0, "issuer_key"}, expect: h("AISSUER")},
+				{path: []any{"connections", 0, "tags", 0}, expect: h("team-a")},
+				{path: []any{"connections", 0, "account"}, expect: "ACME"},
+				{path: []any{"connections", 0, "lang"}, expect: "go"},
+				{path: []any{"connections", 0, "jwt"}, absent: true},
+				{path: []any{"connections", 0, "subscriptions_list", 0}, expect: "orders." + h("C-1234") + ".new"},
+				{path: []any{"connections", 0, "subscriptions_list", 1}, expect: "_INBOX.abc"},
+				{path: []any{"connections", 0, "subscriptions_list_detail", 0, "subject"}, expect: "orders." + h("C-1234") + ".new"},
+			},
+		},
+		{
+			name: "connz drop", fixture: auditRedactConnzFixture, profile: "drop",
+			expectations: []expectation{
+				{path: []any{"connections", 0, "ip"}, absent: true},
+				{path: []any{"connections", 0, "name"}, absent: true},
+				{path: []any{"connections", 0, "authorized_user"}, absent: true},
+				{path: []any{"connections", 0, "tags"}, absent: true},
+				{path: []any{"connections", 0, "jwt"}, absent: true},
+				{path: []any{"connections", 0, "cid"}, expect: json.Number("5")},
+				{path: []any{"connections", 0, "account"}, expect: "ACME"},
+				{path: []any{"connections", 0, "subscriptions_list", 0}, expect: "orders." + h("C-1234") + ".new"},
+			},
+		},
+		{
+			name: "varz hash", fixture: auditRedactVarzFixture, profile: "hash",
+			expectations: []expectation{
+				{path: []any{"server_name"}, expect: "n1"},
+				{path: []any{"host"}, expect: h("0.0.0.0")},
+				{path: []any{"http_host"}, expect: h("monitor.example.net")},
+				{path: []any{"max_payload"}, expect: json.Number("1048576")},
+				{path: []any{"cluster", "name"}, expect: "C1"},
+				{path: []any{"cluster", "urls", 1}, expect: h("nats://10.0.0.3:6222")},
+				{path: []any{"trusted_operators_jwt"}, absent: true},
+				{path: []any{"system_account"}, expect: "SYS"},
+			},
+		},
+		{
+			name: "varz drop", fixture: auditRedactVarzFixture, profile: "drop",
+			expectations: []expectation{
+				{path: []any{"server_name"}, expect: "n1"},
+				{path: []any{"host"}, absent: true},
+				{path: []any{"http_host"}, absent: true},
+				{path: []any{"cluster", "urls"}, absent: true},
+				{path: []any{"cluster", "name"}, expect: "C1"},
+				{path: []any{"trusted_operators_jwt"}, absent: true},
+			},
+		},
+		{
+			name: "metadata hash", fixture: auditRedactMetadataFixture, profile: "hash",
+			expectations: []expectation{
+				{path: []any{"connect_url"}, expect: h("nats://10.0.0.1:4222")},
+				{path: []any{"gather_failures", 0, "source"}, expect: "server n2"},
+				{path: []any{"gather_failures", 0, "error"}, expect: h(`Get "http://10.0.0.2:8222/varz": connection refused`)},
+			},
+		},
+		{
+			name: "metadata drop", fixture: auditRedactMetadataFixture, profile: "drop",
+			expectations: []expectation{
+				{path: []any{"connect_url"}, absent: true},
+				{path: []any{"gather_failures", 0, "artifact"}, expect: "varz"},
+				{path: []any{"gather_failures", 0, "error"}, absent: true},
+			},
+		},
+		{
+			name: "subsz hash", fixture: auditRedactSubszFixture, profile: "hash",
+			expectations: []expectation{
+				{path: []any{"subscriptions_list", 0, "subject"}, expect: "orders." + h("C-1234") + ".new"},
+				{path: []any{"subscriptions_list", 0, "account"}, expect: "ACME"},
+				{path: []any{"subscriptions_list", 0, "qgroup"}, expect: "workers"},
+				{path: []any{"subscriptions_list", 0, "msgs"}, expect: json.Number("3")},
+				{path: []any{"subscriptions_list", 1, "subject"}, expect: "orders.*.new"},
+			},
+		},
+		{
+			name: "subsz drop", fixture: auditRedactSubszFixture, profile: "drop",
+			expectations: []expectation{
+				{path: []any{"subscriptions_list", 0, "subject"}, expect: "orders." + h("C-1234") + ".new"},
+				{path: []any{"subscriptions_list", 0, "sid"}, expect: "1"},
+				{path: []any{"subscriptions_list", 1, "cid"}, expect: json.Number("6")},
+			},
+		},
+		{
+			name: "stream hash", fixture: auditRedactStreamFixture, profile: "hash",
+			expectations: []expectation{
+				{path: []any{"name"}, expect: "ORDERS"},
+				{path: []any{"cluster", "leader"}, expect: "n1"},
+				{path: []any{"config", "subjects", 0}, expect: "orders.*.new"},
+				{path: []any{"state", "messages"}, expect: json.Number("10")},
+				{path: []any{"state", "subjects", "orders.C-1234.new"}, absent: true},
+				{path: []any{"state", "subjects", "orders." + h("C-1234") + ".new"}, expect: json.Number("7")},
+				{path: []any{"state", "subjects", "orders." + h("C-99") + ".new"}, expect: json.Number("3")},
+				{path: []any{"consumer_detail", 0, "name"}, expect: "PROCESSOR"},
+				{path: []any{"consumer_detail", 0, "config", "filter_subject"}, expect: "orders." + h("C-1234") + ".new"},
+				{path: []any{"consumer_detail", 0, "config", "deliver_subject"}, expect: "_INBOX.xyz"},
+			},
+		},
+		{
+			name: "stream drop", fixture: auditRedactStreamFixture, profile: "drop",
+			expectations: []expectation{
+				{path: []any{"name"}, expect: "ORDERS"},
+				{path: []any{"state", "subjects", "orders." + h("C-1234") + ".new"}, expect: json.Number("7")},
+				{path: []any{"consumer_detail", 0, "config", "filter_subject"}, expect: "orders." + h("C-1234") + ".new"},
+			},
+		},
+		{
+			name: "account hash", fixture: auditRedactAccountFixture, profile: "hash",
+			expectations: []expectation{
+				{path: []any{"account_name"}, expect: "ACME"},
+				{path: []any{"jwt"}, absent: true},
+				{path: []any{"decoded_jwt", "name"}, expect: h("Acme Corp")},
+				{path: []any{"decoded_jwt", "iss"}, expect: h("OOPERATOR")},
+				{path: []any{"decoded_jwt", "sub"}, expect: "ACME"},
+				{path: []any{"decoded_jwt", "nats", "limits", "conn"}, expect: json.Number("100")},
+			},
+		},
+		{
+			name: "account drop", fixture: auditRedactAccountFixture, profile: "drop",
+			expectations: []expectation{
+				{path: []any{"decoded_jwt", "name"}, expect: h("Acme Corp")},
+				{path: []any{"decoded_jwt", "nats", "limits", "payload"}, expect: json.Number("1048576")},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			redacted, err := newRedactor(c.profile).redactJSON([]byte(c.fixture))
+			assertNoError(t, err)
+
+			decoder := json.NewDecoder(bytes.NewReader(redacted))
+			decoder.UseNumber()
+			var doc any
+			err = decoder.Decode(&doc)
+			assertNoError(t, err)
+
+			for _, e := range c.expectations {
+				v, found := auditRedactLookup(doc, e.path...)
+				if e.absent {
+					if found {
+						t.Fatalf("expected %v to be removed, found: %v", e.path, v)
+					}
+					continue
+				}
+				if !found {
+					t.Fatalf("expected %v to be present in: %s", e.path, redacted)
+				}
+				if !cmp.Equal(v, e.expect) {
+					t.Fatalf("expected %v to be %v, got %v", e.path, e.expect, v)
+				}
+			}
+		})
+	}
+
+	t.Run("salted", func(t *testing.T) {
+		r := newRedactor("hash")
+		if r.hash("x") != r.hash("x") {
+			t.Fatalf("expected equal values to have equal hashes")
+		}
+		other := newRedactor("hash")
+		other.salt = "other"
+		if r.hash("x") == other.hash("x") {
+			t.Fatalf("expected different salts to produce different hashes")
+		}
+	})
+}
+
+func TestAuditRedactArchive(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.zip")
+	outputPath := filepath.Join(dir, "output.zip")
+
+	fixture := func(content string) map[string]any {
+		var v map[string]any
+		err := json.Unmarshal([]byte(content), &v)
+		checkErr(t, err, "invalid fixture: %v", err)
+		return v
+	}
+
+	aw, err := archive.NewWriter(inputPath)
+	checkErr(t, err, "failed to create archive: %v", err)
+	for _, serverName := range []string{"n1", "n2"} {
+		serverTags := []*archive.Tag{archive.TagCluster("C1"), archive.TagServer(serverName)}
+		err = aw.Add(fixture(auditRedactVarzFixture), append(serverTags, archive.TagServerVars())...)
+		checkErr(t, err, "failed to add artifact: %v", err)
+		err = aw.Add(fixture(auditRedactConnzFixture), append(serverTags, archive.TagServerConnections())...)
+		checkErr(t, err, "failed to add artifact: %v", err)
+		err = aw.Add(fixture(auditRedactSubszFixture), append(serverTags, archive.TagServerSubs())...)
+		checkErr(t, err, "failed to add artifact: %v", err)
+		err = aw.Add(fixture(auditRedactAccountFixture), append(serverTags, archive.TagAccount("ACME"), archive.TagAccountInfo())...)
+		checkErr(t, err, "failed to add artifact: %v", err)
+		err = aw.Add(fixture(auditRedactStreamFixture), append(serverTags, archive.TagAccount("ACME"), archive.TagStream("ORDERS"), archive.TagStreamInfo())...)
+		checkErr(t, err, "failed to add artifact: %v", err)
+	}
+	err = aw.Close()
+	checkErr(t, err, "failed to close archive: %v", err)
+
+	for _, profile := range []string{"hash", "drop"} {
+		t.Run(profile, func(t *testing.T) {
+			c := &auditRedactCmd{inputPath: inputPath, outputPath: outputPath, profileName: profile, salt: "salt"}
+			err := c.redact(nil)
+			checkErr(t, err, "redact failed: %v", err)
+
+			input, err := archive.NewReader(inputPath)
+			checkErr(t, err, "failed to open input archive: %v", err)
+			defer input.Close()
+			output, err := archive.NewReader(outputPath)
+			checkErr(t, err, "failed to open redacted archive: %v", err)
+			defer output.Close()
+
+			if !cmp.Equal(input.ListArtifacts(), output.ListArtifacts()) {
+				t.Fatalf("expected the same artifacts: %s", cmp.Diff(input.ListArtifacts(), output.ListArtifacts()))
+			}
+			for _, name := range input.ListArtifacts() {
+				inputTags, err := input.GetArtifactTags(name)
+				checkErr(t, err, "failed to get tags of %s: %v", name, err)
+				outputTags, err := output.GetArtifactTags(name)
+				checkErr(t, err, "failed to get tags of %s: %v", name, err)
+				if !cmp.Equal(inputTags, outputTags) {
+					t.Fatalf("expected the same tags for %s: %s", name, cmp.Diff(inputTags, outputTags))
+				}
+			}
+
+			assertListEquals(t, output.GetClusterServerNames("C1"), "n1", "n2")
+			assertListEquals(t, output.GetAccountStreamNames("ACME"), "ORDERS")
+
+			var connz map[string]any
+			err = output.Load(&connz, archive.TagCluster("C1"), archive.TagServer("n2"), archive.TagServerConnections())
+			checkErr(t, err, "failed to load redacted connections: %v", err)
+			if ip, found := auditRedactLookup(connz, "connections", 0, "ip"); found && ip == "10.0.0.1" {
+				t.Fatalf("expected client address to be redacted")
+			}
+
+			var accountInfo map[string]any
+			err = output.Load(&accountInfo, archive.TagServer("n1"), archive.TagAccount("ACME"), archive.TagAccountInfo())
+			checkErr(t, err, "failed to load redacted account info: %v", err)
+			if _, found := auditRedactLookup(accountInfo, "decoded_jwt", "nats", "limits", "conn"); !found {
+				t.Fatalf("expected account limits to be preserved")
+			}
+		})
+	}
+}
+
+func TestAuditRedactGatheredArchive(t *testing.T) {
+	SetContext(context.Background())
+
+	srv, err := server.NewServer(&server.Options{
+		ServerName: "n1",
+		Host:       "127.0.0.1",
+		Port:       -1,
+		HTTPHost:   "127.0.0.1",
+		HTTPPort:   -1,
+		StoreDir:   t.TempDir(),
+		JetStream:  true,
+	})
+	checkErr(t, err, "could not start server: %v", err)
+	go srv.Start()
+	defer srv.Shutdown()
+	if !srv.ReadyForConnections(10 * time.Second) {
+		t.Fatalf("nats server did not start")
+	}
+
+	dir := t.TempDir()
+	c := newAuditGatherCmd()
+	c.archiveFilePath = filepath.Join(dir, "input.zip")
+	c.count = 1
+	c.workers = 2
+	c.requestTimeout = 5 * time.Second
+	c.retryBackoff = time.Millisecond
+	c.monitorURLs = []string{fmt.Sprintf("http://%s", srv.MonitorAddr())}
+	c.include.serverEndpoints = true
+	c.include.accountEndpoints = true
+	c.include.streams = true
+	c.include.consumers = true
+
+	err = c.gather(nil)
+	checkErr(t, err, "gather failed: %v", err)
+
+	input, err := archive.NewReader(c.archiveFilePath)
+	checkErr(t, err, "failed to open input archive: %v", err)
+	defer input.Close()
+
+	var captureLog []byte
+	for _, name := range input.ListArtifacts() {
+		if strings.HasSuffix(name, ".log") {
+			captureLog, err = input.ReadArtifact(name)
+			checkErr(t, err, "failed to read capture log: %v", err)
+		}
+	}
+	if !strings.Contains(string(captureLog), "127.0.0.1") {
+		t.Fatalf("expected the capture log to include the monitoring address:\n%s", captureLog)
+	}
+
+	for _, profile := range []string{"hash", "drop"} {
+		t.Run(profile, func(t *testing.T) {
+			outputPath := filepath.Join(dir, profile+".zip")
+			rc := &auditRedactCmd{inputPath: c.archiveFilePath, outputPath: outputPath, profileName: profile, salt: "salt"}
+			err := rc.redact(nil)
+			checkErr(t, err, "redact failed: %v", err)
+
+			output, err := archive.NewReader(outputPath)
+			checkErr(t, err, "failed to open redacted archive: %v", err)
+			defer output.Close()
+
+			if len(output.ListArtifacts()) != len(input.ListArtifacts())-1 {
+				t.Fatalf("expected all artifacts but the capture log, got %v", output.ListArtifacts())
+			}
+			for _, name := range output.ListArtifacts() {
+				content, err := output.ReadArtifact(name)
+				checkErr(t, err, "failed to read %s: %v", name, err)
+				if strings.Contains(string(content), "127.0.0.1") {
+					t.Fatalf("address found in redacted artifact %s:\n%s", name, content)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditRedactUnsupportedArtifact(t *testing.T) {
+	dir := t.TempDir()
+	inputPath := filepath.Join(dir, "input.zip")
+
+	aw, err := archive.NewWriter(inputPath)
+	checkErr(t, err, "failed to create archive: %v", err)
+	err = aw.AddRaw(bytes.NewReader([]byte("server at 10.0.0.1")), "txt", archive.TagSpecial("notes"))
+	checkErr(t, err, "failed to add artifact: %v", err)
+	err = aw.Close()
+	checkErr(t, err, "failed to close archive: %v", err)
+
+	c := &auditRedactCmd{inputPath: inputPath, outputPath: filepath.Join(dir, "output.zip"), profileName: "hash", salt: "salt"}
+	err = c.redact(nil)
+	if err == nil || !strings.Contains(err.Error(), "unsupported artifact type 'txt'") {
+		t.Fatalf("expected unsupported artifact error, got: %v", err)
+	}
+}
@@ -0,0 +1,738 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/natscli/archive"
+)
+
+type auditReplayCmd struct {
+	archivePath string
+	keys        auditArchiveKeys
+	host        string
+	port        int
+	account     string
+	servers     []*auditReplayServer
+	streams     map[string]*auditReplayStream
+	streamNames []string
+}
+
+// auditReplayServer holds the archived endpoints of a server, used to answer requests as if they came from it
+type auditReplayServer struct {
+	info             server.ServerInfo
+	varz             *server.Varz
+	metaLeader       bool
+	endpoints        map[string]json.RawMessage
+	accountsCount    int
+	accountEndpoints map[string]map[string]json.RawMessage
+	routez           *server.Routez
+	gatewayz         *server.Gatewayz
+}
+
+// auditReplayStream is the state of a stream, as reported by its most up-to-date replica
+type auditReplayStream struct {
+	info      *auditReplayStreamInfo
+	consumers map[string]*server.ConsumerInfo
+	names     []string
+}
+
+// auditReplayStreamInfo is the JetStream API representation of a stream
+type auditReplayStreamInfo struct {
+	Config    *server.StreamConfig       `json:"config"`
+	Created   time.Time                  `json:"created"`
+	State     server.StreamState         `json:"state"`
+	Cluster   *server.ClusterInfo        `json:"cluster,omitempty"`
+	Mirror    *server.StreamSourceInfo   `json:"mirror,omitempty"`
+	Sources   []*server.StreamSourceInfo `json:"sources,omitempty"`
+	TimeStamp time.Time                  `json:"ts"`
+}
+
+// auditReplayRequestOptions are the request options honored when answering system requests, all other options are
+// ignored and archived responses are returned as they were captured
+type auditReplayRequestOptions struct {
+	server.EventFilterOptions
+	LeaderOnly bool `json:"leader_only,omitempty"`
+}
+
+type auditReplayAPIError struct {
+	Code        int    `json:"code"`
+	ErrCode     uint16 `json:"err_code,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type auditReplayAPIResponse struct {
+	Type  string               `json:"type"`
+	Error *auditReplayAPIError `json:"error,omitempty"`
+}
+
+type auditReplayAPIPaged struct {
+	Total  int `json:"total"`
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+}
+
+type auditReplayAPIPagedRequest struct {
+	Offset int `json:"offset"`
+}
+
+const (
+	auditReplayNamesLimit = 1024
+	auditReplayListLimit  = 256
+)
+
+// Endpoints of server and account artifacts, keyed by the suffix of the system request subject
+var (
+	auditReplayServerEndpoints = map[string]*archive.Tag{
+		"VARZ":     archive.TagServerVars(),
+		"CONNZ":    archive.TagServerConnections(),
+		"ROUTEZ":   archive.TagServerRoutes(),
+		"GATEWAYZ": archive.TagServerGateways(),
+		"LEAFZ":    archive.TagServerLeafs(),
+		"SUBSZ":    archive.TagServerSubs(),
+		"JSZ":      archive.TagServerJetStream(),
+		"ACCOUNTZ": archive.TagServerAccounts(),
+		"HEALTHZ":  archive.TagServerHealth(),
+	}
+	auditReplayAccountEndpoints = map[string]*archive.Tag{
+		"CONNZ": archive.TagAccountConnections(),
+		"LEAFZ": archive.TagAccountLeafs(),
+		"SUBSZ": archive.TagAccountSubs(),
+		"INFO":  archive.TagAccountInfo(),
+		"JSZ":   archive.TagAccountJetStream(),
+	}
+)
+
+func configureAuditReplayCommand(srv *fisk.CmdClause) {
+	c := &auditReplayCmd{}
+
+	replayHelp := `Starts a local NATS server that answers system and JetStream API
+requests using the content of an archive, as the servers in the
+archive would have at the time of capture.
+
+Most reporting commands can then be used against the archive,
+for example:
+
+   nats audit replay archive.zip --port 4333
+   nats --server nats://127.0.0.1:4333 server report jetstream
+   nats --server nats://127.0.0.1:4333 stream report
+
+Server name, cluster, host and domain filters of system requests
+are honored, all other request options (sorting, paging, details)
+are ignored and the archived responses are returned as they were
+captured.
+
+JetStream API requests are answered using the streams of a single
+account (see --account), only read requests are supported.
+`
+
+	replay := srv.Command("replay", "serve the content of an archive as a local NATS deployment, for use with other commands").Action(c.replay)
+	replay.HelpLong(replayHelp)
+	replay.Arg("archive", "path to input archive to replay").Required().ExistingFileVar(&c.archivePath)
+	replay.Flag("host", "Host to listen on").Default("127.0.0.1").StringVar(&c.host)
+	replay.Flag("port", "Port to listen on (default: random)").Default("-1").IntVar(&c.port)
+	replay.Flag("account", "Account whose streams are served over the JetStream API").StringVar(&c.account)
+	c.keys.configureFlags(replay)
+}
+
+func (c *auditReplayCmd) replay(_ *fisk.ParseContext) error {
+	ar, err := c.keys.openArchive(c.archivePath)
+	if err != nil {
+		return err
+	}
+
+	err = c.loadServers(ar)
+	if err == nil {
+		err = c.loadStreams(ar)
+	}
+	ar.Close()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	srv, nc, err := c.start()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		nc.Close()
+		srv.Shutdown()
+		srv.WaitForShutdown()
+	}()
+
+	fmt.Printf("Replaying %s: %d servers", c.archivePath, len(c.servers))
+	if c.streams != nil {
+		fmt.Printf(", %d streams in account %s", len(c.streams), c.account)
+	}
+	fmt.Println()
+	fmt.Println()
+	fmt.Printf("                     URL: %s\n", srv.ClientURL())
+	fmt.Printf("                 Example: nats --server %s server list\n", srv.ClientURL())
+	fmt.Println()
+	fmt.Println("Press Ctrl-C to stop")
+
+	<-ctx.Done()
+
+	return nil
+}
+
+// start starts the replay server and subscribes the request handlers
+func (c *auditReplayCmd) start() (*server.Server, *nats.Conn, error) {
+	// No system account, so that $SYS subjects are regular subjects handled by the subscriptions below
+	srv, err := server.NewServer(&server.Options{
+		ServerName:      "audit_replay",
+		Host:            c.host,
+		Port:            c.port,
+		NoSystemAccount: true,
+		NoSigs:          true,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	srv.Start()
+
+	nc, err := c.subscribe(srv)
+	if err != nil {
+		srv.Shutdown()
+		srv.WaitForShutdown()
+		return nil, nil, err
+	}
+
+	return srv, nc, nil
+}
+
+// subscribe connects to the replay server and subscribes the request handlers
+func (c *auditReplayCmd) subscribe(srv *server.Server) (*nats.Conn, error) {
+	if !srv.ReadyForConnections(10 * time.Second) {
+		return nil, fmt.Errorf("replay server did not start")
+	}
+
+	nc, err := nats.Connect(srv.ClientURL(), nats.Name("audit replay"))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = nc.Subscribe("$SYS.REQ.SERVER.>", c.handleServerRequest)
+	if err == nil {
+		_, err = nc.Subscribe("$SYS.REQ.ACCOUNT.*.*", c.handleAccountRequest)
+	}
+	if err == nil && c.streams != nil {
+		_, err = nc.Subscribe("$JS.API.>", c.handleJetStreamRequest)
+	}
+	if err == nil {
+		// Make sure the subscriptions are registered before requests are sent
+		err = nc.Flush()
+	}
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	return nc, nil
+}
+
+// loadServers loads the endpoints of all servers in the archive
+func (c *auditReplayCmd) loadServers(ar *archive.Reader) error {
+	accountNames := ar.GetAccountNames()
+
+	for _, clusterName := range ar.GetClusterNames() {
+		for _, serverName := range ar.GetClusterServerNames(clusterName) {
+			serverTag := archive.TagServer(serverName)
+
+			var varz server.Varz
+			err := ar.Load(&varz, serverTag, archive.TagServerVars())
+			if errors.Is(err, archive.ErrNoMatches) {
+				fmt.Printf("Warning: skipping server %s, variables artifact not found\n", serverName)
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to load variables of server %s: %w", serverName, err)
+			}
+
+			s := &auditReplayServer{
+				info: server.ServerInfo{
+					Name:      varz.Name,
+					Host:      varz.Host,
+					ID:        varz.ID,
+					Cluster:   varz.Cluster.Name,
+					Version:   varz.Version,
+					Tags:      varz.Tags,
+					JetStream: varz.JetStream.Config != nil,
+				},
+				varz:             &varz,
+				metaLeader:       varz.JetStream.Meta != nil && varz.JetStream.Meta.Leader == varz.Name,
+				endpoints:        make(map[string]json.RawMessage),
+				accountEndpoints: make(map[string]map[string]json.RawMessage),
+			}
+			if varz.JetStream.Config != nil {
+				s.info.Domain = varz.JetStream.Config.Domain
+			}
+
+			for suffix, typeTag := range auditReplayServerEndpoints {
+				var endpoint json.RawMessage
+				err := ar.Load(&endpoint, serverTag, typeTag)
+				if errors.Is(err, archive.ErrNoMatches) {
+					continue
+				} else if err != nil {
+					return fmt.Errorf("failed to load %s of server %s: %w", typeTag.Value, serverName, err)
+				}
+				s.endpoints[suffix] = endpoint
+			}
+
+			// Decode the endpoints needed to compose server statistics
+			if data, found := s.endpoints["ROUTEZ"]; found {
+				s.routez = &server.Routez{}
+				err = json.Unmarshal(data, s.routez)
+				if err != nil {
+					return fmt.Errorf("failed to decode routes of server %s: %w", serverName, err)
+				}
+			}
+			if data, found := s.endpoints["GATEWAYZ"]; found {
+				s.gatewayz = &server.Gatewayz{}
+				err = json.Unmarshal(data, s.gatewayz)
+				if err != nil {
+					return fmt.Errorf("failed to decode gateways of server %s: %w", serverName, err)
+				}
+			}
+			if data, found := s.endpoints["ACCOUNTZ"]; found {
+				var accountz server.Accountz
+				err = json.Unmarshal(data, &accountz)
+				if err != nil {
+					return fmt.Errorf("failed to decode accounts of server %s: %w", serverName, err)
+				}
+				s.accountsCount = len(accountz.Accounts)
+			}
+
+			for _, accountName := range accountNames {
+				for suffix, typeTag := range auditReplayAccountEndpoints {
+					var endpoint json.RawMessage
+					err := ar.Load(&endpoint, archive.TagAccount(accountName), serverTag, typeTag)
+					if errors.Is(err, archive.ErrNoMatches) {
+						continue
+					} else if err != nil {
+						return fmt.Errorf("failed to load %s of account %s on server %s: %w", typeTag.Value, accountName, serverName, err)
+					}
+					if s.accountEndpoints[accountName] == nil {
+						s.accountEndpoints[accountName] = make(map[string]json.RawMessage)
+					}
+					s.accountEndpoints[accountName][suffix] = endpoint
+				}
+			}
+
+			c.servers = append(c.servers, s)
+		}
+	}
+
+	if len(c.servers) == 0 {
+		return fmt.Errorf("no servers found in archive")
+	}
+
+	return nil
+}
+
+// loadStreams loads the streams of the selected account
+func (c *auditReplayCmd) loadStreams(ar *archive.Reader) error {
+	if c.account == "" {
+		var accountsWithStreams []string
+		for _, accountName := range ar.GetAccountNames() {
+			if len(ar.GetAccountStreamNames(accountName)) > 0 {
+				accountsWithStreams = append(accountsWithStreams, accountName)
+			}
+		}
+
+		switch len(accountsWithStreams) {
+		case 0:
+			fmt.Println("No streams found in archive, JetStream API requests will not be answered")
+			return nil
+		case 1:
+			c.account = accountsWithStreams[0]
+		default:
+			return fmt.Errorf("multiple accounts with streams in archive, select one with --account: %s", strings.Join(accountsWithStreams, ", "))
+		}
+	}
+
+	c.streams = make(map[string]*auditReplayStream)
+	for _, streamName := range ar.GetAccountStreamNames(c.account) {
+		var current *server.StreamDetail
+		for _, serverName := range ar.GetStreamServerNames(c.account, streamName) {
+			var streamDetail server.StreamDetail
+			err := ar.Load(&streamDetail, archive.TagAccount(c.account), archive.TagStream(streamName), archive.TagServer(serverName), archive.TagStreamInfo())
+			if errors.Is(err, archive.ErrNoMatches) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to load stream %s on server %s: %w", streamName, serverName, err)
+			}
+
+			// Prefer the leader replica, or the most up-to-date one
+			isLeader := streamDetail.Cluster != nil && streamDetail.Cluster.Leader == serverName
+			if current == nil || isLeader || streamDetail.State.LastSeq > current.State.LastSeq {
+				current = &streamDetail
+			}
+			if isLeader {
+				break
+			}
+		}
+		if current == nil {
+			continue
+		}
+
+		stream := &auditReplayStream{
+			info: &auditReplayStreamInfo{
+				Config:    current.Config,
+				Created:   current.Created,
+				State:     current.State,
+				Cluster:   current.Cluster,
+				Mirror:    current.Mirror,
+				Sources:   current.Sources,
+				TimeStamp: time.Now().UTC(),
+			},
+			consumers: make(map[string]*server.ConsumerInfo, len(current.Consumer)),
+		}
+		for _, consumer := range current.Consumer {
+			stream.consumers[consumer.Name] = consumer
+			stream.names = append(stream.names, consumer.Name)
+		}
+		slices.Sort(stream.names)
+
+		c.streams[streamName] = stream
+		c.streamNames = append(c.streamNames, streamName)
+	}
+	slices.Sort(c.streamNames)
+
+	return nil
+}
+
+// matches returns true if the server satisfies the filters of the request
+func (s *auditReplayServer) matches(opts *auditReplayRequestOptions) bool {
+	switch {
+	case opts.Name != "" && !strings.Contains(s.info.Name, opts.Name):
+		return false
+	case opts.Cluster != "" && !strings.Contains(s.info.Cluster, opts.Cluster):
+		return false
+	case opts.Host != "" && !strings.Contains(s.info.Host, opts.Host):
+		return false
+	case opts.Domain != "" && s.info.Domain != opts.Domain:
+		return false
+	case opts.LeaderOnly && !s.metaLeader:
+		return false
+	default:
+		return true
+	}
+}
+
+// stats composes the server statistics (STATSZ) from the archived endpoints
+func (s *auditReplayServer) stats(activeServers int) *server.ServerStatsMsg {
+	msg := &server.ServerStatsMsg{Server: s.info}
+	msg.Server.Time = time.Now().UTC()
+
+	msg.Stats.Start = s.varz.Start
+	msg.Stats.Mem = s.varz.Mem
+	msg.Stats.Cores = s.varz.Cores
+	msg.Stats.CPU = s.varz.CPU
+	msg.Stats.Connections = s.varz.Connections
+	msg.Stats.TotalConnections = s.varz.TotalConnections
+	msg.Stats.ActiveAccounts = s.accountsCount
+	msg.Stats.NumSubs = s.varz.Subscriptions
+	msg.Stats.Sent.Msgs = s.varz.OutMsgs
+	msg.Stats.Sent.Bytes = s.varz.OutBytes
+	msg.Stats.Received.Msgs = s.varz.InMsgs
+	msg.Stats.Received.Bytes = s.varz.InBytes
+	msg.Stats.SlowConsumers = s.varz.SlowConsumers
+	msg.Stats.ActiveServers = activeServers
+
+	if s.varz.JetStream.Config != nil {
+		jsVarz := s.varz.JetStream
+		msg.Stats.JetStream = &jsVarz
+	}
+
+	if s.routez != nil {
+		for _, route := range s.routez.Routes {
+			routeStat := &server.RouteStat{
+				ID:      route.Rid,
+				Pending: route.Pending,
+			}
+			routeStat.Sent.Msgs = route.OutMsgs
+			routeStat.Sent.Bytes = route.OutBytes
+			routeStat.Received.Msgs = route.InMsgs
+			routeStat.Received.Bytes = route.InBytes
+			msg.Stats.Routes = append(msg.Stats.Routes, routeStat)
+		}
+	}
+
+	if s.gatewayz != nil {
+		for gatewayName, outbound := range s.gatewayz.OutboundGateways {
+			gatewayStat := &server.GatewayStat{
+				Name:       gatewayName,
+				NumInbound: len(s.gatewayz.InboundGateways[gatewayName]),
+			}
+			if outbound.Connection != nil {
+				gatewayStat.ID = outbound.Connection.Cid
+				gatewayStat.Sent.Msgs = outbound.Connection.OutMsgs
+				gatewayStat.Sent.Bytes = outbound.Connection.OutBytes
+				gatewayStat.Received.Msgs = outbound.Connection.InMsgs
+				gatewayStat.Received.Bytes = outbound.Connection.InBytes
+			}
+			msg.Stats.Gateways = append(msg.Stats.Gateways, gatewayStat)
+		}
+	}
+
+	return msg
+}
+
+// respond sends the given response, or an empty response if it cannot be encoded
+func (c *auditReplayCmd) respond(m *nats.Msg, response any) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		fmt.Printf("Failed to encode response to %s: %s\n", m.Subject, err)
+		return
+	}
+	err = m.Respond(data)
+	if err != nil {
+		fmt.Printf("Failed to respond to %s: %s\n", m.Subject, err)
+	}
+}
+
+// respondEndpoint responds with an archived endpoint wrapped in a system API response from the given server
+func (c *auditReplayCmd) respondEndpoint(m *nats.Msg, s *auditReplayServer, data json.RawMessage) {
+	info := s.info
+	info.Time = time.Now().UTC()
+	c.respond(m, &serverAPIResponseNoData{
+		Server: &info,
+		Data:   data,
+	})
+}
+
+// respondEndpointError responds with an error from the given server, for an endpoint that is not in the archive
+func (c *auditReplayCmd) respondEndpointError(m *nats.Msg, s *auditReplayServer, endpoint string) {
+	info := s.info
+	info.Time = time.Now().UTC()
+	c.respond(m, &serverAPIResponseNoData{
+		Server: &info,
+		Error: &server.ApiError{
+			Code:        404,
+			Description: fmt.Sprintf("%s not available in archive", endpoint),
+		},
+	})
+}
+
+// handleServerRequest answers $SYS.REQ.SERVER.PING, $SYS.REQ.SERVER.PING.<endpoint> and
+// $SYS.REQ.SERVER.<id>.<endpoint> requests
+func (c *auditReplayCmd) handleServerRequest(m *nats.Msg) {
+	tokens := strings.Split(m.Subject, ".")
+	if len(tokens) < 4 || len(tokens) > 5 || m.Reply == "" {
+		return
+	}
+
+	target, endpoint := tokens[3], "STATSZ"
+	if len(tokens) == 5 {
+		endpoint = tokens[4]
+	}
+
+	var opts auditReplayRequestOptions
+	if len(m.Data) > 0 {
+		// Unknown or malformed options are ignored
+		_ = json.Unmarshal(m.Data, &opts)
+	}
+
+	for _, s := range c.servers {
+		if target == "PING" {
+			if !s.matches(&opts) {
+				continue
+			}
+		} else if target != s.info.ID {
+			continue
+		}
+
+		if endpoint == "STATSZ" {
+			c.respond(m, s.stats(len(c.servers)))
+		} else if data, found := s.endpoints[endpoint]; found {
+			c.respondEndpoint(m, s, data)
+		} else if target != "PING" {
+			// A request to a single server expects a response, even if the endpoint was not captured
+			c.respondEndpointError(m, s, endpoint)
+		}
+	}
+}
+
+// handleAccountRequest answers $SYS.REQ.ACCOUNT.<account>.<endpoint> requests with the responses of each server
+func (c *auditReplayCmd) handleAccountRequest(m *nats.Msg) {
+	tokens := strings.Split(m.Subject, ".")
+	if len(tokens) != 5 || m.Reply == "" {
+		return
+	}
+
+	accountName, endpoint := tokens[3], tokens[4]
+	for _, s := range c.servers {
+		if data, found := s.accountEndpoints[accountName][endpoint]; found {
+			c.respondEndpoint(m, s, data)
+		}
+	}
+}
+
+// handleJetStreamRequest answers read-only JetStream API requests using the streams of the selected account
+func (c *auditReplayCmd) handleJetStreamRequest(m *nats.Msg) {
+	if m.Reply == "" {
+		return
+	}
+
+	api := strings.TrimPrefix(m.Subject, "$JS.API.")
+	tokens := strings.Split(api, ".")
+
+	var page auditReplayAPIPagedRequest
+	if len(m.Data) > 0 {
+		_ = json.Unmarshal(m.Data, &page)
+	}
+
+	switch {
+	case api == "INFO":
+		c.respondAccountInfo(m)
+
+	case api == "STREAM.NAMES":
+		names, paged := auditReplayPage(c.streamNames, page.Offset, auditReplayNamesLimit)
+		c.respond(m, &struct {
+			auditReplayAPIResponse
+			auditReplayAPIPaged
+			Streams []string `json:"streams"`
+		}{auditReplayAPIResponse{Type: "io.nats.jetstream.api.v1.stream_names_response"}, paged, names})
+
+	case api == "STREAM.LIST":
+		names, paged := auditReplayPage(c.streamNames, page.Offset, auditReplayListLimit)
+		streams := make([]*auditReplayStreamInfo, 0, len(names))
+		for _, name := range names {
+			streams = append(streams, c.streams[name].info)
+		}
+		c.respond(m, &struct {
+			auditReplayAPIResponse
+			auditReplayAPIPaged
+			Streams []*auditReplayStreamInfo `json:"streams"`
+		}{auditReplayAPIResponse{Type: "io.nats.jetstream.api.v1.stream_list_response"}, paged, streams})
+
+	case len(tokens) == 3 && tokens[0] == "STREAM" && tokens[1] == "INFO":
+		stream, found := c.streams[tokens[2]]
+		if !found {
+			c.respondError(m, "io.nats.jetstream.api.v1.stream_info_response", 404, 10059, "stream not found")
+			return
+		}
+		c.respond(m, &struct {
+			auditReplayAPIResponse
+			*auditReplayStreamInfo
+		}{auditReplayAPIResponse{Type: "io.nats.jetstream.api.v1.stream_info_response"}, stream.info})
+
+	case len(tokens) == 3 && tokens[0] == "CONSUMER" && (tokens[1] == "NAMES" || tokens[1] == "LIST"):
+		responseType := fmt.Sprintf("io.nats.jetstream.api.v1.consumer_%s_response", strings.ToLower(tokens[1]))
+		stream, found := c.streams[tokens[2]]
+		if !found {
+			c.respondError(m, responseType, 404, 10059, "stream not found")
+			return
+		}
+		if tokens[1] == "NAMES" {
+			names, paged := auditReplayPage(stream.names, page.Offset, auditReplayNamesLimit)
+			c.respond(m, &struct {
+				auditReplayAPIResponse
+				auditReplayAPIPaged
+				Consumers []string `json:"consumers"`
+			}{auditReplayAPIResponse{Type: responseType}, paged, names})
+			return
+		}
+		names, paged := auditReplayPage(stream.names, page.Offset, auditReplayListLimit)
+		consumers := make([]*server.ConsumerInfo, 0, len(names))
+		for _, name := range names {
+			consumers = append(consumers, stream.consumers[name])
+		}
+		c.respond(m, &struct {
+			auditReplayAPIResponse
+			auditReplayAPIPaged
+			Consumers []*server.ConsumerInfo `json:"consumers"`
+		}{auditReplayAPIResponse{Type: responseType}, paged, consumers})
+
+	case len(tokens) == 4 && tokens[0] == "CONSUMER" && tokens[1] == "INFO":
+		responseType := "io.nats.jetstream.api.v1.consumer_info_response"
+		stream, found := c.streams[tokens[2]]
+		if !found {
+			c.respondError(m, responseType, 404, 10059, "stream not found")
+			return
+		}
+		consumer, found := stream.consumers[tokens[3]]
+		if !found {
+			c.respondError(m, responseType, 404, 10014, "consumer not found")
+			return
+		}
+		c.respond(m, &struct {
+			auditReplayAPIResponse
+			*server.ConsumerInfo
+		}{auditReplayAPIResponse{Type: responseType}, consumer})
+
+	default:
+		c.respondError(m, "io.nats.jetstream.api.v1.error_response", 400, 0, "not supported when replaying an archive")
+	}
+}
+
+// respondAccountInfo answers $JS.API.INFO with usage computed from the archived streams
+func (c *auditReplayCmd) respondAccountInfo(m *nats.Msg) {
+	stats := &server.JetStreamAccountStats{}
+	for _, stream := range c.streams {
+		if stream.info.Config != nil && stream.info.Config.Storage == server.MemoryStorage {
+			stats.Memory += stream.info.State.Bytes
+		} else {
+			stats.Store += stream.info.State.Bytes
+		}
+		stats.Streams += 1
+		stats.Consumers += len(stream.consumers)
+	}
+
+	c.respond(m, &struct {
+		auditReplayAPIResponse
+		*server.JetStreamAccountStats
+	}{auditReplayAPIResponse{Type: "io.nats.jetstream.api.v1.account_info_response"}, stats})
+}
+
+func (c *auditReplayCmd) respondError(m *nats.Msg, responseType string, code int, errCode uint16, description string) {
+	c.respond(m, &auditReplayAPIResponse{
+		Type: responseType,
+		Error: &auditReplayAPIError{
+			Code:        code,
+			ErrCode:     errCode,
+			Description: description,
+		},
+	})
+}
+
+// auditReplayPage returns the page of names starting at the given offset
+func auditReplayPage(names []string, offset int, limit int) ([]string, auditReplayAPIPaged) {
+	paged := auditReplayAPIPaged{
+		Total:  len(names),
+		Offset: offset,
+		Limit:  limit,
+	}
+	if offset >= len(names) || offset < 0 {
+		return []string{}, paged
+	}
+	end := min(offset+limit, len(names))
+	return names[offset:end], paged
+}
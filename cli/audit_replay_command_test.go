@@ -0,0 +1,199 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/jsm.go/api"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/natscli/archive"
+)
+
+func writeReplayTestArchive(t *testing.T) string {
+	t.Helper()
+
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+
+	for i, serverName := range []string{"n1", "n2"} {
+		serverTags := []*archive.Tag{archive.TagCluster("C1"), archive.TagServer(serverName)}
+
+		varz := &server.Varz{
+			ID:            "NID" + serverName,
+			Name:          serverName,
+			Host:          "127.0.0.1",
+			Version:       "2.10.0",
+			Connections:   i + 1,
+			InMsgs:        100,
+			Subscriptions: 10,
+			Cluster:       server.ClusterOptsVarz{Name: "C1"},
+			JetStream: server.JetStreamVarz{
+				Config: &server.JetStreamConfig{MaxStore: 1024 * 1024},
+				Meta:   &server.MetaClusterInfo{Name: "C1", Leader: "n1", Size: 2},
+			},
+		}
+		err = aw.Add(varz, append(serverTags, archive.TagServerVars())...)
+		checkErr(t, err, "could not add varz: %v", err)
+
+		jsz := &server.JSInfo{ID: varz.ID, Streams: 1, Consumers: 1, Messages: 5}
+		err = aw.Add(jsz, append(serverTags, archive.TagServerJetStream())...)
+		checkErr(t, err, "could not add jsz: %v", err)
+
+		stream := &server.StreamDetail{
+			Name:    "ORDERS",
+			Created: time.Now().UTC(),
+			Cluster: &server.ClusterInfo{Name: "C1", Leader: "n1"},
+			Config:  &server.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}, Storage: server.FileStorage, Replicas: 2},
+			State:   server.StreamState{Msgs: 5, Bytes: 500, FirstSeq: 1, LastSeq: 5},
+			Consumer: []*server.ConsumerInfo{
+				{Stream: "ORDERS", Name: "PROCESSOR", Config: &server.ConsumerConfig{Durable: "PROCESSOR", AckPolicy: server.AckExplicit}, NumPending: 2},
+			},
+		}
+		err = aw.Add(stream, append(serverTags, archive.TagAccount("ACME"), archive.TagStream("ORDERS"), archive.TagStreamInfo())...)
+		checkErr(t, err, "could not add stream: %v", err)
+	}
+
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	return archivePath
+}
+
+func withReplay(t *testing.T, cb func(nc *nats.Conn)) {
+	t.Helper()
+
+	c := &auditReplayCmd{archivePath: writeReplayTestArchive(t), host: "127.0.0.1", port: -1}
+
+	ar, err := archive.NewReader(c.archivePath)
+	checkErr(t, err, "could not open archive: %v", err)
+	err = c.loadServers(ar)
+	checkErr(t, err, "could not load servers: %v", err)
+	err = c.loadStreams(ar)
+	checkErr(t, err, "could not load streams: %v", err)
+	ar.Close()
+
+	srv, rnc, err := c.start()
+	checkErr(t, err, "could not start replay: %v", err)
+	defer func() {
+		rnc.Close()
+		srv.Shutdown()
+		srv.WaitForShutdown()
+	}()
+
+	nc, err := nats.Connect(srv.ClientURL())
+	checkErr(t, err, "could not connect client to server @ %s: %v", srv.ClientURL(), err)
+	defer nc.Close()
+
+	cb(nc)
+}
+
+func TestAuditReplay(t *testing.T) {
+	withReplay(t, func(nc *nats.Conn) {
+		t.Run("STATSZ", func(t *testing.T) {
+			msg, err := nc.Request("$SYS.REQ.SERVER.PING", nil, time.Second)
+			checkErr(t, err, "request failed: %v", err)
+
+			var stats server.ServerStatsMsg
+			err = json.Unmarshal(msg.Data, &stats)
+			checkErr(t, err, "invalid response: %v", err)
+			if stats.Server.Cluster != "C1" || stats.Server.ID != "NID"+stats.Server.Name {
+				t.Fatalf("unexpected server: %+v", stats.Server)
+			}
+			if stats.Stats.ActiveServers != 2 || stats.Stats.Received.Msgs != 100 || stats.Stats.JetStream == nil {
+				t.Fatalf("unexpected statistics: %+v", stats.Stats)
+			}
+		})
+
+		t.Run("JSZ", func(t *testing.T) {
+			msg, err := nc.Request("$SYS.REQ.SERVER.PING.JSZ", nil, time.Second)
+			checkErr(t, err, "request failed: %v", err)
+
+			var response serverAPIResponseNoData
+			err = json.Unmarshal(msg.Data, &response)
+			checkErr(t, err, "invalid response: %v", err)
+			if response.Error != nil || response.Server == nil {
+				t.Fatalf("unexpected response: %s", msg.Data)
+			}
+
+			var jsz server.JSInfo
+			err = json.Unmarshal(response.Data, &jsz)
+			checkErr(t, err, "invalid JSZ: %v", err)
+			if jsz.ID != response.Server.ID || jsz.Streams != 1 || jsz.Messages != 5 {
+				t.Fatalf("unexpected JSZ: %s", response.Data)
+			}
+		})
+
+		t.Run("missing endpoint", func(t *testing.T) {
+			msg, err := nc.Request("$SYS.REQ.SERVER.NIDn2.LEAFZ", nil, time.Second)
+			checkErr(t, err, "request failed: %v", err)
+
+			var response serverAPIResponseNoData
+			err = json.Unmarshal(msg.Data, &response)
+			checkErr(t, err, "invalid response: %v", err)
+			if response.Error == nil || response.Error.Code != 404 || response.Server == nil || response.Server.Name != "n2" {
+				t.Fatalf("unexpected response: %s", msg.Data)
+			}
+		})
+
+		t.Run("STREAM.LIST", func(t *testing.T) {
+			msg, err := nc.Request("$JS.API.STREAM.LIST", nil, time.Second)
+			checkErr(t, err, "request failed: %v", err)
+
+			var response api.JSApiStreamListResponse
+			err = json.Unmarshal(msg.Data, &response)
+			checkErr(t, err, "invalid response: %v", err)
+			if response.IsError() || response.Total != 1 || len(response.Streams) != 1 || response.Streams[0].Config.Name != "ORDERS" {
+				t.Fatalf("unexpected response: %s", msg.Data)
+			}
+		})
+
+		t.Run("STREAM.INFO", func(t *testing.T) {
+			msg, err := nc.Request("$JS.API.STREAM.INFO.ORDERS", nil, time.Second)
+			checkErr(t, err, "request failed: %v", err)
+
+			var response api.JSApiStreamInfoResponse
+			err = json.Unmarshal(msg.Data, &response)
+			checkErr(t, err, "invalid response: %v", err)
+			if response.IsError() || response.StreamInfo == nil || response.State.Msgs != 5 || response.Cluster.Leader != "n1" {
+				t.Fatalf("unexpected response: %s", msg.Data)
+			}
+
+			msg, err = nc.Request("$JS.API.STREAM.INFO.MISSING", nil, time.Second)
+			checkErr(t, err, "request failed: %v", err)
+			err = json.Unmarshal(msg.Data, &response)
+			checkErr(t, err, "invalid response: %v", err)
+			if !response.IsError() || response.Error.Code != 404 {
+				t.Fatalf("expected stream not found, got: %s", msg.Data)
+			}
+		})
+
+		t.Run("CONSUMER.INFO", func(t *testing.T) {
+			msg, err := nc.Request("$JS.API.CONSUMER.INFO.ORDERS.PROCESSOR", nil, time.Second)
+			checkErr(t, err, "request failed: %v", err)
+
+			var response api.JSApiConsumerInfoResponse
+			err = json.Unmarshal(msg.Data, &response)
+			checkErr(t, err, "invalid response: %v", err)
+			if response.IsError() || response.ConsumerInfo == nil || response.Name != "PROCESSOR" || response.NumPending != 2 {
+				t.Fatalf("unexpected response: %s", msg.Data)
+			}
+		})
+	})
+}
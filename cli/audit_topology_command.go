@@ -0,0 +1,424 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/choria-io/fisk"
+	"github.com/emicklei/dot"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+type auditTopologyCmd struct {
+	archivePath string
+	outputFile  string
+	format      string
+	keys        auditArchiveKeys
+}
+
+const (
+	auditTopologyRoute    = "route"
+	auditTopologyGateway  = "gateway"
+	auditTopologyLeafnode = "leafnode"
+)
+
+// auditTopology is the graph of clusters, servers and the connections between them found in an archive
+type auditTopology struct {
+	clusters []*auditTopologyCluster
+	// external lists servers connected as leafnodes, that are not in the archive
+	external []string
+	links    []*auditTopologyLink
+}
+
+type auditTopologyCluster struct {
+	name    string
+	servers []string
+}
+
+// auditTopologyLink is a connection between two servers, or between two clusters for gateways
+type auditTopologyLink struct {
+	kind  string
+	from  string
+	to    string
+	label string
+	// problem describes what is wrong with the connection, empty if the connection is healthy
+	problem string
+}
+
+func configureAuditTopologyCommand(srv *fisk.CmdClause) {
+	c := &auditTopologyCmd{}
+
+	topologyHelp := `Routes are drawn between servers of the same cluster, gateways between
+clusters and leafnode connections from the spoke to the hub server.
+
+Problems are drawn in red: routes missing between two servers of the
+same cluster, gateways connected in one direction only (or from some
+of the cluster servers only).
+
+Render the graph with GraphViz:
+
+   nats audit topology archive.zip --output topology.dot
+   dot -Tsvg topology.dot > topology.svg
+`
+
+	topology := srv.Command("topology", "render the topology of the servers in an archive as a graph").Action(c.topology)
+	topology.HelpLong(topologyHelp)
+	topology.Arg("archive", "path to input archive").Required().ExistingFileVar(&c.archivePath)
+	topology.Flag("format", "Graph format (dot, mermaid)").Default("dot").EnumVar(&c.format, "dot", "mermaid")
+	topology.Flag("output", "Write the graph to a file instead of the console").Short('o').PlaceHolder("FILE").StringVar(&c.outputFile)
+	c.keys.configureFlags(topology)
+}
+
+func (c *auditTopologyCmd) topology(_ *fisk.ParseContext) error {
+	ar, err := c.keys.openArchive(c.archivePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := ar.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close archive reader: %s\n", err)
+		}
+	}()
+
+	topology, err := buildAuditTopology(ar)
+	if err != nil {
+		return err
+	}
+
+	var graph string
+	switch c.format {
+	case "mermaid":
+		graph = topology.mermaid()
+	default:
+		graph = topology.dot()
+	}
+
+	if c.outputFile != "" {
+		err = os.WriteFile(c.outputFile, []byte(graph), 0600)
+		if err != nil {
+			return fmt.Errorf("failed to write graph: %w", err)
+		}
+	} else {
+		fmt.Print(graph)
+	}
+
+	// Problems go to stderr, so the graph can be piped to a renderer
+	for _, link := range topology.links {
+		if link.problem != "" {
+			fmt.Fprintf(os.Stderr, "(!) %s %s - %s: %s\n", link.kind, link.from, link.to, link.problem)
+		}
+	}
+
+	return nil
+}
+
+// buildAuditTopology builds the topology graph from the routes, gateways and leafnodes artifacts in the archive
+func buildAuditTopology(ar *archive.Reader) (*auditTopology, error) {
+	t := &auditTopology{}
+	serverClusters := make(map[string]string)
+
+	for _, clusterName := range ar.GetClusterNames() {
+		serverNames := ar.GetClusterServerNames(clusterName)
+		sort.Strings(serverNames)
+		t.clusters = append(t.clusters, &auditTopologyCluster{name: clusterName, servers: serverNames})
+		for _, serverName := range serverNames {
+			serverClusters[serverName] = clusterName
+		}
+	}
+	sort.Slice(t.clusters, func(i, j int) bool { return t.clusters[i].name < t.clusters[j].name })
+
+	err := t.addRoutes(ar)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.addGateways(ar)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.addLeafnodes(ar, serverClusters)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// addRoutes links the servers of each cluster, and adds a missing link for each pair of servers that are not routed
+func (t *auditTopology) addRoutes(ar *archive.Reader) error {
+	// Remote server names each server has a route to, for servers with routes information
+	routes := make(map[string]map[string]struct{})
+	err := auditExportServerArtifacts(ar, nil, archive.TagServerRoutes(), func(_, serverName string, routez *server.Routez) {
+		routes[serverName] = make(map[string]struct{})
+		for _, route := range routez.Routes {
+			routes[serverName][route.RemoteName] = struct{}{}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	isRouted := func(from, to string) bool {
+		_, found := routes[from][to]
+		return found
+	}
+
+	for _, cluster := range t.clusters {
+		for i, serverName := range cluster.servers {
+			for _, otherServerName := range cluster.servers[i+1:] {
+				_, known := routes[serverName]
+				_, otherKnown := routes[otherServerName]
+
+				switch {
+				case isRouted(serverName, otherServerName) || isRouted(otherServerName, serverName):
+					t.links = append(t.links, &auditTopologyLink{kind: auditTopologyRoute, from: serverName, to: otherServerName})
+				case known && otherKnown:
+					t.links = append(t.links, &auditTopologyLink{kind: auditTopologyRoute, from: serverName, to: otherServerName, problem: "missing route"})
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// addGateways links clusters with a gateway connection from at least one of their servers, gateways that are not
+// connected from all servers, or not connected in both directions, are reported as problems
+func (t *auditTopology) addGateways(ar *archive.Reader) error {
+	// Number of servers with an outbound connection, for each source and target cluster
+	connected := make(map[string]map[string]int)
+	// Number of servers with gateways information, for each cluster
+	reporting := make(map[string]int)
+
+	err := auditExportServerArtifacts(ar, nil, archive.TagServerGateways(), func(clusterName, _ string, gatewayz *server.Gatewayz) {
+		reporting[clusterName] += 1
+		if connected[clusterName] == nil {
+			connected[clusterName] = make(map[string]int)
+		}
+		for gatewayName, gateway := range gatewayz.OutboundGateways {
+			if gatewayName == clusterName {
+				continue
+			}
+			if gateway.Connection != nil {
+				connected[clusterName][gatewayName] += 1
+			} else if _, seen := connected[clusterName][gatewayName]; !seen {
+				// Configured, but not connected
+				connected[clusterName][gatewayName] = 0
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, clusterName := range sortedMapKeys(connected) {
+		for _, gatewayName := range sortedMapKeys(connected[clusterName]) {
+			servers := connected[clusterName][gatewayName]
+			link := &auditTopologyLink{
+				kind:  auditTopologyGateway,
+				from:  clusterName,
+				to:    gatewayName,
+				label: fmt.Sprintf("%d/%d", servers, reporting[clusterName]),
+			}
+
+			_, targetKnown := connected[gatewayName]
+			switch {
+			case servers == 0:
+				link.problem = "not connected"
+			case servers < reporting[clusterName]:
+				link.problem = fmt.Sprintf("connected from %d of %d servers", servers, reporting[clusterName])
+			case targetKnown && connected[gatewayName][clusterName] == 0:
+				link.problem = "asymmetric, no connection in the opposite direction"
+			}
+
+			t.links = append(t.links, link)
+		}
+	}
+
+	return nil
+}
+
+// addLeafnodes links spoke servers to their hub, each connection is reported by both ends if they are both in the
+// archive
+func (t *auditTopology) addLeafnodes(ar *archive.Reader, serverClusters map[string]string) error {
+	seen := make(map[string]struct{})
+	external := make(map[string]struct{})
+
+	err := auditExportServerArtifacts(ar, nil, archive.TagServerLeafs(), func(_, serverName string, leafz *server.Leafz) {
+		for _, leaf := range leafz.Leafs {
+			spoke, hub := leaf.Name, serverName
+			if leaf.IsSpoke {
+				spoke, hub = serverName, leaf.Name
+			}
+
+			key := spoke + ">" + hub + ">" + leaf.Account
+			if _, found := seen[key]; found {
+				continue
+			}
+			seen[key] = struct{}{}
+
+			if _, known := serverClusters[leaf.Name]; !known {
+				external[leaf.Name] = struct{}{}
+			}
+			t.links = append(t.links, &auditTopologyLink{kind: auditTopologyLeafnode, from: spoke, to: hub, label: leaf.Account})
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	t.external = sortedMapKeys(external)
+
+	return nil
+}
+
+// dot renders the topology as a GraphViz graph, clusters are drawn as boxes around their servers
+func (t *auditTopology) dot() string {
+	dg := dot.NewGraph(dot.Directed)
+	dg.Label("NATS Topology")
+	// Allow gateway edges between clusters
+	dg.Attr("compound", "true")
+
+	clusterGraphs := make(map[string]*dot.Graph)
+	clusterAnchors := make(map[string]dot.Node)
+	for _, cluster := range t.clusters {
+		cg := dg.Subgraph(cluster.name, dot.ClusterOption{})
+		cg.Label(cluster.name)
+		clusterGraphs[cluster.name] = cg
+		for _, serverName := range cluster.servers {
+			node := cg.Node(serverName).Box()
+			if _, found := clusterAnchors[cluster.name]; !found {
+				clusterAnchors[cluster.name] = node
+			}
+		}
+	}
+	for _, serverName := range t.external {
+		dg.Node(serverName).Box().Attr("style", "dashed")
+	}
+
+	for _, link := range t.links {
+		var edge dot.Edge
+		switch link.kind {
+		case auditTopologyGateway:
+			from, fromFound := clusterAnchors[link.from]
+			to, toFound := clusterAnchors[link.to]
+			if !toFound {
+				// Gateway to a cluster not in the archive
+				to = dg.Node(link.to).Attr("shape", "doubleoctagon")
+			}
+			if !fromFound {
+				continue
+			}
+			edge = dg.Edge(from, to).Attr("ltail", clusterGraphs[link.from].GetID()).Attr("color", "blue")
+			if toFound {
+				edge.Attr("lhead", clusterGraphs[link.to].GetID())
+			}
+		case auditTopologyRoute:
+			from, _ := dg.FindNodeById(link.from)
+			to, _ := dg.FindNodeById(link.to)
+			edge = dg.Edge(from, to).Attr("dir", "none")
+		case auditTopologyLeafnode:
+			from, _ := dg.FindNodeById(link.from)
+			to, _ := dg.FindNodeById(link.to)
+			edge = dg.Edge(from, to).Attr("color", "darkgreen")
+		}
+
+		label := link.label
+		if link.problem != "" {
+			edge.Attr("color", "red").Attr("fontcolor", "red").Dashed()
+			label = strings.TrimSpace(label + " " + link.problem)
+		}
+		if label != "" {
+			edge.Label(label)
+		}
+	}
+
+	return dg.String()
+}
+
+// mermaid renders the topology as a Mermaid flowchart, clusters are drawn as subgraphs
+func (t *auditTopology) mermaid() string {
+	sb := new(strings.Builder)
+	sb.WriteString("flowchart LR\n")
+
+	ids := make(map[string]string)
+	nodeId := func(name string) string {
+		id, found := ids[name]
+		if !found {
+			id = fmt.Sprintf("n%d", len(ids)+1)
+			ids[name] = id
+		}
+		return id
+	}
+	clusterIds := make(map[string]string)
+
+	for i, cluster := range t.clusters {
+		clusterIds[cluster.name] = fmt.Sprintf("c%d", i+1)
+		fmt.Fprintf(sb, "  subgraph %s[%q]\n", clusterIds[cluster.name], cluster.name)
+		for _, serverName := range cluster.servers {
+			fmt.Fprintf(sb, "    %s[%q]\n", nodeId(serverName), serverName)
+		}
+		sb.WriteString("  end\n")
+	}
+	for _, serverName := range t.external {
+		fmt.Fprintf(sb, "  %s([%q])\n", nodeId(serverName), serverName)
+	}
+
+	var problemLinks []string
+	for i, link := range t.links {
+		var from, to, arrow string
+		switch link.kind {
+		case auditTopologyGateway:
+			var found bool
+			from, arrow = clusterIds[link.from], "==>"
+			to, found = clusterIds[link.to]
+			if !found {
+				// Gateway to a cluster not in the archive
+				to = nodeId(link.to)
+				fmt.Fprintf(sb, "  %s{{%q}}\n", to, link.to)
+			}
+		case auditTopologyRoute:
+			from, to, arrow = nodeId(link.from), nodeId(link.to), "---"
+			if link.problem != "" {
+				arrow = "-.-"
+			}
+		case auditTopologyLeafnode:
+			from, to, arrow = nodeId(link.from), nodeId(link.to), "-->"
+		}
+
+		label := strings.TrimSpace(link.label + " " + link.problem)
+		if label != "" {
+			fmt.Fprintf(sb, "  %s %s|%q| %s\n", from, arrow, label, to)
+		} else {
+			fmt.Fprintf(sb, "  %s %s %s\n", from, arrow, to)
+		}
+
+		if link.problem != "" {
+			problemLinks = append(problemLinks, fmt.Sprint(i))
+		}
+	}
+
+	if len(problemLinks) > 0 {
+		fmt.Fprintf(sb, "  linkStyle %s stroke:red,color:red\n", strings.Join(problemLinks, ","))
+	}
+
+	return sb.String()
+}
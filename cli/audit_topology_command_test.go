@@ -0,0 +1,108 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/archive"
+)
+
+func TestAuditTopology(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "archive.zip")
+	aw, err := archive.NewWriter(archivePath)
+	checkErr(t, err, "could not create archive: %v", err)
+
+	add := func(artifact any, clusterName, serverName string, typeTag *archive.Tag) {
+		t.Helper()
+		err := aw.Add(artifact, archive.TagCluster(clusterName), archive.TagServer(serverName), typeTag)
+		checkErr(t, err, "could not add artifact: %v", err)
+	}
+	routez := func(remoteNames ...string) *server.Routez {
+		routes := &server.Routez{}
+		for _, remoteName := range remoteNames {
+			routes.Routes = append(routes.Routes, &server.RouteInfo{RemoteName: remoteName})
+		}
+		return routes
+	}
+	gatewayz := func(clusterName string, connected map[string]bool) *server.Gatewayz {
+		gateways := &server.Gatewayz{Name: clusterName, OutboundGateways: make(map[string]*server.RemoteGatewayz)}
+		for gatewayName, isConnected := range connected {
+			gateway := &server.RemoteGatewayz{IsConfigured: true}
+			if isConnected {
+				gateway.Connection = &server.ConnInfo{}
+			}
+			gateways.OutboundGateways[gatewayName] = gateway
+		}
+		return gateways
+	}
+
+	// n1 and n3 are not routed
+	add(routez("n2"), "C1", "n1", archive.TagServerRoutes())
+	add(routez("n1", "n3"), "C1", "n2", archive.TagServerRoutes())
+	add(routez("n2"), "C1", "n3", archive.TagServerRoutes())
+	// C1 connects to C2 from all servers, C2 does not connect back
+	for _, serverName := range []string{"n1", "n2", "n3"} {
+		add(gatewayz("C1", map[string]bool{"C2": true}), "C1", serverName, archive.TagServerGateways())
+	}
+	add(gatewayz("C2", map[string]bool{"C1": false}), "C2", "m1", archive.TagServerGateways())
+	// Leafnode from edge (not in archive) to n1, and from m1 to n2 (reported by both)
+	add(&server.Leafz{Leafs: []*server.LeafInfo{{Name: "edge", Account: "ACME"}}}, "C1", "n1", archive.TagServerLeafs())
+	add(&server.Leafz{Leafs: []*server.LeafInfo{{Name: "m1", Account: "SYS"}}}, "C1", "n2", archive.TagServerLeafs())
+	add(&server.Leafz{Leafs: []*server.LeafInfo{{Name: "n2", Account: "SYS", IsSpoke: true}}}, "C2", "m1", archive.TagServerLeafs())
+
+	err = aw.Close()
+	checkErr(t, err, "could not close archive: %v", err)
+
+	ar, err := archive.NewReader(archivePath)
+	checkErr(t, err, "could not open archive: %v", err)
+	defer ar.Close()
+
+	topology, err := buildAuditTopology(ar)
+	checkErr(t, err, "could not build topology: %v", err)
+
+	links := []string{}
+	for _, link := range topology.links {
+		links = append(links, strings.TrimSpace(strings.Join([]string{link.kind, link.from, link.to, link.label, link.problem}, " ")))
+	}
+	expected := []string{
+		"route n1 n2",
+		"route n1 n3  missing route",
+		"route n2 n3",
+		"gateway C1 C2 3/3 asymmetric, no connection in the opposite direction",
+		"gateway C2 C1 0/1 not connected",
+		"leafnode edge n1 ACME",
+		"leafnode m1 n2 SYS",
+	}
+	if diff := cmp.Diff(expected, links); diff != "" {
+		t.Fatalf("unexpected links (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"edge"}, topology.external); diff != "" {
+		t.Fatalf("unexpected external servers (-want +got):\n%s", diff)
+	}
+
+	dotGraph := topology.dot()
+	if !strings.Contains(dotGraph, `label="missing route"`) || !strings.Contains(dotGraph, "lhead=") {
+		t.Fatalf("unexpected dot graph:\n%s", dotGraph)
+	}
+
+	mermaidGraph := topology.mermaid()
+	if !strings.Contains(mermaidGraph, `n1 -.-|"missing route"| n3`) || !strings.Contains(mermaidGraph, "linkStyle 1,3,4 stroke:red") {
+		t.Fatalf("unexpected mermaid graph:\n%s", mermaidGraph)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/natscli/archive"
+)
+
+type auditVerifyCmd struct {
+	archivePath string
+	keys        auditArchiveKeys
+}
+
+func configureAuditVerifyCommand(srv *fisk.CmdClause) {
+	c := &auditVerifyCmd{}
+
+	verify := srv.Command("verify", "check the integrity of an archive created by the 'gather' subcommand").Action(c.verify)
+	verify.Arg("archive", "path to input archive to verify").Required().ExistingFileVar(&c.archivePath)
+	c.keys.configureFlags(verify)
+}
+
+func (c *auditVerifyCmd) verify(_ *fisk.ParseContext) error {
+	opts, wipe, err := c.keys.readerOptions()
+	if err != nil {
+		return err
+	}
+	defer wipe()
+
+	report, err := archive.VerifyArchive(c.archivePath, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to verify %s: %w", c.archivePath, err)
+	}
+
+	if report.Signer != "" {
+		fmt.Printf("Archive signature verified, signed by %s\n", report.Signer)
+	}
+	if report.Recovered {
+		fmt.Printf("(!) Archive is incomplete, the writer was interrupted before closing it\n")
+	}
+
+	fmt.Printf("Verified %d/%d artifacts\n", report.Verified, report.Artifacts)
+
+	issues := []struct {
+		description string
+		names       []string
+	}{
+		{"Artifacts without checksum (readable, but not verified)", report.Unchecked},
+		{"Artifacts listed in the manifest, but missing from the archive", report.Missing},
+		{"Corrupted artifacts", report.Corrupted},
+		{"Files not listed in the manifest", report.Orphans},
+	}
+	for _, issue := range issues {
+		if len(issue.names) == 0 {
+			continue
+		}
+		fmt.Printf("%s: %d\n", issue.description, len(issue.names))
+		for _, name := range issue.names {
+			fmt.Println("   - " + name)
+		}
+	}
+
+	c.printMetadata(opts)
+
+	if !report.Ok() {
+		return fmt.Errorf("archive %s failed verification", c.archivePath)
+	}
+
+	fmt.Printf("\nArchive %s is intact\n", c.archivePath)
+	return nil
+}
+
+// printMetadata prints the capture metadata, if the archive can be opened
+func (c *auditVerifyCmd) printMetadata(opts []archive.ReaderOption) {
+	ar, err := archive.NewReader(c.archivePath, opts...)
+	if err != nil {
+		fmt.Printf("Capture metadata unavailable: %s\n", err)
+		return
+	}
+	defer ar.Close()
+
+	var metadata auditMetadata
+	err = ar.Load(&metadata, archive.TagSpecial("audit_gather_metadata"))
+	if err != nil {
+		fmt.Printf("Capture metadata unavailable: %s\n", err)
+		return
+	}
+
+	fmt.Printf("\nCapture metadata:\n")
+	fmt.Printf("   Captured: %s\n", metadata.Timestamp.Format(time.RFC3339))
+	fmt.Printf("   Connected to: %s (%s, version %s)\n", metadata.ConnectedServerName, metadata.ConnectURL, metadata.ConnectedServerVersion)
+	fmt.Printf("   User: %s\n", metadata.UserName)
+	fmt.Printf("   CLI version: %s\n", metadata.CLIVersion)
+	if len(metadata.GatherScope) > 0 {
+		fmt.Printf("   Limited to: %s\n", strings.Join(metadata.GatherScope, ", "))
+	}
+	if len(metadata.GatherFailures) > 0 {
+		fmt.Printf("   Failed artifacts: %d\n", len(metadata.GatherFailures))
+		for _, failure := range metadata.GatherFailures {
+			fmt.Printf("      %s\n", failure)
+		}
+	}
+}
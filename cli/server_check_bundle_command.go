@@ -0,0 +1,260 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/choria-io/fisk"
+	"github.com/ghodss/yaml"
+	"github.com/nats-io/natscli/monitor"
+)
+
+// srvCheckBundleFile is the format of a YAML file of checks to run together, for example:
+//
+//	name: production
+//	checks:
+//	  - name: connection
+//	    check: connection
+//	  - name: orders
+//	    check: stream
+//	    options:
+//	      stream: ORDERS
+//	      peer-expect: 3
+//	      msgs-warn: 10
+//
+// Options are the flags of the corresponding check subcommand, without the leading dashes.
+type srvCheckBundleFile struct {
+	Name   string                      `json:"name"`
+	Checks []srvCheckBundleEntryConfig `json:"checks"`
+}
+
+// srvCheckBundleEntryConfig is the definition of a check in a bundle
+type srvCheckBundleEntryConfig struct {
+	Name    string         `json:"name"`
+	Check   string         `json:"check"`
+	Options map[string]any `json:"options"`
+}
+
+// srvCheckBundleEntry is a check of a bundle, with its options parsed and ready to run
+type srvCheckBundleEntry struct {
+	name string
+	kind *srvCheckKind
+	cmd  *SrvCheckCmd
+}
+
+type srvCheckBundleCmd struct {
+	configFile string
+}
+
+func configureServerCheckBundleCommand(check *fisk.CmdClause) {
+	c := &srvCheckBundleCmd{}
+
+	bundle := check.Command("bundle", "Runs many checks defined in a configuration file, reporting a single result").Action(c.bundleAction)
+	bundle.Flag("config", "YAML file listing the checks to run and their options").Required().PlaceHolder("FILE").ExistingFileVar(&c.configFile)
+}
+
+func (c *srvCheckBundleCmd) bundleAction(_ *fisk.ParseContext) error {
+	check := &monitor.Result{Name: "Check Bundle", Check: "bundle", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
+	defer check.GenericExit()
+
+	name, entries, err := loadSrvCheckBundle(c.configFile)
+	check.CriticalIfErr(err, "%s", err)
+	if name != "" {
+		check.Name = name
+	}
+
+	runSrvCheckBundle(check, entries)
+
+	return nil
+}
+
+// runSrvCheckBundle runs all checks of a bundle, adding their outcome to the bundle result
+func runSrvCheckBundle(check *monitor.Result, entries []*srvCheckBundleEntry) {
+	for _, entry := range entries {
+		mergeSrvCheckBundleResult(check, entry.name, entry.run())
+	}
+}
+
+// loadSrvCheckBundle loads the bundle name and the checks defined in the given YAML file
+func loadSrvCheckBundle(configFile string) (string, []*srvCheckBundleEntry, error) {
+	content, err := os.ReadFile(configFile)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var bundle srvCheckBundleFile
+	err = yaml.Unmarshal(content, &bundle)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid check bundle %s: %w", configFile, err)
+	}
+
+	if len(bundle.Checks) == 0 {
+		return "", nil, fmt.Errorf("no checks defined in %s", configFile)
+	}
+
+	// names are also the prefix of the performance data names, so they must be unique once converted to metric names
+	names := make(map[string]string)
+	entries := make([]*srvCheckBundleEntry, 0, len(bundle.Checks))
+	for i, config := range bundle.Checks {
+		entry, err := newSrvCheckBundleEntry(config)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid check #%d in %s: %w", i+1, configFile, err)
+		}
+		metricName := srvCheckMetricName(entry.name)
+		if name, ok := names[metricName]; ok {
+			if name == entry.name {
+				return "", nil, fmt.Errorf("invalid check #%d in %s: duplicate name '%s'", i+1, configFile, entry.name)
+			}
+			return "", nil, fmt.Errorf("invalid check #%d in %s: name '%s' has the same metric name %s as '%s'", i+1, configFile, entry.name, metricName, name)
+		}
+		names[metricName] = entry.name
+		entries = append(entries, entry)
+	}
+
+	return bundle.Name, entries, nil
+}
+
+// newSrvCheckBundleEntry parses the options of a check using the flags of the corresponding check subcommand, so
+// that defaults, required options and validation are the same as when running the check on its own
+func newSrvCheckBundleEntry(config srvCheckBundleEntryConfig) (*srvCheckBundleEntry, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+
+	var kind *srvCheckKind
+	for _, k := range srvCheckKinds {
+		if config.Check == k.name || (k.alias != "" && config.Check == k.alias) {
+			kind = k
+			break
+		}
+	}
+	if kind == nil {
+		return nil, fmt.Errorf("check '%s' has unknown type '%s'", config.Name, config.Check)
+	}
+
+	entry := &srvCheckBundleEntry{
+		name: config.Name,
+		kind: kind,
+		cmd:  &SrvCheckCmd{},
+	}
+
+	app := fisk.New("bundle", "").Writer(io.Discard).ErrorWriter(io.Discard).UsageWriter(io.Discard)
+	cmd := app.Command(kind.name, kind.help)
+	kind.flags(entry.cmd, cmd)
+
+	args, err := srvCheckBundleArgs(cmd, config.Options)
+	if err != nil {
+		return nil, fmt.Errorf("check '%s' has invalid options: %w", config.Name, err)
+	}
+
+	_, err = app.Parse(append([]string{kind.name}, args...))
+	if err != nil {
+		return nil, fmt.Errorf("check '%s' has invalid options: %w", config.Name, err)
+	}
+
+	return entry, nil
+}
+
+// srvCheckBundleArgs converts check options to command line flags, sorted by option name
+func srvCheckBundleArgs(cmd *fisk.CmdClause, options map[string]any) ([]string, error) {
+	flags := make(map[string]*fisk.FlagModel)
+	for _, flag := range cmd.Model().Flags {
+		flags[flag.Name] = flag
+	}
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		flag, ok := flags[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown option '%s'", name)
+		}
+
+		switch value := options[name].(type) {
+		case bool:
+			if !flag.IsBoolFlag() {
+				return nil, fmt.Errorf("option '%s' is not a boolean", name)
+			}
+			switch {
+			case value:
+				args = append(args, "--"+name)
+			case flag.IsNegatable():
+				args = append(args, "--no-"+name)
+			}
+
+		case []any:
+			for _, v := range value {
+				args = append(args, fmt.Sprintf("--%s=%s", name, srvCheckBundleValue(v)))
+			}
+
+		default:
+			args = append(args, fmt.Sprintf("--%s=%s", name, srvCheckBundleValue(value)))
+		}
+	}
+
+	return args, nil
+}
+
+// srvCheckBundleValue formats an option value as a flag value, numbers are not formatted in exponent notation
+func srvCheckBundleValue(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// run performs the check, errors preventing the check from completing are reported as critical
+func (e *srvCheckBundleEntry) run() *monitor.Result {
+	result := e.kind.result(e.cmd)
+
+	err := e.kind.run(e.cmd, result)
+	if err != nil {
+		result.Critical("%s", err)
+	}
+
+	return result
+}
+
+// mergeSrvCheckBundleResult adds the outcome of a check to the bundle result, messages are prefixed with the check
+// name and performance data names with the check name converted to a valid metric name
+func mergeSrvCheckBundleResult(bundle *monitor.Result, name string, result *monitor.Result) {
+	for _, msg := range result.Criticals {
+		bundle.Critical("%s: %s", name, msg)
+	}
+	for _, msg := range result.Warnings {
+		bundle.Warn("%s: %s", name, msg)
+	}
+	for _, msg := range result.OKs {
+		bundle.Ok("%s: %s", name, msg)
+	}
+
+	prefix := srvCheckMetricName(name)
+	for _, pd := range result.PerfData {
+		item := *pd
+		item.Name = prefix + "_" + pd.Name
+		bundle.Pd(&item)
+	}
+}
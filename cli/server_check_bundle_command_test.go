@@ -0,0 +1,188 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/jsm.go"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/natscli/monitor"
+)
+
+func TestSrvCheckBundleEntry(t *testing.T) {
+	t.Run("Defaults and options", func(t *testing.T) {
+		entry, err := newSrvCheckBundleEntry(srvCheckBundleEntryConfig{
+			Name:  "orders",
+			Check: "stream",
+			Options: map[string]any{
+				"stream":      "ORDERS",
+				"peer-expect": float64(3),
+				"msgs-warn":   float64(1000000),
+			},
+		})
+		checkErr(t, err, "unexpected error: %v", err)
+
+		if entry.kind.name != "stream" {
+			t.Fatalf("expected stream check, got %s", entry.kind.name)
+		}
+		if entry.cmd.sourcesStream != "ORDERS" || entry.cmd.raftExpect != 3 || entry.cmd.sourcesMessagesWarn != 1000000 {
+			t.Fatalf("options not set: %+v", entry.cmd)
+		}
+		if entry.cmd.raftSeenCritical != 10*time.Second || entry.cmd.subjectsWarn != -1 {
+			t.Fatalf("defaults not set: %+v", entry.cmd)
+		}
+	})
+
+	t.Run("Alias and booleans", func(t *testing.T) {
+		entry, err := newSrvCheckBundleEntry(srvCheckBundleEntryConfig{
+			Name:    "js",
+			Check:   "js",
+			Options: map[string]any{"replicas": false, "mem-warn": float64(50)},
+		})
+		checkErr(t, err, "unexpected error: %v", err)
+
+		if entry.kind.name != "jetstream" || entry.cmd.jsReplicas || entry.cmd.jsMemWarn != 50 || entry.cmd.jsMemCritical != 90 {
+			t.Fatalf("unexpected check: %s %+v", entry.kind.name, entry.cmd)
+		}
+	})
+
+	for _, tc := range []struct {
+		name   string
+		config srvCheckBundleEntryConfig
+		err    string
+	}{
+		{"Missing name", srvCheckBundleEntryConfig{Check: "connection"}, "name is required"},
+		{"Unknown check", srvCheckBundleEntryConfig{Name: "x", Check: "foo"}, "unknown type 'foo'"},
+		{"Unknown option", srvCheckBundleEntryConfig{Name: "x", Check: "connection", Options: map[string]any{"foo": "1s"}}, "unknown option 'foo'"},
+		{"Not a boolean", srvCheckBundleEntryConfig{Name: "x", Check: "kv", Options: map[string]any{"bucket": true}}, "'bucket' is not a boolean"},
+		{"Required option", srvCheckBundleEntryConfig{Name: "x", Check: "kv"}, "required flag --bucket not provided"},
+		{"Invalid value", srvCheckBundleEntryConfig{Name: "x", Check: "connection", Options: map[string]any{"rtt-warn": "soon"}}, "invalid options"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := newSrvCheckBundleEntry(tc.config)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
+
+func TestMergeSrvCheckBundleResult(t *testing.T) {
+	bundle := &monitor.Result{Name: "Check Bundle", Check: "bundle"}
+
+	mergeSrvCheckBundleResult(bundle, "conn", &monitor.Result{
+		OKs:      []string{"connected"},
+		PerfData: monitor.PerfData{{Name: "rtt", Value: 1, Unit: "s"}},
+	})
+	mergeSrvCheckBundleResult(bundle, "orders stream", &monitor.Result{
+		Warnings:  []string{"10 messages"},
+		Criticals: []string{"not clustered"},
+		PerfData:  monitor.PerfData{{Name: "messages", Value: 10, Warn: 100}},
+	})
+
+	if len(bundle.OKs) != 1 || bundle.OKs[0] != "conn: connected" {
+		t.Fatalf("unexpected oks: %v", bundle.OKs)
+	}
+	if len(bundle.Warnings) != 1 || bundle.Warnings[0] != "orders stream: 10 messages" {
+		t.Fatalf("unexpected warnings: %v", bundle.Warnings)
+	}
+	if len(bundle.Criticals) != 1 || bundle.Criticals[0] != "orders stream: not clustered" {
+		t.Fatalf("unexpected criticals: %v", bundle.Criticals)
+	}
+	if len(bundle.PerfData) != 2 || bundle.PerfData[0].Name != "conn_rtt" || bundle.PerfData[1].Name != "orders_stream_messages" || bundle.PerfData[1].Warn != 100 {
+		t.Fatalf("unexpected perf data: %+v", bundle.PerfData)
+	}
+}
+
+func TestSrvCheckBundle(t *testing.T) {
+	withJetStream(t, func(_ *server.Server, nc *nats.Conn, mgr *jsm.Manager) {
+		opts.Conn = nc
+		opts.Mgr = mgr
+		defer func() { opts.Conn, opts.Mgr = nil, nil }()
+
+		js, err := nc.JetStream()
+		checkErr(t, err, "js context failed: %v", err)
+		_, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: "TEST"})
+		checkErr(t, err, "kv create failed: %v", err)
+
+		config := filepath.Join(t.TempDir(), "checks.yaml")
+		err = os.WriteFile(config, []byte(`name: test
+checks:
+  - name: bucket
+    check: kv
+    options:
+      bucket: TEST
+  - name: missing
+    check: kv
+    options:
+      bucket: MISSING
+`), 0600)
+		checkErr(t, err, "write failed: %v", err)
+
+		name, entries, err := loadSrvCheckBundle(config)
+		checkErr(t, err, "load failed: %v", err)
+		if name != "test" || len(entries) != 2 {
+			t.Fatalf("unexpected bundle: %s %d", name, len(entries))
+		}
+
+		bundle := &monitor.Result{Name: name, Check: "bundle"}
+		for _, entry := range entries {
+			mergeSrvCheckBundleResult(bundle, entry.name, entry.run())
+		}
+
+		if len(bundle.OKs) != 1 || bundle.OKs[0] != "bucket: bucket TEST" {
+			t.Fatalf("unexpected oks: %v", bundle.OKs)
+		}
+		if len(bundle.Criticals) != 1 || bundle.Criticals[0] != "missing: bucket MISSING not found" {
+			t.Fatalf("unexpected criticals: %v", bundle.Criticals)
+		}
+		if len(bundle.PerfData) == 0 || bundle.PerfData[0].Name != "bucket_values" {
+			t.Fatalf("unexpected perf data: %+v", bundle.PerfData)
+		}
+	})
+
+	t.Run("Duplicate names", func(t *testing.T) {
+		config := filepath.Join(t.TempDir(), "checks.yaml")
+		err := os.WriteFile(config, []byte(`checks:
+  - {name: conn, check: connection}
+  - {name: conn, check: connection}
+`), 0600)
+		checkErr(t, err, "write failed: %v", err)
+
+		_, _, err = loadSrvCheckBundle(config)
+		if err == nil || !strings.Contains(err.Error(), "duplicate name 'conn'") {
+			t.Fatalf("expected duplicate name error, got %v", err)
+		}
+	})
+
+	t.Run("Colliding metric names", func(t *testing.T) {
+		config := filepath.Join(t.TempDir(), "checks.yaml")
+		err := os.WriteFile(config, []byte(`checks:
+  - {name: a-b, check: connection}
+  - {name: a.b, check: connection}
+`), 0600)
+		checkErr(t, err, "write failed: %v", err)
+
+		_, _, err = loadSrvCheckBundle(config)
+		if err == nil || !strings.Contains(err.Error(), "name 'a.b' has the same metric name a_b as 'a-b'") {
+			t.Fatalf("expected colliding name error, got %v", err)
+		}
+	})
+}
@@ -105,6 +105,188 @@ type SrvCheckCmd struct {
 	credentialValidityWarn   time.Duration
 	credentialRequiresExpire bool
 	credential               string
+
+	leafServerName      string
+	leafExpect          []string
+	leafCountWarn       int
+	leafCountCrit       int
+	leafRTTWarn         time.Duration
+	leafRTTCrit         time.Duration
+	leafImbalanceWarn   int
+	leafImbalanceCrit   int
+	leafReconnectWindow time.Duration
+	leafReconnectsWarn  int
+	leafReconnectsCrit  int
+
+	gwCluster string
+	gwExpect  []string
+	gwRTTWarn time.Duration
+	gwRTTCrit time.Duration
+
+	svcName           string
+	svcInstancesWarn  int
+	svcInstancesCrit  int
+	svcRTTWarn        time.Duration
+	svcRTTCrit        time.Duration
+	svcErrorsWarn     int
+	svcErrorsCrit     int
+	svcErrorRateWarn  float64
+	svcErrorRateCrit  float64
+	svcProcessingWarn time.Duration
+	svcProcessingCrit time.Duration
+
+	tlsTargets      []string
+	tlsHostname     string
+	tlsExpireWarn   time.Duration
+	tlsExpireCrit   time.Duration
+	tlsMinRSABits   int
+	tlsMinECDSABits int
+}
+
+// srvCheckKind is a check that can be run on its own as a subcommand of check, or as part of a bundle of checks
+type srvCheckKind struct {
+	name  string
+	alias string
+	help  string
+	// flags configures the check options, the same options are used in bundle configuration files
+	flags func(c *SrvCheckCmd, cmd *fisk.CmdClause)
+	// result creates the result the check reports into
+	result func(c *SrvCheckCmd) *monitor.Result
+	// run performs the check, errors preventing the check from completing are reported as critical
+	run func(c *SrvCheckCmd, check *monitor.Result) error
+	// nagiosOnly renders the result of the check subcommand in nagios format to STDOUT, ignoring --format and --outfile
+	nagiosOnly bool
+}
+
+var srvCheckKinds = []*srvCheckKind{
+	{
+		name:   "connection",
+		alias:  "conn",
+		help:   "Checks basic server connection",
+		flags:  (*SrvCheckCmd).connectionCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: "Connection", Check: "connections"} },
+		run:    (*SrvCheckCmd).checkConnection,
+	},
+	{
+		name:   "stream",
+		help:   "Checks the health of mirrored streams, streams with sources or clustered streams",
+		flags:  (*SrvCheckCmd).streamCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: c.sourcesStream, Check: "stream"} },
+		run:    (*SrvCheckCmd).checkStream,
+	},
+	{
+		name:  "consumer",
+		help:  "Checks the health of a consumer",
+		flags: (*SrvCheckCmd).consumerCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result {
+			return &monitor.Result{Name: fmt.Sprintf("%s_%s", c.sourcesStream, c.consumerName), Check: "consumer"}
+		},
+		run: (*SrvCheckCmd).checkConsumer,
+	},
+	{
+		name:   "message",
+		help:   "Checks properties of a message stored in a stream",
+		flags:  (*SrvCheckCmd).messageCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: "Stream Message", Check: "message"} },
+		run:    (*SrvCheckCmd).checkMsg,
+	},
+	{
+		name:  "meta",
+		alias: "raft",
+		help:  "Check JetStream cluster state",
+		flags: (*SrvCheckCmd).metaCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result {
+			return &monitor.Result{Name: "JetStream Meta Cluster", Check: "meta"}
+		},
+		run:        (*SrvCheckCmd).checkRaft,
+		nagiosOnly: true,
+	},
+	{
+		name:   "jetstream",
+		alias:  "js",
+		help:   "Check JetStream account state",
+		flags:  (*SrvCheckCmd).jetStreamCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: "JetStream", Check: "jetstream"} },
+		run:    (*SrvCheckCmd).checkJS,
+	},
+	{
+		name:   "server",
+		help:   "Checks a NATS Server health",
+		flags:  (*SrvCheckCmd).serverCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: c.srvName, Check: "server"} },
+		run:    (*SrvCheckCmd).checkSrv,
+	},
+	{
+		name:   "kv",
+		help:   "Checks a NATS KV Bucket",
+		flags:  (*SrvCheckCmd).kvCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: c.kvBucket, Check: "kv"} },
+		run:    (*SrvCheckCmd).checkKV,
+	},
+	{
+		name:   "credential",
+		help:   "Checks the validity of a NATS credential file",
+		flags:  (*SrvCheckCmd).credentialCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: "Credential", Check: "credential"} },
+		run:    (*SrvCheckCmd).checkCredential,
+	},
+	{
+		name:   "leafnode",
+		alias:  "leaf",
+		help:   "Checks the leafnode connections of a server, or of all servers",
+		flags:  (*SrvCheckCmd).leafnodeCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: "Leafnodes", Check: "leafnode"} },
+		run:    (*SrvCheckCmd).checkLeafnode,
+	},
+	{
+		name:   "gateway",
+		alias:  "gw",
+		help:   "Checks the gateway connections between clusters of a super-cluster",
+		flags:  (*SrvCheckCmd).gatewayCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: "Gateways", Check: "gateway"} },
+		run:    (*SrvCheckCmd).checkGateway,
+	},
+	{
+		name:   "service",
+		alias:  "svc",
+		help:   "Checks the health of a service built using the NATS micro framework",
+		flags:  (*SrvCheckCmd).serviceCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: c.svcName, Check: "service"} },
+		run:    (*SrvCheckCmd).checkService,
+	},
+	{
+		name:   "tls",
+		help:   "Checks the TLS certificates presented by servers",
+		flags:  (*SrvCheckCmd).tlsCheckFlags,
+		result: func(c *SrvCheckCmd) *monitor.Result { return &monitor.Result{Name: "TLS", Check: "tls"} },
+		run:    (*SrvCheckCmd).checkTLS,
+	},
+}
+
+var srvCheckMetricNameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_]+`)
+
+// srvCheckMetricName converts a name to a valid perf data name
+func srvCheckMetricName(name string) string {
+	return srvCheckMetricNameInvalidChars.ReplaceAllString(name, "_")
+}
+
+// srvCheckMetricNames converts names to unique perf data names, a name converting to the same perf data name as an
+// earlier name gets a numeric suffix
+func srvCheckMetricNames(names []string) map[string]string {
+	metricNames := make(map[string]string, len(names))
+	used := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		metricName := srvCheckMetricName(name)
+		for i := 2; ; i++ {
+			if _, ok := used[metricName]; !ok {
+				break
+			}
+			metricName = fmt.Sprintf("%s_%d", srvCheckMetricName(name), i)
+		}
+		used[metricName] = struct{}{}
+		metricNames[name] = metricName
+	}
+	return metricNames
 }
 
 func configureServerCheckCommand(srv *fisk.CmdClause) {
@@ -116,15 +298,28 @@ func configureServerCheckCommand(srv *fisk.CmdClause) {
 	check.Flag("outfile", "Save output to a file rather than STDOUT").StringVar(&checkRenderOutFile)
 	check.PreAction(c.parseRenderFormat)
 
-	conn := check.Command("connection", "Checks basic server connection").Alias("conn").Action(c.checkConnection)
+	for _, kind := range srvCheckKinds {
+		cmd := check.Command(kind.name, kind.help).Action(c.checkAction(kind))
+		if kind.alias != "" {
+			cmd.Alias(kind.alias)
+		}
+		kind.flags(c, cmd)
+	}
+
+	configureServerCheckBundleCommand(check)
+	configureServerCheckServeCommand(check)
+}
+
+func (c *SrvCheckCmd) connectionCheckFlags(conn *fisk.CmdClause) {
 	conn.Flag("connect-warn", "Warning threshold to allow for establishing connections").Default("500ms").PlaceHolder("DURATION").DurationVar(&c.connectWarning)
 	conn.Flag("connect-critical", "Critical threshold to allow for establishing connections").Default("1s").PlaceHolder("DURATION").DurationVar(&c.connectCritical)
 	conn.Flag("rtt-warn", "Warning threshold to allow for server RTT").Default("500ms").PlaceHolder("DURATION").DurationVar(&c.rttWarning)
 	conn.Flag("rtt-critical", "Critical threshold to allow for server RTT").Default("1s").PlaceHolder("DURATION").DurationVar(&c.rttCritical)
 	conn.Flag("req-warn", "Warning threshold to allow for full round trip test").PlaceHolder("DURATION").Default("500ms").DurationVar(&c.reqWarning)
 	conn.Flag("req-critical", "Critical threshold to allow for full round trip test").PlaceHolder("DURATION").Default("1s").DurationVar(&c.reqCritical)
+}
 
-	stream := check.Command("stream", "Checks the health of mirrored streams, streams with sources or clustered streams").Action(c.checkStream)
+func (c *SrvCheckCmd) streamCheckFlags(stream *fisk.CmdClause) {
 	stream.Flag("stream", "The streams to check").Required().StringVar(&c.sourcesStream)
 	stream.Flag("lag-critical", "Critical threshold to allow for lag on any source or mirror").PlaceHolder("MSGS").Uint64Var(&c.sourcesLagCritical)
 	stream.Flag("seen-critical", "Critical threshold for how long ago the source or mirror should have been seen").PlaceHolder("DURATION").DurationVar(&c.sourcesSeenCritical)
@@ -137,8 +332,9 @@ func configureServerCheckCommand(srv *fisk.CmdClause) {
 	stream.Flag("msgs-critical", "Critical if there are fewer than this many messages in the stream").PlaceHolder("MSGS").Uint64Var(&c.sourcesMessagesCrit)
 	stream.Flag("subjects-warn", "Critical threshold for subjects in the stream").PlaceHolder("SUBJECTS").Default("-1").IntVar(&c.subjectsWarn)
 	stream.Flag("subjects-critical", "Warning threshold for subjects in the stream").PlaceHolder("SUBJECTS").Default("-1").IntVar(&c.subjectsCrit)
+}
 
-	consumer := check.Command("consumer", "Checks the health of a consumer").Action(c.checkConsumer)
+func (c *SrvCheckCmd) consumerCheckFlags(consumer *fisk.CmdClause) {
 	consumer.Flag("stream", "The streams to check").Required().StringVar(&c.sourcesStream)
 	consumer.Flag("consumer", "The consumer to check").Required().StringVar(&c.consumerName)
 	consumer.Flag("outstanding-ack-critical", "Maximum number of outstanding acks to allow").Default("-1").IntVar(&c.consumerAckOutstandingCritical)
@@ -147,21 +343,24 @@ func configureServerCheckCommand(srv *fisk.CmdClause) {
 	consumer.Flag("last-delivery-critical", "Time to allow since the last delivery").Default("0s").DurationVar(&c.consumerLastDeliveryCritical)
 	consumer.Flag("last-ack-critical", "Time to allow since the last ack").Default("0s").DurationVar(&c.consumerLastAckCritical)
 	consumer.Flag("redelivery-critical", "Maximum number of redeliveries to allow").Default("-1").IntVar(&c.consumerRedeliveryCritical)
+}
 
-	msg := check.Command("message", "Checks properties of a message stored in a stream").Action(c.checkMsg)
+func (c *SrvCheckCmd) messageCheckFlags(msg *fisk.CmdClause) {
 	msg.Flag("stream", "The streams to check").Required().StringVar(&c.sourcesStream)
 	msg.Flag("subject", "The subject to fetch a message from").Default(">").StringVar(&c.msgSubject)
 	msg.Flag("age-warn", "Warning threshold for message age as a duration").PlaceHolder("DURATION").DurationVar(&c.msgAgeWarn)
 	msg.Flag("age-critical", "Critical threshold for message age as a duration").PlaceHolder("DURATION").DurationVar(&c.msgAgeCrit)
 	msg.Flag("content", "Regular expression to check the content against").PlaceHolder("REGEX").RegexpVar(&c.msgRegexp)
 	msg.Flag("body-timestamp", "Use message body as a unix timestamp instead of message metadata").UnNegatableBoolVar(&c.msgBodyAsTs)
+}
 
-	meta := check.Command("meta", "Check JetStream cluster state").Alias("raft").Action(c.checkRaft)
+func (c *SrvCheckCmd) metaCheckFlags(meta *fisk.CmdClause) {
 	meta.Flag("expect", "Number of servers to expect").Required().PlaceHolder("SERVERS").IntVar(&c.raftExpect)
 	meta.Flag("lag-critical", "Critical threshold to allow for lag").PlaceHolder("OPS").Required().Uint64Var(&c.raftLagCritical)
 	meta.Flag("seen-critical", "Critical threshold for how long ago a peer should have been seen").Required().PlaceHolder("DURATION").DurationVar(&c.raftSeenCritical)
+}
 
-	js := check.Command("jetstream", "Check JetStream account state").Alias("js").Action(c.checkJS)
+func (c *SrvCheckCmd) jetStreamCheckFlags(js *fisk.CmdClause) {
 	js.Flag("mem-warn", "Warning threshold for memory storage, in percent").Default("75").IntVar(&c.jsMemWarn)
 	js.Flag("mem-critical", "Critical threshold for memory storage, in percent").Default("90").IntVar(&c.jsMemCritical)
 	js.Flag("store-warn", "Warning threshold for disk storage, in percent").Default("75").IntVar(&c.jsStoreWarn)
@@ -173,8 +372,9 @@ func configureServerCheckCommand(srv *fisk.CmdClause) {
 	js.Flag("replicas", "Checks if all streams have healthy replicas").Default("true").BoolVar(&c.jsReplicas)
 	js.Flag("replica-seen-critical", "Critical threshold for when a stream replica should have been seen, as a duration").Default("5s").DurationVar(&c.jsReplicaSeenCritical)
 	js.Flag("replica-lag-critical", "Critical threshold for how many operations behind a peer can be").Default("200").Uint64Var(&c.jsReplicaLagCritical)
+}
 
-	serv := check.Command("server", "Checks a NATS Server health").Action(c.checkSrv)
+func (c *SrvCheckCmd) serverCheckFlags(serv *fisk.CmdClause) {
 	serv.Flag("name", "Server name to require in the result").Required().StringVar(&c.srvName)
 	serv.Flag("cpu-warn", "Warning threshold for CPU usage, in percent").IntVar(&c.srvCPUWarn)
 	serv.Flag("cpu-critical", "Critical threshold for CPU usage, in percent").IntVar(&c.srvCPUCrit)
@@ -189,20 +389,40 @@ func configureServerCheckCommand(srv *fisk.CmdClause) {
 	serv.Flag("auth-required", "Checks that authentication is enabled").UnNegatableBoolVar(&c.srvAuthRequire)
 	serv.Flag("tls-required", "Checks that TLS is required").UnNegatableBoolVar(&c.srvTLSRequired)
 	serv.Flag("js-required", "Checks that JetStream is enabled").UnNegatableBoolVar(&c.srvJSRequired)
+}
 
-	kv := check.Command("kv", "Checks a NATS KV Bucket").Action(c.checkKV)
+func (c *SrvCheckCmd) kvCheckFlags(kv *fisk.CmdClause) {
 	kv.Flag("bucket", "Checks a specific bucket").Required().StringVar(&c.kvBucket)
 	kv.Flag("values-critical", "Critical threshold for number of values in the bucket").Default("-1").IntVar(&c.kvValuesCrit)
 	kv.Flag("values-warn", "Warning threshold for number of values in the bucket").Default("-1").IntVar(&c.kvValuesWarn)
 	kv.Flag("key", "Requires a key to have any non-delete value set").StringVar(&c.kvKey)
+}
 
-	cred := check.Command("credential", "Checks the validity of a NATS credential file").Action(c.checkCredentialAction)
+func (c *SrvCheckCmd) credentialCheckFlags(cred *fisk.CmdClause) {
 	cred.Flag("credential", "The file holding the NATS credential").Required().StringVar(&c.credential)
 	cred.Flag("validity-warn", "Warning threshold for time before expiry").DurationVar(&c.credentialValidityWarn)
 	cred.Flag("validity-critical", "Critical threshold for time before expiry").DurationVar(&c.credentialValidityCrit)
 	cred.Flag("require-expiry", "Requires the credential to have expiry set").Default("true").BoolVar(&c.credentialRequiresExpire)
 }
 
+// checkAction runs a single check and renders its result
+func (c *SrvCheckCmd) checkAction(kind *srvCheckKind) fisk.Action {
+	return func(_ *fisk.ParseContext) error {
+		check := kind.result(c)
+		if !kind.nagiosOnly {
+			check.OutFile = checkRenderOutFile
+			check.NameSpace = opts.PrometheusNamespace
+			check.RenderFormat = checkRenderFormat
+		}
+		defer check.GenericExit()
+
+		err := kind.run(c, check)
+		check.CriticalIfErr(err, "%s", err)
+
+		return nil
+	}
+}
+
 var (
 	checkRenderFormatText = "nagios"
 	checkRenderFormat     = monitor.NagiosFormat
@@ -224,7 +444,10 @@ func (c *SrvCheckCmd) parseRenderFormat(_ *fisk.ParseContext) error {
 
 func (c *SrvCheckCmd) checkKVStatusAndBucket(check *monitor.Result, nc *nats.Conn) {
 	js, err := nc.JetStream()
-	check.CriticalIfErr(err, "connection failed: %v", err)
+	if err != nil {
+		check.Critical("connection failed: %v", err)
+		return
+	}
 
 	kv, err := js.KeyValue(c.kvBucket)
 	if err == nats.ErrBucketNotFound {
@@ -238,7 +461,10 @@ func (c *SrvCheckCmd) checkKVStatusAndBucket(check *monitor.Result, nc *nats.Con
 	check.Ok("bucket %s", c.kvBucket)
 
 	status, err := kv.Status()
-	check.CriticalIfErr(err, "could not obtain bucket status: %v", err)
+	if err != nil {
+		check.Critical("could not obtain bucket status: %v", err)
+		return
+	}
 
 	check.Pd(
 		&monitor.PerfDataItem{Name: "values", Value: float64(status.Values()), Warn: float64(c.kvValuesWarn), Crit: float64(c.kvValuesCrit), Help: "How many values are stored in the bucket"},
@@ -336,12 +562,11 @@ func (c *SrvCheckCmd) checkConsumerStatus(check *monitor.Result, nfo api.Consume
 	}
 }
 
-func (c *SrvCheckCmd) checkConsumer(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: fmt.Sprintf("%s_%s", c.sourcesStream, c.consumerName), Check: "consumer", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
-	defer check.GenericExit()
-
+func (c *SrvCheckCmd) checkConsumer(check *monitor.Result) error {
 	_, mgr, err := prepareHelper("", natsOpts()...)
-	check.CriticalIfErr(err, "connection failed: %s", err)
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
 
 	cons, err := mgr.LoadConsumer(c.sourcesStream, c.consumerName)
 	if err != nil {
@@ -360,27 +585,27 @@ func (c *SrvCheckCmd) checkConsumer(_ *fisk.ParseContext) error {
 	return nil
 }
 
-func (c *SrvCheckCmd) checkKV(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: c.kvBucket, Check: "kv", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
-	defer check.GenericExit()
-
+func (c *SrvCheckCmd) checkKV(check *monitor.Result) error {
 	nc, _, err := prepareHelper("", natsOpts()...)
-	check.CriticalIfErr(err, "connection failed: %s", err)
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
 
 	c.checkKVStatusAndBucket(check, nc)
 
 	return nil
 }
 
-func (c *SrvCheckCmd) checkSrv(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: c.srvName, Check: "server", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
-	defer check.GenericExit()
-
+func (c *SrvCheckCmd) checkSrv(check *monitor.Result) error {
 	vz, err := c.fetchVarz()
-	check.CriticalIfErr(err, "could not retrieve VARZ information: %s", err)
+	if err != nil {
+		return fmt.Errorf("could not retrieve VARZ information: %s", err)
+	}
 
 	err = c.checkVarz(check, vz)
-	check.CriticalIfErr(err, "check failed: %s", err)
+	if err != nil {
+		return fmt.Errorf("check failed: %s", err)
+	}
 
 	return nil
 }
@@ -479,6 +704,53 @@ func (c *SrvCheckCmd) checkVarz(check *monitor.Result, vz *server.Varz) error {
 	return nil
 }
 
+// srvCheckServerResponse is the response of a server to a system request
+type srvCheckServerResponse[T any] struct {
+	Server *server.ServerInfo `json:"server"`
+	Data   *T                 `json:"data"`
+	Error  *server.ApiError   `json:"error,omitempty"`
+}
+
+// srvCheckPing sends a system request to all servers, or only to the named server, and decodes the responses.
+// Servers filter requests by a substring of their name, so all responses are received and only those from the server
+// with exactly the given name are kept.
+func srvCheckPing[T any](nc *nats.Conn, endpoint string, req any, serverName string) ([]*srvCheckServerResponse[T], error) {
+	res, err := doReq(req, "$SYS.REQ.SERVER.PING."+endpoint, 0, nc)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*srvCheckServerResponse[T], 0, len(res))
+	for _, r := range res {
+		resp := &srvCheckServerResponse[T]{}
+		err = json.Unmarshal(r, resp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s response: %w", endpoint, err)
+		}
+		if serverName != "" && resp.Server != nil && resp.Server.Name != serverName {
+			continue
+		}
+		if resp.Error != nil {
+			return nil, fmt.Errorf("%s request failed: %s", endpoint, resp.Error.Description)
+		}
+		if resp.Server == nil || resp.Data == nil {
+			return nil, fmt.Errorf("invalid %s response: no data received", endpoint)
+		}
+		responses = append(responses, resp)
+	}
+
+	switch {
+	case serverName != "" && len(responses) > 1:
+		return nil, fmt.Errorf("received %s responses from %d servers named %s", endpoint, len(responses), serverName)
+	case len(responses) > 0:
+		return responses, nil
+	case serverName != "":
+		return nil, fmt.Errorf("no %s response received from %s", endpoint, serverName)
+	default:
+		return nil, fmt.Errorf("no %s responses received", endpoint)
+	}
+}
+
 func (c *SrvCheckCmd) fetchVarz() (*server.Varz, error) {
 	var vz json.RawMessage
 
@@ -530,25 +802,32 @@ func (c *SrvCheckCmd) fetchVarz() (*server.Varz, error) {
 	return varz, nil
 }
 
-func (c *SrvCheckCmd) checkJS(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: "JetStream", Check: "jetstream", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
-	defer check.GenericExit()
-
+func (c *SrvCheckCmd) checkJS(check *monitor.Result) error {
 	_, mgr, err := prepareHelper("", natsOpts()...)
-	check.CriticalIfErr(err, "connection failed: %s", err)
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
 
 	info, err := mgr.JetStreamAccountInfo()
-	check.CriticalIfErr(err, "JetStream not available: %s", err)
+	if err != nil {
+		return fmt.Errorf("JetStream not available: %s", err)
+	}
 
 	err = c.checkAccountInfo(check, info)
-	check.CriticalIfErr(err, "JetStream not available: %s", err)
+	if err != nil {
+		return fmt.Errorf("JetStream not available: %s", err)
+	}
 
 	if c.jsReplicas {
 		streams, _, err := mgr.Streams(nil)
-		check.CriticalIfErr(err, "JetStream not available: %s", err)
+		if err != nil {
+			return fmt.Errorf("JetStream not available: %s", err)
+		}
 
 		err = c.checkStreamClusterHealth(check, streams)
-		check.CriticalIfErr(err, "JetStream not available: %s", err)
+		if err != nil {
+			return fmt.Errorf("JetStream not available: %s", err)
+		}
 	}
 
 	return nil
@@ -698,19 +977,19 @@ func (c *SrvCheckCmd) checkAccountInfo(check *monitor.Result, info *api.JetStrea
 	return nil
 }
 
-func (c *SrvCheckCmd) checkRaft(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: "JetStream Meta Cluster", Check: "meta"}
-	defer check.GenericExit()
-
+func (c *SrvCheckCmd) checkRaft(check *monitor.Result) error {
 	nc, _, err := prepareHelper("", natsOpts()...)
-	check.CriticalIfErr(err, "connection failed: %s", err)
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
 
 	res, err := doReq(&server.JSzOptions{LeaderOnly: true}, "$SYS.REQ.SERVER.PING.JSZ", 1, nc)
-	check.CriticalIfErr(err, "JSZ API request failed: %s", err)
+	if err != nil {
+		return fmt.Errorf("JSZ API request failed: %s", err)
+	}
 
 	if len(res) != 1 {
-		check.CriticalExit(fmt.Sprintf("JSZ API request returned %d results", len(res)))
-		return nil
+		return fmt.Errorf("JSZ API request returned %d results", len(res))
 	}
 
 	type jszr struct {
@@ -730,7 +1009,9 @@ func (c *SrvCheckCmd) checkRaft(_ *fisk.ParseContext) error {
 
 	jszresp := &jszr{}
 	err = json.Unmarshal(res[0], jszresp)
-	check.CriticalIfErr(err, "invalid result received: %s", err)
+	if err != nil {
+		return fmt.Errorf("invalid result received: %s", err)
+	}
 
 	// we may have a pre 2.7.0 machine and will try get data with old struct names, if all of these are
 	// 0 it might be that they are 0 or that we had data in the old format, so we try parse the old
@@ -746,7 +1027,9 @@ func (c *SrvCheckCmd) checkRaft(_ *fisk.ParseContext) error {
 	}
 
 	err = c.checkMetaClusterInfo(check, jszresp.Data.Meta)
-	check.CriticalIfErr(err, "invalid result received: %s", err)
+	if err != nil {
+		return fmt.Errorf("invalid result received: %s", err)
+	}
 
 	if len(check.Criticals) == 0 && len(check.Warnings) == 0 {
 		check.Ok("%d peers led by %s", len(jszresp.Data.Meta.Replicas)+1, jszresp.Data.Meta.Leader)
@@ -828,25 +1111,30 @@ func (c *SrvCheckCmd) checkClusterInfo(check *monitor.Result, ci *server.Cluster
 	return nil
 }
 
-func (c *SrvCheckCmd) checkStream(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: c.sourcesStream, Check: "stream", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
-	defer check.GenericExit()
-
+func (c *SrvCheckCmd) checkStream(check *monitor.Result) error {
 	_, mgr, err := prepareHelper("", natsOpts()...)
-	check.CriticalIfErr(err, "connection failed: %s", err)
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
 
 	stream, err := mgr.LoadStream(c.sourcesStream)
-	check.CriticalIfErr(err, "could not load stream %s: %s", c.sourcesStream, err)
+	if err != nil {
+		return fmt.Errorf("could not load stream %s: %s", c.sourcesStream, err)
+	}
 
 	info, err := stream.LatestInformation()
-	check.CriticalIfErr(err, "could not load stream %s info: %s", c.sourcesStream, err)
+	if err != nil {
+		return fmt.Errorf("could not load stream %s info: %s", c.sourcesStream, err)
+	}
 
 	if info.Cluster != nil {
 		var sci server.ClusterInfo
 		cij, _ := json.Marshal(info.Cluster)
 		json.Unmarshal(cij, &sci)
 		err = c.checkClusterInfo(check, &sci)
-		check.CriticalIfErr(err, "Invalid cluster data: %s", err)
+		if err != nil {
+			return fmt.Errorf("Invalid cluster data: %s", err)
+		}
 
 		if len(check.Criticals) == 0 {
 			check.Ok("%d current replicas", len(info.Cluster.Replicas)+1)
@@ -884,7 +1172,9 @@ func (c *SrvCheckCmd) checkStream(_ *fisk.ParseContext) error {
 	switch {
 	case stream.IsMirror():
 		err = c.checkMirror(check, info)
-		check.CriticalIfErr(err, "Invalid mirror data: %s", err)
+		if err != nil {
+			return fmt.Errorf("Invalid mirror data: %s", err)
+		}
 
 		if len(check.Criticals) == 0 {
 			check.Ok("%s mirror of %s is %d lagged, last seen %s ago", c.sourcesStream, info.Mirror.Name, info.Mirror.Lag, info.Mirror.Active.Round(time.Millisecond))
@@ -892,7 +1182,9 @@ func (c *SrvCheckCmd) checkStream(_ *fisk.ParseContext) error {
 
 	case stream.IsSourced():
 		err = c.checkSources(check, info)
-		check.CriticalIfErr(err, "Invalid source data: %s", err)
+		if err != nil {
+			return fmt.Errorf("Invalid source data: %s", err)
+		}
 
 		if len(check.Criticals) == 0 {
 			check.Ok("%d sources", len(info.Sources))
@@ -971,12 +1263,16 @@ func (c *SrvCheckCmd) checkStreamMessage(mgr *jsm.Manager, check *monitor.Result
 		check.Critical("no message found")
 		return nil
 	}
-	check.CriticalIfErr(err, "msg load failed: %v", err)
+	if err != nil {
+		return fmt.Errorf("msg load failed: %v", err)
+	}
 
 	ts := msg.Time
 	if c.msgBodyAsTs {
 		i, err := strconv.ParseInt(string(bytes.TrimSpace(msg.Data)), 10, 64)
-		check.CriticalIfErr(err, "invalid timestamp body: %v", err)
+		if err != nil {
+			return fmt.Errorf("invalid timestamp body: %v", err)
+		}
 		ts = time.Unix(i, 0)
 	}
 
@@ -1015,23 +1311,21 @@ func (c *SrvCheckCmd) checkStreamMessage(mgr *jsm.Manager, check *monitor.Result
 	return nil
 }
 
-func (c *SrvCheckCmd) checkMsg(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: "Stream Message", Check: "message", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
-	defer check.GenericExit()
-
+func (c *SrvCheckCmd) checkMsg(check *monitor.Result) error {
 	_, mgr, err := prepareHelper("", natsOpts()...)
-	check.CriticalIfErr(err, "connection failed")
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
 
 	return c.checkStreamMessage(mgr, check)
 }
 
-func (c *SrvCheckCmd) checkConnection(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: "Connection", Check: "connections", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
-	defer check.GenericExit()
-
+func (c *SrvCheckCmd) checkConnection(check *monitor.Result) error {
 	connStart := time.Now()
 	nc, _, err := prepareHelper("", natsOpts()...)
-	check.CriticalIfErr(err, "connection failed")
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
 
 	ct := time.Since(connStart)
 	check.Pd(&monitor.PerfDataItem{Name: "connect_time", Value: ct.Seconds(), Warn: c.connectWarning.Seconds(), Crit: c.connectCritical.Seconds(), Unit: "s", Help: "Time taken to connect to NATS"})
@@ -1045,7 +1339,9 @@ func (c *SrvCheckCmd) checkConnection(_ *fisk.ParseContext) error {
 	}
 
 	rtt, err := nc.RTT()
-	check.CriticalIfErr(err, "rtt failed: %s", err)
+	if err != nil {
+		return fmt.Errorf("rtt failed: %s", err)
+	}
 
 	check.Pd(&monitor.PerfDataItem{Name: "rtt", Value: rtt.Seconds(), Warn: c.rttWarning.Seconds(), Crit: c.rttCritical.Seconds(), Unit: "s", Help: "The round-trip-time of the connection"})
 	if rtt >= c.rttCritical {
@@ -1059,15 +1355,21 @@ func (c *SrvCheckCmd) checkConnection(_ *fisk.ParseContext) error {
 	msg := []byte(randomPassword(100))
 	ib := nc.NewRespInbox()
 	sub, err := nc.SubscribeSync(ib)
-	check.CriticalIfErr(err, "could not subscribe to %s: %s", ib, err)
+	if err != nil {
+		return fmt.Errorf("could not subscribe to %s: %s", ib, err)
+	}
 	sub.AutoUnsubscribe(1)
 
 	start := time.Now()
 	err = nc.Publish(ib, msg)
-	check.CriticalIfErr(err, "could not publish to %s: %s", ib, err)
+	if err != nil {
+		return fmt.Errorf("could not publish to %s: %s", ib, err)
+	}
 
 	received, err := sub.NextMsg(opts.Timeout)
-	check.CriticalIfErr(err, "did not receive from %s: %s", ib, err)
+	if err != nil {
+		return fmt.Errorf("did not receive from %s: %s", ib, err)
+	}
 
 	reqt := time.Since(start)
 	check.Pd(&monitor.PerfDataItem{Name: "request_time", Value: reqt.Seconds(), Warn: c.reqWarning.Seconds(), Crit: c.reqCritical.Seconds(), Unit: "s", Help: "Time taken for a full Request-Reply operation"})
@@ -1114,6 +1416,7 @@ func (c *SrvCheckCmd) checkCredential(check *monitor.Result) error {
 	claims, err := jwt.Decode(token)
 	if err != nil {
 		check.Critical("invalid credential: %v", err)
+		return nil
 	}
 
 	now := time.Now().UTC().Unix()
@@ -1137,10 +1440,3 @@ func (c *SrvCheckCmd) checkCredential(check *monitor.Result) error {
 
 	return nil
 }
-
-func (c *SrvCheckCmd) checkCredentialAction(_ *fisk.ParseContext) error {
-	check := &monitor.Result{Name: "Credential", Check: "credential", OutFile: checkRenderOutFile, NameSpace: opts.PrometheusNamespace, RenderFormat: checkRenderFormat}
-	defer check.GenericExit()
-
-	return c.checkCredential(check)
-}
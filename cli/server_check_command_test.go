@@ -14,8 +14,12 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"strconv"
 	"strings"
 	"testing"
@@ -1136,3 +1140,123 @@ func TestCheckJSZ(t *testing.T) {
 			"1 lagged more than 10 ops")
 	})
 }
+
+func TestCheckActionRequestFailure(t *testing.T) {
+	// GenericExit exits the process, so the check runs in a child process with this variable set to the check name
+	if name := os.Getenv("NATSCLI_TEST_CHECK_ACTION"); name != "" {
+		srv, err := server.NewServer(&server.Options{Port: -1})
+		checkErr(t, err, "could not start server: %v", err)
+		go srv.Start()
+		if !srv.ReadyForConnections(10 * time.Second) {
+			t.Fatalf("nats server did not start")
+		}
+
+		_, _, err = prepareHelper(srv.ClientURL())
+		checkErr(t, err, "could not connect client to server @ %s: %v", srv.ClientURL(), err)
+		SetContext(context.Background())
+		opts.Timeout = 250 * time.Millisecond
+		checkRenderFormat = monitor.JSONFormat
+
+		for _, kind := range srvCheckKinds {
+			if kind.name == name {
+				(&SrvCheckCmd{}).checkAction(kind)(nil)
+			}
+		}
+		t.Fatalf("check %s did not exit", name)
+	}
+
+	run := func(name string) (string, int) {
+		t.Helper()
+
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCheckActionRequestFailure$")
+		cmd.Env = append(os.Environ(), "NATSCLI_TEST_CHECK_ACTION="+name)
+		out, err := cmd.Output()
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Fatalf("expected check %s to exit with an error, got %v: %s", name, err, out)
+		}
+		return string(out), exitErr.ExitCode()
+	}
+
+	t.Run("jetstream", func(t *testing.T) {
+		// Requests failing are reported as critical, rendered in the requested format, with the nagios critical exit code
+		out, code := run("jetstream")
+		if code != 2 {
+			t.Fatalf("expected exit code 2, got %d: %s", code, out)
+		}
+
+		var check monitor.Result
+		err := json.Unmarshal([]byte(out), &check)
+		checkErr(t, err, "invalid json output %q: %v", out, err)
+		if check.Status != monitor.CriticalStatus || check.Name != "JetStream" {
+			t.Fatalf("unexpected result: %+v", check)
+		}
+		if len(check.Criticals) != 1 || check.Criticals[0] != "JetStream not available: nats: jetstream not enabled" {
+			t.Fatalf("unexpected criticals: %v", check.Criticals)
+		}
+		assertListIsEmpty(t, check.OKs)
+	})
+
+	t.Run("meta", func(t *testing.T) {
+		// The meta check always renders in nagios format
+		out, code := run("meta")
+		if code != 2 {
+			t.Fatalf("expected exit code 2, got %d: %s", code, out)
+		}
+		if !strings.HasPrefix(out, "CRITICAL JetStream Meta Cluster Crit:JSZ API request failed: ") {
+			t.Fatalf("expected nagios output, got %q", out)
+		}
+	})
+}
+
+func TestSrvCheckPing(t *testing.T) {
+	withJetStream(t, func(_ *server.Server, nc *nats.Conn, _ *jsm.Manager) {
+		SetContext(context.Background())
+		timeout := opts.Timeout
+		opts.Timeout = time.Second
+		defer func() { opts.Timeout = timeout }()
+
+		// respond as servers whose names all contain n1, like servers do for requests filtered by name
+		respond := func(names ...string) {
+			t.Helper()
+			for _, name := range names {
+				name := name
+				sub, err := nc.Subscribe("$SYS.REQ.SERVER.PING.LEAFZ", func(m *nats.Msg) {
+					resp, _ := json.Marshal(&srvCheckServerResponse[server.Leafz]{Server: &server.ServerInfo{Name: name}, Data: &server.Leafz{ID: name}})
+					m.Respond(resp)
+				})
+				checkErr(t, err, "subscribe failed: %v", err)
+				t.Cleanup(func() { sub.Unsubscribe() })
+			}
+		}
+
+		t.Run("exact name", func(t *testing.T) {
+			respond("n10", "n1", "n11")
+			leafz, err := srvCheckPing[server.Leafz](nc, "LEAFZ", &server.LeafzEventOptions{}, "n1")
+			checkErr(t, err, "ping failed: %v", err)
+			if len(leafz) != 1 || leafz[0].Server.Name != "n1" {
+				t.Fatalf("expected only the n1 response, got %d responses", len(leafz))
+			}
+
+			leafz, err = srvCheckPing[server.Leafz](nc, "LEAFZ", &server.LeafzEventOptions{}, "")
+			checkErr(t, err, "ping failed: %v", err)
+			if len(leafz) != 3 {
+				t.Fatalf("expected 3 responses, got %d", len(leafz))
+			}
+
+			_, err = srvCheckPing[server.Leafz](nc, "LEAFZ", &server.LeafzEventOptions{}, "n")
+			if err == nil || err.Error() != "no LEAFZ response received from n" {
+				t.Fatalf("expected no response error, got %v", err)
+			}
+		})
+
+		t.Run("duplicate names", func(t *testing.T) {
+			respond("n1")
+			_, err := srvCheckPing[server.Leafz](nc, "LEAFZ", &server.LeafzEventOptions{}, "n1")
+			if err == nil || err.Error() != "received LEAFZ responses from 2 servers named n1" {
+				t.Fatalf("expected duplicate name error, got %v", err)
+			}
+		})
+	})
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/monitor"
+)
+
+// srvCheckGatewayStats are the gateway connections to a remote cluster, from all servers checked
+type srvCheckGatewayStats struct {
+	outbound int
+	inbound  int
+	rtt      time.Duration
+}
+
+func (c *SrvCheckCmd) gatewayCheckFlags(gw *fisk.CmdClause) {
+	gw.Flag("cluster", "Only checks gateways of servers in this cluster").PlaceHolder("CLUSTER").StringVar(&c.gwCluster)
+	gw.Flag("expect", "Cluster all servers must have gateways to, defaults to all clusters seen (repeatable)").PlaceHolder("CLUSTER").StringsVar(&c.gwExpect)
+	gw.Flag("rtt-warn", "Warning threshold for gateway RTT").Default("500ms").PlaceHolder("DURATION").DurationVar(&c.gwRTTWarn)
+	gw.Flag("rtt-critical", "Critical threshold for gateway RTT").Default("1s").PlaceHolder("DURATION").DurationVar(&c.gwRTTCrit)
+}
+
+func (c *SrvCheckCmd) checkGateway(check *monitor.Result) error {
+	nc, _, err := prepareHelper("", natsOpts()...)
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
+
+	gatewayz, err := srvCheckPing[server.Gatewayz](nc, "GATEWAYZ", &server.GatewayzEventOptions{
+		GatewayzOptions:    server.GatewayzOptions{Accounts: true},
+		EventFilterOptions: server.EventFilterOptions{Cluster: c.gwCluster},
+	}, "")
+	if err != nil {
+		return err
+	}
+
+	c.checkGatewayz(check, gatewayz)
+
+	return nil
+}
+
+// checkGatewayz verifies that every server has outbound and inbound gateways to all expected clusters
+func (c *SrvCheckCmd) checkGatewayz(check *monitor.Result, gatewayz []*srvCheckServerResponse[server.Gatewayz]) {
+	servers := make([]*srvCheckServerResponse[server.Gatewayz], 0, len(gatewayz))
+	for _, resp := range gatewayz {
+		if resp.Data.Name != "" {
+			servers = append(servers, resp)
+		}
+	}
+	if len(servers) == 0 {
+		check.Critical("gateways are not enabled")
+		return
+	}
+	sort.Slice(servers, func(i, j int) bool { return servers[i].Server.Name < servers[j].Server.Name })
+
+	// without explicit expectations, every cluster seen is expected, clusters without any connection can only be
+	// detected when expected explicitly
+	expected := c.gwExpect
+	if len(expected) == 0 {
+		seen := make(map[string]struct{})
+		for _, resp := range servers {
+			seen[resp.Data.Name] = struct{}{}
+			for name := range resp.Data.OutboundGateways {
+				seen[name] = struct{}{}
+			}
+			for name := range resp.Data.InboundGateways {
+				seen[name] = struct{}{}
+			}
+		}
+		for name := range seen {
+			expected = append(expected, name)
+		}
+		sort.Strings(expected)
+	}
+
+	stats := make(map[string]*srvCheckGatewayStats)
+	for _, cluster := range expected {
+		stats[cluster] = &srvCheckGatewayStats{}
+	}
+
+	for _, resp := range servers {
+		srv := fmt.Sprintf("%s (cluster %s)", resp.Server.Name, resp.Data.Name)
+
+		for _, cluster := range expected {
+			if cluster == resp.Data.Name {
+				continue
+			}
+
+			outbound := resp.Data.OutboundGateways[cluster]
+			if outbound == nil || outbound.Connection == nil {
+				check.Critical("%s has no outbound gateway to %s", srv, cluster)
+			}
+			if len(resp.Data.InboundGateways[cluster]) == 0 {
+				check.Critical("%s has no inbound gateway from %s", srv, cluster)
+			}
+		}
+
+		for _, cluster := range sortedMapKeys(resp.Data.OutboundGateways) {
+			outbound := resp.Data.OutboundGateways[cluster]
+			if outbound == nil || outbound.Connection == nil {
+				continue
+			}
+
+			st, ok := stats[cluster]
+			if !ok {
+				st = &srvCheckGatewayStats{}
+				stats[cluster] = st
+			}
+			st.outbound++
+
+			rtt, err := time.ParseDuration(outbound.Connection.RTT)
+			if err == nil {
+				st.rtt = max(st.rtt, rtt)
+
+				switch {
+				case c.gwRTTCrit > 0 && rtt >= c.gwRTTCrit:
+					check.Critical("%s outbound gateway to %s rtt %v", srv, cluster, rtt)
+				case c.gwRTTWarn > 0 && rtt >= c.gwRTTWarn:
+					check.Warn("%s outbound gateway to %s rtt %v", srv, cluster, rtt)
+				}
+			}
+
+			for _, acct := range outbound.Accounts {
+				if acct.InterestMode == server.Transitioning.String() {
+					check.Warn("%s outbound gateway to %s is transitioning account %s to interest-only mode", srv, cluster, acct.Name)
+				}
+			}
+		}
+
+		for _, cluster := range sortedMapKeys(resp.Data.InboundGateways) {
+			inbound := resp.Data.InboundGateways[cluster]
+			st, ok := stats[cluster]
+			if !ok {
+				st = &srvCheckGatewayStats{}
+				stats[cluster] = st
+			}
+			st.inbound += len(inbound)
+		}
+	}
+
+	check.Pd(&monitor.PerfDataItem{Name: "servers", Value: float64(len(servers)), Help: "Number of servers with gateways enabled"})
+
+	local := make(map[string]struct{})
+	for _, resp := range servers {
+		local[resp.Data.Name] = struct{}{}
+	}
+
+	var clusters []string
+	for _, cluster := range sortedMapKeys(stats) {
+		st := stats[cluster]
+		if _, ok := local[cluster]; ok && st.outbound == 0 && st.inbound == 0 {
+			continue
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	metricNames := srvCheckMetricNames(clusters)
+	for _, cluster := range clusters {
+		st := stats[cluster]
+		name := metricNames[cluster]
+		check.Pd(
+			&monitor.PerfDataItem{Name: name + "_outbound", Value: float64(st.outbound), Help: fmt.Sprintf("Outbound gateway connections to cluster %s", cluster)},
+			&monitor.PerfDataItem{Name: name + "_inbound", Value: float64(st.inbound), Help: fmt.Sprintf("Inbound gateway connections from cluster %s", cluster)},
+			&monitor.PerfDataItem{Name: name + "_rtt", Value: st.rtt.Seconds(), Warn: c.gwRTTWarn.Seconds(), Crit: c.gwRTTCrit.Seconds(), Unit: "s", Help: fmt.Sprintf("The highest round-trip-time of a gateway connection to cluster %s", cluster)},
+		)
+	}
+
+	if len(check.Criticals) == 0 {
+		check.Ok("%d servers in a gateway mesh of %d clusters", len(servers), len(expected))
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/monitor"
+)
+
+func TestCheckGatewayz(t *testing.T) {
+	gateway := func(rtt string, accounts ...*server.AccountGatewayz) *server.RemoteGatewayz {
+		return &server.RemoteGatewayz{IsConfigured: true, Connection: &server.ConnInfo{RTT: rtt}, Accounts: accounts}
+	}
+	response := func(serverName string, cluster string, outbound map[string]*server.RemoteGatewayz, inbound ...string) *srvCheckServerResponse[server.Gatewayz] {
+		gwz := &server.Gatewayz{Name: cluster, OutboundGateways: outbound, InboundGateways: map[string][]*server.RemoteGatewayz{}}
+		for _, name := range inbound {
+			gwz.InboundGateways[name] = append(gwz.InboundGateways[name], gateway("1ms"))
+		}
+		return &srvCheckServerResponse[server.Gatewayz]{Server: &server.ServerInfo{Name: serverName}, Data: gwz}
+	}
+	mesh := func() []*srvCheckServerResponse[server.Gatewayz] {
+		return []*srvCheckServerResponse[server.Gatewayz]{
+			response("a1", "A", map[string]*server.RemoteGatewayz{"B": gateway("10ms")}, "B"),
+			response("b1", "B", map[string]*server.RemoteGatewayz{"A": gateway("20ms")}, "A"),
+		}
+	}
+
+	t.Run("not enabled", func(t *testing.T) {
+		cmd := &SrvCheckCmd{}
+		check := &monitor.Result{}
+		cmd.checkGatewayz(check, []*srvCheckServerResponse[server.Gatewayz]{response("s1", "", nil)})
+		assertListEquals(t, check.Criticals, "gateways are not enabled")
+	})
+
+	t.Run("healthy", func(t *testing.T) {
+		cmd := &SrvCheckCmd{}
+		check := &monitor.Result{}
+		cmd.checkGatewayz(check, mesh())
+		assertListIsEmpty(t, check.Criticals)
+		assertListIsEmpty(t, check.Warnings)
+		assertListEquals(t, check.OKs, "2 servers in a gateway mesh of 2 clusters")
+
+		names := make([]string, 0, len(check.PerfData))
+		for _, pd := range check.PerfData {
+			names = append(names, pd.Name)
+		}
+		assertListEquals(t, names, "servers", "A_outbound", "A_inbound", "A_rtt", "B_outbound", "B_inbound", "B_rtt")
+		if check.PerfData[6].Value != 0.01 {
+			t.Fatalf("unexpected B rtt: %v", check.PerfData[6].Value)
+		}
+	})
+
+	t.Run("colliding metric names", func(t *testing.T) {
+		cmd := &SrvCheckCmd{}
+		check := &monitor.Result{RenderFormat: monitor.PrometheusFormat}
+		cmd.checkGatewayz(check, []*srvCheckServerResponse[server.Gatewayz]{
+			response("s1", "a-b", map[string]*server.RemoteGatewayz{"a.b": gateway("1ms")}, "a.b"),
+			response("s2", "a.b", map[string]*server.RemoteGatewayz{"a-b": gateway("1ms")}, "a-b"),
+		})
+		assertListIsEmpty(t, check.Criticals)
+
+		names := make([]string, 0, len(check.PerfData))
+		for _, pd := range check.PerfData {
+			names = append(names, pd.Name)
+		}
+		assertListEquals(t, names, "servers", "a_b_outbound", "a_b_inbound", "a_b_rtt", "a_b_2_outbound", "a_b_2_inbound", "a_b_2_rtt")
+
+		// duplicate names make the prometheus registry panic
+		_ = check.String()
+	})
+
+	t.Run("missing gateways", func(t *testing.T) {
+		cmd := &SrvCheckCmd{}
+		responses := mesh()
+		responses = append(responses, response("c1", "C", map[string]*server.RemoteGatewayz{"A": gateway("1ms")}, "A"))
+
+		check := &monitor.Result{}
+		cmd.checkGatewayz(check, responses)
+		assertListEquals(t, check.Criticals,
+			"a1 (cluster A) has no outbound gateway to C",
+			"a1 (cluster A) has no inbound gateway from C",
+			"b1 (cluster B) has no outbound gateway to C",
+			"b1 (cluster B) has no inbound gateway from C",
+			"c1 (cluster C) has no outbound gateway to B",
+			"c1 (cluster C) has no inbound gateway from B",
+		)
+		assertListIsEmpty(t, check.OKs)
+	})
+
+	t.Run("expected clusters", func(t *testing.T) {
+		cmd := &SrvCheckCmd{gwExpect: []string{"B", "D"}}
+		check := &monitor.Result{}
+		cmd.checkGatewayz(check, mesh()[:1])
+		assertListEquals(t, check.Criticals, "a1 (cluster A) has no outbound gateway to D", "a1 (cluster A) has no inbound gateway from D")
+	})
+
+	t.Run("rtt and interest mode", func(t *testing.T) {
+		cmd := &SrvCheckCmd{gwRTTWarn: 15 * time.Millisecond, gwRTTCrit: time.Second}
+		responses := mesh()
+		responses[0].Data.OutboundGateways["B"].Accounts = []*server.AccountGatewayz{
+			{Name: "APP", InterestMode: server.Transitioning.String()},
+			{Name: "OTHER", InterestMode: server.InterestOnly.String()},
+		}
+
+		check := &monitor.Result{}
+		cmd.checkGatewayz(check, responses)
+		assertListIsEmpty(t, check.Criticals)
+		assertListEquals(t, check.Warnings,
+			"a1 (cluster A) outbound gateway to B is transitioning account APP to interest-only mode",
+			"b1 (cluster B) outbound gateway to A rtt 20ms",
+		)
+	})
+}
@@ -0,0 +1,293 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/monitor"
+)
+
+const (
+	// leafnodes that exchanged fewer messages than this are not checked for imbalance
+	srvCheckLeafImbalanceMinMsgs = 1000
+	// closed connections requested from each server to find leafnode reconnects
+	srvCheckLeafClosedLimit = 10000
+)
+
+// srvCheckLeafExpectation is a leafnode remote expected to be connected, empty fields match any value
+type srvCheckLeafExpectation struct {
+	name    string
+	account string
+	cluster string
+}
+
+// srvCheckLeaf is a leafnode connection to a server
+type srvCheckLeaf struct {
+	server string
+	// cluster is the cluster of the remote server, when known
+	cluster    string
+	info       *server.LeafInfo
+	reconnects int
+}
+
+func (c *SrvCheckCmd) leafnodeCheckFlags(leaf *fisk.CmdClause) {
+	leaf.Flag("server-name", "Only checks leafnodes connected to the server with exactly this name").PlaceHolder("NAME").StringVar(&c.leafServerName)
+	leaf.Flag("expect", "Leafnode remote that must be connected, as a server name or name=,account=,cluster= pairs (repeatable)").PlaceHolder("REMOTE").StringsVar(&c.leafExpect)
+	leaf.Flag("count-warn", "Warning threshold for the minimum number of leafnodes").PlaceHolder("LEAFNODES").IntVar(&c.leafCountWarn)
+	leaf.Flag("count-critical", "Critical threshold for the minimum number of leafnodes").PlaceHolder("LEAFNODES").IntVar(&c.leafCountCrit)
+	leaf.Flag("rtt-warn", "Warning threshold for leafnode RTT").Default("500ms").PlaceHolder("DURATION").DurationVar(&c.leafRTTWarn)
+	leaf.Flag("rtt-critical", "Critical threshold for leafnode RTT").Default("1s").PlaceHolder("DURATION").DurationVar(&c.leafRTTCrit)
+	leaf.Flag("imbalance-warn", "Warning threshold for the difference between messages sent and received, in percent").PlaceHolder("PERCENT").IntVar(&c.leafImbalanceWarn)
+	leaf.Flag("imbalance-critical", "Critical threshold for the difference between messages sent and received, in percent").PlaceHolder("PERCENT").IntVar(&c.leafImbalanceCrit)
+	leaf.Flag("reconnect-window", "How far back to look for leafnode reconnects").Default("10m").PlaceHolder("DURATION").DurationVar(&c.leafReconnectWindow)
+	leaf.Flag("reconnects-warn", "Warning threshold for leafnode reconnects in the reconnect window, checking reconnects queries recently closed connections").PlaceHolder("RECONNECTS").IntVar(&c.leafReconnectsWarn)
+	leaf.Flag("reconnects-critical", "Critical threshold for leafnode reconnects in the reconnect window, checking reconnects queries recently closed connections").PlaceHolder("RECONNECTS").IntVar(&c.leafReconnectsCrit)
+}
+
+func (c *SrvCheckCmd) checkLeafnode(check *monitor.Result) error {
+	expectations := make([]*srvCheckLeafExpectation, 0, len(c.leafExpect))
+	needClusters := false
+	for _, expect := range c.leafExpect {
+		e, err := parseSrvCheckLeafExpectation(expect)
+		if err != nil {
+			return err
+		}
+		needClusters = needClusters || e.cluster != ""
+		expectations = append(expectations, e)
+	}
+
+	nc, _, err := prepareHelper("", natsOpts()...)
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
+
+	filter := server.EventFilterOptions{Name: c.leafServerName}
+	leafz, err := srvCheckPing[server.Leafz](nc, "LEAFZ", &server.LeafzEventOptions{EventFilterOptions: filter}, c.leafServerName)
+	if err != nil {
+		return err
+	}
+
+	var leafs []*srvCheckLeaf
+	for _, resp := range leafz {
+		for _, info := range resp.Data.Leafs {
+			leafs = append(leafs, &srvCheckLeaf{server: resp.Server.Name, info: info})
+		}
+	}
+
+	// the remote cluster is only known for remote servers sharing the system account
+	if needClusters {
+		varz, err := srvCheckPing[server.Varz](nc, "VARZ", &server.VarzEventOptions{}, "")
+		if err != nil {
+			return fmt.Errorf("could not determine leafnode clusters: %s", err)
+		}
+
+		clusters := make(map[string]string)
+		for _, resp := range varz {
+			clusters[resp.Server.Name] = resp.Data.Cluster.Name
+		}
+		for _, leaf := range leafs {
+			leaf.cluster = clusters[leaf.info.Name]
+		}
+	}
+
+	reconnects := 0
+	if c.leafReconnectsWarn > 0 || c.leafReconnectsCrit > 0 {
+		connz, err := srvCheckPing[server.Connz](nc, "CONNZ", &server.ConnzEventOptions{
+			ConnzOptions:       server.ConnzOptions{State: server.ConnClosed, Sort: server.ByStop, Username: true, Limit: srvCheckLeafClosedLimit},
+			EventFilterOptions: filter,
+		}, c.leafServerName)
+		if err != nil {
+			return fmt.Errorf("could not determine leafnode reconnects: %s", err)
+		}
+
+		closed := make(map[string][]*server.ConnInfo)
+		for _, resp := range connz {
+			closed[resp.Server.Name] = resp.Data.Conns
+		}
+		reconnects = c.countLeafReconnects(leafs, closed, time.Now())
+	}
+
+	c.checkLeafs(check, leafs, expectations, reconnects)
+
+	return nil
+}
+
+// countLeafReconnects counts leafnode connections closed within the reconnect window, by server, account and
+// address of current leafnodes. Returns the total number of leafnode connections closed within the window.
+func (c *SrvCheckCmd) countLeafReconnects(leafs []*srvCheckLeaf, closed map[string][]*server.ConnInfo, now time.Time) int {
+	total := 0
+	counts := make(map[string]int)
+	for serverName, conns := range closed {
+		for _, conn := range conns {
+			if conn.Kind != "Leafnode" || conn.Stop == nil || now.Sub(*conn.Stop) > c.leafReconnectWindow {
+				continue
+			}
+
+			// closed connections omit the global account
+			account := conn.Account
+			if account == "" {
+				account = server.DEFAULT_GLOBAL_ACCOUNT
+			}
+
+			counts[serverName+"/"+account+"/"+conn.IP]++
+			total++
+		}
+	}
+
+	for _, leaf := range leafs {
+		leaf.reconnects = counts[leaf.server+"/"+leaf.info.Account+"/"+leaf.info.IP]
+	}
+
+	return total
+}
+
+func (c *SrvCheckCmd) checkLeafs(check *monitor.Result, leafs []*srvCheckLeaf, expectations []*srvCheckLeafExpectation, reconnects int) {
+	check.Pd(&monitor.PerfDataItem{Name: "leafnodes", Value: float64(len(leafs)), Warn: float64(c.leafCountWarn), Crit: float64(c.leafCountCrit), Help: "Number of connected leafnodes"})
+
+	switch {
+	case c.leafCountCrit > 0 && len(leafs) < c.leafCountCrit:
+		check.Critical("%d leafnodes connected", len(leafs))
+	case c.leafCountWarn > 0 && len(leafs) < c.leafCountWarn:
+		check.Warn("%d leafnodes connected", len(leafs))
+	default:
+		check.Ok("%d leafnodes connected", len(leafs))
+	}
+
+	for _, expect := range expectations {
+		found := false
+		for _, leaf := range leafs {
+			if expect.matches(leaf) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			check.Critical("leafnode %s not connected", expect)
+		}
+	}
+
+	var maxRTT time.Duration
+	for _, leaf := range leafs {
+		rtt, err := time.ParseDuration(leaf.info.RTT)
+		if err != nil {
+			continue
+		}
+		maxRTT = max(maxRTT, rtt)
+
+		switch {
+		case c.leafRTTCrit > 0 && rtt >= c.leafRTTCrit:
+			check.Critical("%s rtt %v", leaf, rtt)
+		case c.leafRTTWarn > 0 && rtt >= c.leafRTTWarn:
+			check.Warn("%s rtt %v", leaf, rtt)
+		}
+	}
+	check.Pd(&monitor.PerfDataItem{Name: "rtt", Value: maxRTT.Seconds(), Warn: c.leafRTTWarn.Seconds(), Crit: c.leafRTTCrit.Seconds(), Unit: "s", Help: "The highest round-trip-time of a leafnode connection"})
+
+	if c.leafImbalanceWarn > 0 || c.leafImbalanceCrit > 0 {
+		for _, leaf := range leafs {
+			in, out := leaf.info.InMsgs, leaf.info.OutMsgs
+			if in+out < srvCheckLeafImbalanceMinMsgs {
+				continue
+			}
+
+			diff := in - out
+			if diff < 0 {
+				diff = -diff
+			}
+			imbalance := int(diff * 100 / max(in, out))
+			switch {
+			case c.leafImbalanceCrit > 0 && imbalance >= c.leafImbalanceCrit:
+				check.Critical("%s messages imbalance %d%% (%d in, %d out)", leaf, imbalance, in, out)
+			case c.leafImbalanceWarn > 0 && imbalance >= c.leafImbalanceWarn:
+				check.Warn("%s messages imbalance %d%% (%d in, %d out)", leaf, imbalance, in, out)
+			}
+		}
+	}
+
+	if c.leafReconnectsWarn > 0 || c.leafReconnectsCrit > 0 {
+		check.Pd(&monitor.PerfDataItem{Name: "reconnects", Value: float64(reconnects), Warn: float64(c.leafReconnectsWarn), Crit: float64(c.leafReconnectsCrit), Help: "Leafnode connections closed in the reconnect window"})
+
+		for _, leaf := range leafs {
+			switch {
+			case c.leafReconnectsCrit > 0 && leaf.reconnects >= c.leafReconnectsCrit:
+				check.Critical("%s reconnected %d times in %v", leaf, leaf.reconnects, c.leafReconnectWindow)
+			case c.leafReconnectsWarn > 0 && leaf.reconnects >= c.leafReconnectsWarn:
+				check.Warn("%s reconnected %d times in %v", leaf, leaf.reconnects, c.leafReconnectWindow)
+			}
+		}
+	}
+}
+
+func (l *srvCheckLeaf) String() string {
+	return fmt.Sprintf("%s (account %s) on %s", l.info.Name, l.info.Account, l.server)
+}
+
+// parseSrvCheckLeafExpectation parses an expected leafnode remote, either a server name or comma separated
+// name=, account= and cluster= pairs
+func parseSrvCheckLeafExpectation(expect string) (*srvCheckLeafExpectation, error) {
+	e := &srvCheckLeafExpectation{}
+	if !strings.Contains(expect, "=") {
+		e.name = strings.TrimSpace(expect)
+	} else {
+		for _, part := range strings.Split(expect, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+			if !ok || value == "" {
+				return nil, fmt.Errorf("invalid expected leafnode %q: %q is not a key=value pair", expect, part)
+			}
+
+			switch key {
+			case "name":
+				e.name = value
+			case "account":
+				e.account = value
+			case "cluster":
+				e.cluster = value
+			default:
+				return nil, fmt.Errorf("invalid expected leafnode %q: unknown key %q", expect, key)
+			}
+		}
+	}
+
+	if e.name == "" && e.account == "" && e.cluster == "" {
+		return nil, fmt.Errorf("invalid expected leafnode %q", expect)
+	}
+
+	return e, nil
+}
+
+func (e *srvCheckLeafExpectation) matches(leaf *srvCheckLeaf) bool {
+	return (e.name == "" || e.name == leaf.info.Name) &&
+		(e.account == "" || e.account == leaf.info.Account) &&
+		(e.cluster == "" || e.cluster == leaf.cluster)
+}
+
+func (e *srvCheckLeafExpectation) String() string {
+	var parts []string
+	if e.name != "" {
+		parts = append(parts, e.name)
+	}
+	if e.account != "" {
+		parts = append(parts, "in account "+e.account)
+	}
+	if e.cluster != "" {
+		parts = append(parts, "from cluster "+e.cluster)
+	}
+
+	return strings.Join(parts, " ")
+}
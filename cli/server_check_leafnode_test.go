@@ -0,0 +1,130 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/monitor"
+)
+
+func TestParseSrvCheckLeafExpectation(t *testing.T) {
+	e, err := parseSrvCheckLeafExpectation("edge1")
+	assertNoError(t, err)
+	if *e != (srvCheckLeafExpectation{name: "edge1"}) {
+		t.Fatalf("unexpected expectation: %+v", e)
+	}
+
+	e, err = parseSrvCheckLeafExpectation("account=APP, cluster=EDGE")
+	assertNoError(t, err)
+	if *e != (srvCheckLeafExpectation{account: "APP", cluster: "EDGE"}) {
+		t.Fatalf("unexpected expectation: %+v", e)
+	}
+	if e.String() != "in account APP from cluster EDGE" {
+		t.Fatalf("unexpected string: %s", e)
+	}
+
+	for _, invalid := range []string{"", "name=", "name=edge1,foo=bar", "name=edge1,account"} {
+		_, err = parseSrvCheckLeafExpectation(invalid)
+		if err == nil {
+			t.Fatalf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestCheckLeafs(t *testing.T) {
+	leafs := func() []*srvCheckLeaf {
+		return []*srvCheckLeaf{
+			{server: "hub1", cluster: "EDGE", info: &server.LeafInfo{Name: "edge1", Account: "APP", IP: "10.0.0.1", RTT: "10ms", InMsgs: 1000, OutMsgs: 1000}},
+			{server: "hub2", cluster: "EDGE", info: &server.LeafInfo{Name: "edge2", Account: "APP", IP: "10.0.0.2", RTT: "700ms", InMsgs: 100, OutMsgs: 2000}},
+		}
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		cmd := &SrvCheckCmd{leafCountCrit: 2}
+		check := &monitor.Result{}
+		cmd.checkLeafs(check, leafs()[:1], []*srvCheckLeafExpectation{{name: "edge1"}}, 0)
+		assertListEquals(t, check.Criticals, "1 leafnodes connected")
+
+		check = &monitor.Result{}
+		cmd.checkLeafs(check, leafs(), []*srvCheckLeafExpectation{{name: "edge1"}}, 0)
+		assertListIsEmpty(t, check.Criticals)
+		assertListIsEmpty(t, check.Warnings)
+		assertListEquals(t, check.OKs, "2 leafnodes connected")
+	})
+
+	t.Run("expected remotes", func(t *testing.T) {
+		cmd := &SrvCheckCmd{}
+		check := &monitor.Result{}
+		cmd.checkLeafs(check, leafs(), []*srvCheckLeafExpectation{
+			{name: "edge1", account: "APP", cluster: "EDGE"},
+			{name: "edge1", account: "SYS"},
+			{cluster: "OTHER"},
+		}, 0)
+		assertListEquals(t, check.Criticals, "leafnode edge1 in account SYS not connected", "leafnode from cluster OTHER not connected")
+	})
+
+	t.Run("rtt", func(t *testing.T) {
+		cmd := &SrvCheckCmd{leafRTTWarn: 500 * time.Millisecond, leafRTTCrit: time.Second}
+		check := &monitor.Result{}
+		cmd.checkLeafs(check, leafs(), nil, 0)
+		assertListIsEmpty(t, check.Criticals)
+		assertListEquals(t, check.Warnings, "edge2 (account APP) on hub2 rtt 700ms")
+		if check.PerfData[1].Name != "rtt" || check.PerfData[1].Value != 0.7 {
+			t.Fatalf("unexpected rtt perf data: %+v", check.PerfData[1])
+		}
+	})
+
+	t.Run("imbalance", func(t *testing.T) {
+		cmd := &SrvCheckCmd{leafImbalanceWarn: 50, leafImbalanceCrit: 90}
+		check := &monitor.Result{}
+		cmd.checkLeafs(check, leafs(), nil, 0)
+		assertListEquals(t, check.Criticals, "edge2 (account APP) on hub2 messages imbalance 95% (100 in, 2000 out)")
+		assertListIsEmpty(t, check.Warnings)
+	})
+
+	t.Run("reconnects", func(t *testing.T) {
+		now := time.Now()
+		stop := func(ago time.Duration) *time.Time {
+			ts := now.Add(-ago)
+			return &ts
+		}
+
+		cmd := &SrvCheckCmd{leafReconnectWindow: 10 * time.Minute, leafReconnectsWarn: 1, leafReconnectsCrit: 3}
+		l := leafs()
+		total := cmd.countLeafReconnects(l, map[string][]*server.ConnInfo{
+			"hub1": {
+				{Kind: "Leafnode", Account: "APP", IP: "10.0.0.1", Stop: stop(time.Minute)},
+				{Kind: "Leafnode", Account: "APP", IP: "10.0.0.1", Stop: stop(time.Hour)},
+				{Kind: "Client", Account: "APP", IP: "10.0.0.1", Stop: stop(time.Minute)},
+			},
+			"hub2": {
+				{Kind: "Leafnode", Account: "APP", IP: "10.0.0.2", Stop: stop(time.Minute)},
+				{Kind: "Leafnode", Account: "APP", IP: "10.0.0.2", Stop: stop(2 * time.Minute)},
+				{Kind: "Leafnode", Account: "APP", IP: "10.0.0.2", Stop: stop(3 * time.Minute)},
+				{Kind: "Leafnode", IP: "10.0.0.3", Stop: stop(time.Minute)},
+			},
+		}, now)
+		if total != 5 {
+			t.Fatalf("expected 5 reconnects, got %d", total)
+		}
+
+		check := &monitor.Result{}
+		cmd.checkLeafs(check, l, nil, total)
+		assertListEquals(t, check.Criticals, "edge2 (account APP) on hub2 reconnected 3 times in 10m0s")
+		assertListEquals(t, check.Warnings, "edge1 (account APP) on hub1 reconnected 1 times in 10m0s")
+	})
+}
@@ -0,0 +1,173 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/natscli/monitor"
+)
+
+type srvCheckServeCmd struct {
+	configFile string
+	listen     string
+	interval   time.Duration
+
+	name    string
+	entries []*srvCheckBundleEntry
+
+	mu      sync.Mutex
+	metrics string
+	status  monitor.Status
+}
+
+func configureServerCheckServeCommand(check *fisk.CmdClause) {
+	c := &srvCheckServeCmd{}
+
+	serve := check.Command("serve", "Runs the checks of a bundle on an interval, serving the results as Prometheus metrics").Action(c.serveAction)
+	serve.Flag("config", "YAML file listing the checks to run and their options").Required().PlaceHolder("FILE").ExistingFileVar(&c.configFile)
+	serve.Flag("listen", "Address to serve /metrics and /healthz on").Default(":9100").PlaceHolder("ADDRESS").StringVar(&c.listen)
+	serve.Flag("interval", "How often to run the checks").Default("1m").PlaceHolder("DURATION").DurationVar(&c.interval)
+}
+
+func (c *srvCheckServeCmd) serveAction(_ *fisk.ParseContext) error {
+	if c.interval <= 0 {
+		return fmt.Errorf("interval must be greater than 0")
+	}
+
+	var err error
+	c.name, c.entries, err = loadSrvCheckBundle(c.configFile)
+	if err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", c.listen)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	c.runChecks()
+
+	srv := &http.Server{Handler: c.handler(), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	go func() {
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.runChecks()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	fmt.Printf("Serving %d checks on http://%s/metrics, running every %v\n", len(c.entries), listener.Addr(), c.interval)
+
+	err = srv.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+
+	return err
+}
+
+func (c *srvCheckServeCmd) handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		c.mu.Lock()
+		metrics := c.metrics
+		c.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		fmt.Fprint(w, metrics)
+	})
+
+	// healthz reports the aggregate status, failing when any check is critical
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		c.mu.Lock()
+		status := c.status
+		c.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if status != monitor.OKStatus && status != monitor.WarningStatus {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		fmt.Fprintln(w, status)
+	})
+
+	return mux
+}
+
+// runChecks runs all checks of the bundle and renders the results, only one run happens at a time
+func (c *srvCheckServeCmd) runChecks() {
+	// start over with a new connection once the current one gave up reconnecting
+	resetSrvCheckServeConn(false)
+
+	check := &monitor.Result{Name: "Check Bundle", Check: "bundle", NameSpace: opts.PrometheusNamespace, RenderFormat: monitor.PrometheusFormat}
+	if c.name != "" {
+		check.Name = c.name
+	}
+
+	for _, entry := range c.entries {
+		// the connection check measures the time taken to connect, the connection kept from previous runs would
+		// report a stale connect time
+		if entry.kind.name == "connection" {
+			resetSrvCheckServeConn(true)
+		}
+		mergeSrvCheckBundleResult(check, entry.name, entry.run())
+	}
+	metrics := check.String()
+
+	c.mu.Lock()
+	c.metrics = metrics
+	c.status = check.Status
+	c.mu.Unlock()
+}
+
+// resetSrvCheckServeConn discards the shared connection when it is closed, or always when forced, so that the next
+// check connects again
+func resetSrvCheckServeConn(force bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if opts.Conn == nil || !(force || opts.Conn.IsClosed()) {
+		return
+	}
+
+	opts.Conn.Close()
+	opts.Conn = nil
+	opts.Mgr = nil
+}
@@ -0,0 +1,148 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nats-io/jsm.go"
+	"github.com/nats-io/jwt/v2"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nkeys"
+)
+
+// testCredsFile writes a user credential expiring at the given time
+func testCredsFile(t *testing.T, expires time.Time) string {
+	t.Helper()
+
+	akp, err := nkeys.CreateAccount()
+	checkErr(t, err, "account key failed: %v", err)
+	ukp, err := nkeys.CreateUser()
+	checkErr(t, err, "user key failed: %v", err)
+	upk, err := ukp.PublicKey()
+	checkErr(t, err, "user public key failed: %v", err)
+	seed, err := ukp.Seed()
+	checkErr(t, err, "user seed failed: %v", err)
+
+	claims := jwt.NewUserClaims(upk)
+	claims.Expires = expires.Unix()
+	token, err := claims.Encode(akp)
+	checkErr(t, err, "user jwt failed: %v", err)
+
+	creds, err := jwt.FormatUserConfig(token, seed)
+	checkErr(t, err, "creds failed: %v", err)
+
+	path := filepath.Join(t.TempDir(), "user.creds")
+	err = os.WriteFile(path, creds, 0600)
+	checkErr(t, err, "write failed: %v", err)
+
+	return path
+}
+
+func TestSrvCheckServe(t *testing.T) {
+	get := func(t *testing.T, srv *httptest.Server, path string) (int, string) {
+		t.Helper()
+
+		resp, err := http.Get(srv.URL + path)
+		checkErr(t, err, "request failed: %v", err)
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		checkErr(t, err, "read failed: %v", err)
+
+		return resp.StatusCode, string(body)
+	}
+
+	newCmd := func(t *testing.T, options map[string]any) *srvCheckServeCmd {
+		t.Helper()
+
+		entry, err := newSrvCheckBundleEntry(srvCheckBundleEntryConfig{Name: "user creds", Check: "credential", Options: options})
+		checkErr(t, err, "invalid entry: %v", err)
+
+		return &srvCheckServeCmd{name: "test", entries: []*srvCheckBundleEntry{entry}}
+	}
+
+	t.Run("Before first run", func(t *testing.T) {
+		srv := httptest.NewServer((&srvCheckServeCmd{}).handler())
+		defer srv.Close()
+
+		code, body := get(t, srv, "/healthz")
+		if code != http.StatusServiceUnavailable || body != "\n" {
+			t.Fatalf("unexpected health: %d %q", code, body)
+		}
+	})
+
+	t.Run("Critical", func(t *testing.T) {
+		c := newCmd(t, map[string]any{"credential": "/nonexistent"})
+		c.runChecks()
+
+		srv := httptest.NewServer(c.handler())
+		defer srv.Close()
+
+		code, body := get(t, srv, "/healthz")
+		if code != http.StatusServiceUnavailable || body != "CRITICAL\n" {
+			t.Fatalf("unexpected health: %d %q", code, body)
+		}
+
+		code, body = get(t, srv, "/metrics")
+		if code != http.StatusOK {
+			t.Fatalf("unexpected metrics status: %d", code)
+		}
+		if !strings.Contains(body, `bundle_status_code{item="test",status="CRITICAL"} 2`) {
+			t.Fatalf("status code metric not found in:\n%s", body)
+		}
+	})
+
+	t.Run("Warning", func(t *testing.T) {
+		c := newCmd(t, map[string]any{"credential": testCredsFile(t, time.Now().Add(time.Hour)), "validity-warn": "2h"})
+		c.runChecks()
+
+		srv := httptest.NewServer(c.handler())
+		defer srv.Close()
+
+		code, body := get(t, srv, "/healthz")
+		if code != http.StatusOK || body != "WARNING\n" {
+			t.Fatalf("unexpected health: %d %q", code, body)
+		}
+
+		_, body = get(t, srv, "/metrics")
+		if !strings.Contains(body, `bundle_user_creds_expiry{item="test"}`) {
+			t.Fatalf("expiry metric not found in:\n%s", body)
+		}
+	})
+}
+
+func TestResetSrvCheckServeConn(t *testing.T) {
+	withJetStream(t, func(_ *server.Server, nc *nats.Conn, _ *jsm.Manager) {
+		defer func() { opts.Conn, opts.Mgr = nil, nil }()
+
+		resetSrvCheckServeConn(false)
+		if opts.Conn != nc || nc.IsClosed() {
+			t.Fatalf("expected the open connection to be kept")
+		}
+
+		resetSrvCheckServeConn(true)
+		if opts.Conn != nil || opts.Mgr != nil || !nc.IsClosed() {
+			t.Fatalf("expected the connection to be closed and discarded")
+		}
+	})
+}
@@ -0,0 +1,186 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/nats-io/natscli/monitor"
+)
+
+// srvCheckServiceEndpoint is the statistics of an endpoint, from all instances of a service
+type srvCheckServiceEndpoint struct {
+	requests       int
+	errors         int
+	processingTime time.Duration
+}
+
+func (c *SrvCheckCmd) serviceCheckFlags(svc *fisk.CmdClause) {
+	svc.Flag("name", "The name of the service to check").Required().StringVar(&c.svcName)
+	svc.Flag("instances-warn", "Warning threshold for the minimum number of responding instances").PlaceHolder("INSTANCES").IntVar(&c.svcInstancesWarn)
+	svc.Flag("instances-critical", "Critical threshold for the minimum number of responding instances").Default("1").PlaceHolder("INSTANCES").IntVar(&c.svcInstancesCrit)
+	svc.Flag("rtt-warn", "Warning threshold for instance ping RTT").Default("500ms").PlaceHolder("DURATION").DurationVar(&c.svcRTTWarn)
+	svc.Flag("rtt-critical", "Critical threshold for instance ping RTT").Default("1s").PlaceHolder("DURATION").DurationVar(&c.svcRTTCrit)
+	svc.Flag("errors-warn", "Warning threshold for the number of errors of an endpoint").PlaceHolder("ERRORS").IntVar(&c.svcErrorsWarn)
+	svc.Flag("errors-critical", "Critical threshold for the number of errors of an endpoint").PlaceHolder("ERRORS").IntVar(&c.svcErrorsCrit)
+	svc.Flag("error-rate-warn", "Warning threshold for the percentage of requests to an endpoint that failed").PlaceHolder("PERCENT").Float64Var(&c.svcErrorRateWarn)
+	svc.Flag("error-rate-critical", "Critical threshold for the percentage of requests to an endpoint that failed").PlaceHolder("PERCENT").Float64Var(&c.svcErrorRateCrit)
+	svc.Flag("processing-time-warn", "Warning threshold for the average processing time of an endpoint").PlaceHolder("DURATION").DurationVar(&c.svcProcessingWarn)
+	svc.Flag("processing-time-critical", "Critical threshold for the average processing time of an endpoint").PlaceHolder("DURATION").DurationVar(&c.svcProcessingCrit)
+}
+
+func (c *SrvCheckCmd) checkService(check *monitor.Result) error {
+	nc, _, err := prepareHelper("", natsOpts()...)
+	if err != nil {
+		return fmt.Errorf("connection failed: %s", err)
+	}
+
+	svc := &serviceCmd{}
+	pings := make(map[string]time.Duration)
+	mu := sync.Mutex{}
+	start := time.Now()
+	err = doReqAsync(nil, svc.makeSubj(micro.PingVerb, c.svcName, ""), 0, nc, func(data []byte) {
+		rtt := time.Since(start)
+
+		resp, err := svc.parseMessage(data, micro.PingResponseType)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		pings[resp.(*micro.Ping).ID] = rtt
+		mu.Unlock()
+	})
+	if err != nil && !errors.Is(err, nats.ErrNoResponders) {
+		return fmt.Errorf("ping failed: %s", err)
+	}
+
+	// late responses may still be delivered to the handler, the check uses the responses received so far
+	mu.Lock()
+	rtts := make(map[string]time.Duration, len(pings))
+	for id, rtt := range pings {
+		rtts[id] = rtt
+	}
+	mu.Unlock()
+
+	var stats []*micro.Stats
+	if len(rtts) > 0 {
+		resp, err := doReq(nil, svc.makeSubj(micro.StatsVerb, c.svcName, ""), len(rtts), nc)
+		if err != nil {
+			return fmt.Errorf("statistics request failed: %s", err)
+		}
+
+		for _, r := range resp {
+			s, err := svc.parseMessage(r, micro.StatsResponseType)
+			if err != nil {
+				return fmt.Errorf("invalid statistics response: %s", err)
+			}
+			stats = append(stats, s.(*micro.Stats))
+		}
+	}
+
+	c.checkServiceInstances(check, rtts, stats)
+
+	return nil
+}
+
+// checkServiceInstances checks the ping RTT of each instance, keyed by instance ID, and the statistics of each
+// endpoint across all instances
+func (c *SrvCheckCmd) checkServiceInstances(check *monitor.Result, rtts map[string]time.Duration, stats []*micro.Stats) {
+	check.Pd(&monitor.PerfDataItem{Name: "instances", Value: float64(len(rtts)), Warn: float64(c.svcInstancesWarn), Crit: float64(c.svcInstancesCrit), Help: "Number of responding service instances"})
+
+	switch {
+	case c.svcInstancesCrit > 0 && len(rtts) < c.svcInstancesCrit:
+		check.Critical("%d instances responding", len(rtts))
+	case c.svcInstancesWarn > 0 && len(rtts) < c.svcInstancesWarn:
+		check.Warn("%d instances responding", len(rtts))
+	default:
+		check.Ok("%d instances responding", len(rtts))
+	}
+
+	var maxRTT time.Duration
+	for _, id := range sortedMapKeys(rtts) {
+		rtt := rtts[id]
+		maxRTT = max(maxRTT, rtt)
+
+		switch {
+		case c.svcRTTCrit > 0 && rtt >= c.svcRTTCrit:
+			check.Critical("instance %s rtt %v", id, rtt)
+		case c.svcRTTWarn > 0 && rtt >= c.svcRTTWarn:
+			check.Warn("instance %s rtt %v", id, rtt)
+		}
+	}
+	check.Pd(&monitor.PerfDataItem{Name: "rtt", Value: maxRTT.Seconds(), Warn: c.svcRTTWarn.Seconds(), Crit: c.svcRTTCrit.Seconds(), Unit: "s", Help: "The highest ping round-trip-time of a service instance"})
+
+	endpoints := make(map[string]*srvCheckServiceEndpoint)
+	for _, s := range stats {
+		for _, e := range s.Endpoints {
+			ep, ok := endpoints[e.Name]
+			if !ok {
+				ep = &srvCheckServiceEndpoint{}
+				endpoints[e.Name] = ep
+			}
+			ep.requests += e.NumRequests
+			ep.errors += e.NumErrors
+			ep.processingTime += e.ProcessingTime
+		}
+	}
+
+	names := sortedMapKeys(endpoints)
+	metricNames := srvCheckMetricNames(names)
+	for _, name := range names {
+		ep := endpoints[name]
+
+		var rate float64
+		var avg time.Duration
+		if ep.requests > 0 {
+			rate = float64(ep.errors) * 100 / float64(ep.requests)
+			avg = ep.processingTime / time.Duration(ep.requests)
+		}
+
+		switch {
+		case c.svcErrorsCrit > 0 && ep.errors >= c.svcErrorsCrit:
+			check.Critical("endpoint %s has %d errors", name, ep.errors)
+		case c.svcErrorsWarn > 0 && ep.errors >= c.svcErrorsWarn:
+			check.Warn("endpoint %s has %d errors", name, ep.errors)
+		}
+
+		switch {
+		case c.svcErrorRateCrit > 0 && rate >= c.svcErrorRateCrit:
+			check.Critical("endpoint %s error rate %.1f%%", name, rate)
+		case c.svcErrorRateWarn > 0 && rate >= c.svcErrorRateWarn:
+			check.Warn("endpoint %s error rate %.1f%%", name, rate)
+		}
+
+		switch {
+		case c.svcProcessingCrit > 0 && avg >= c.svcProcessingCrit:
+			check.Critical("endpoint %s average processing time %v", name, avg)
+		case c.svcProcessingWarn > 0 && avg >= c.svcProcessingWarn:
+			check.Warn("endpoint %s average processing time %v", name, avg)
+		}
+
+		prefix := metricNames[name]
+		check.Pd(
+			&monitor.PerfDataItem{Name: prefix + "_requests", Value: float64(ep.requests), Help: fmt.Sprintf("Requests handled by endpoint %s", name)},
+			&monitor.PerfDataItem{Name: prefix + "_errors", Value: float64(ep.errors), Warn: float64(c.svcErrorsWarn), Crit: float64(c.svcErrorsCrit), Help: fmt.Sprintf("Errors returned by endpoint %s", name)},
+			&monitor.PerfDataItem{Name: prefix + "_error_rate", Value: rate, Warn: c.svcErrorRateWarn, Crit: c.svcErrorRateCrit, Unit: "%", Help: fmt.Sprintf("Percentage of requests to endpoint %s that failed", name)},
+			&monitor.PerfDataItem{Name: prefix + "_processing_time", Value: avg.Seconds(), Warn: c.svcProcessingWarn.Seconds(), Crit: c.svcProcessingCrit.Seconds(), Unit: "s", Help: fmt.Sprintf("Average processing time of endpoint %s", name)},
+		)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nats-io/jsm.go"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/micro"
+	"github.com/nats-io/natscli/monitor"
+)
+
+func TestCheckServiceInstances(t *testing.T) {
+	stats := func() []*micro.Stats {
+		return []*micro.Stats{
+			{ServiceIdentity: micro.ServiceIdentity{ID: "1"}, Endpoints: []*micro.EndpointStats{
+				{Name: "get", NumRequests: 100, NumErrors: 1, ProcessingTime: 100 * time.Millisecond},
+				{Name: "put", NumRequests: 10, NumErrors: 5, ProcessingTime: time.Second},
+			}},
+			{ServiceIdentity: micro.ServiceIdentity{ID: "2"}, Endpoints: []*micro.EndpointStats{
+				{Name: "get", NumRequests: 100, NumErrors: 1, ProcessingTime: 300 * time.Millisecond},
+				{Name: "put", NumRequests: 10},
+			}},
+		}
+	}
+	rtts := map[string]time.Duration{"1": time.Millisecond, "2": 700 * time.Millisecond}
+
+	t.Run("instances", func(t *testing.T) {
+		cmd := &SrvCheckCmd{svcInstancesCrit: 1}
+		check := &monitor.Result{}
+		cmd.checkServiceInstances(check, nil, nil)
+		assertListEquals(t, check.Criticals, "0 instances responding")
+
+		cmd = &SrvCheckCmd{svcInstancesWarn: 3, svcInstancesCrit: 1}
+		check = &monitor.Result{}
+		cmd.checkServiceInstances(check, rtts, stats())
+		assertListIsEmpty(t, check.Criticals)
+		assertListEquals(t, check.Warnings, "2 instances responding")
+	})
+
+	t.Run("rtt", func(t *testing.T) {
+		cmd := &SrvCheckCmd{svcRTTWarn: 500 * time.Millisecond, svcRTTCrit: time.Second}
+		check := &monitor.Result{}
+		cmd.checkServiceInstances(check, rtts, stats())
+		assertListIsEmpty(t, check.Criticals)
+		assertListEquals(t, check.Warnings, "instance 2 rtt 700ms")
+		assertListEquals(t, check.OKs, "2 instances responding")
+		if check.PerfData[1].Name != "rtt" || check.PerfData[1].Value != 0.7 {
+			t.Fatalf("unexpected rtt perf data: %+v", check.PerfData[1])
+		}
+	})
+
+	t.Run("endpoints", func(t *testing.T) {
+		cmd := &SrvCheckCmd{svcErrorsWarn: 2, svcErrorsCrit: 10, svcErrorRateWarn: 1, svcErrorRateCrit: 20, svcProcessingWarn: 10 * time.Millisecond, svcProcessingCrit: 100 * time.Millisecond}
+		check := &monitor.Result{}
+		cmd.checkServiceInstances(check, rtts, stats())
+		assertListEquals(t, check.Criticals, "endpoint put error rate 25.0%")
+		assertListEquals(t, check.Warnings,
+			"endpoint get has 2 errors",
+			"endpoint get error rate 1.0%",
+			"endpoint put has 5 errors",
+			"endpoint put average processing time 50ms",
+		)
+
+		names := make([]string, 0, len(check.PerfData))
+		for _, pd := range check.PerfData {
+			names = append(names, pd.Name)
+		}
+		assertListEquals(t, names, "instances", "rtt",
+			"get_requests", "get_errors", "get_error_rate", "get_processing_time",
+			"put_requests", "put_errors", "put_error_rate", "put_processing_time",
+		)
+		if check.PerfData[5].Value != 0.002 {
+			t.Fatalf("unexpected get processing time: %v", check.PerfData[5].Value)
+		}
+	})
+
+	t.Run("colliding metric names", func(t *testing.T) {
+		cmd := &SrvCheckCmd{}
+		check := &monitor.Result{RenderFormat: monitor.PrometheusFormat}
+		cmd.checkServiceInstances(check, rtts, []*micro.Stats{
+			{ServiceIdentity: micro.ServiceIdentity{ID: "1"}, Endpoints: []*micro.EndpointStats{{Name: "a-b"}, {Name: "a.b"}}},
+		})
+
+		names := make([]string, 0, len(check.PerfData))
+		for _, pd := range check.PerfData {
+			names = append(names, pd.Name)
+		}
+		assertListEquals(t, names, "instances", "rtt",
+			"a_b_requests", "a_b_errors", "a_b_error_rate", "a_b_processing_time",
+			"a_b_2_requests", "a_b_2_errors", "a_b_2_error_rate", "a_b_2_processing_time",
+		)
+
+		// duplicate names make the prometheus registry panic
+		_ = check.String()
+	})
+}
+
+func TestCheckService(t *testing.T) {
+	withJetStream(t, func(_ *server.Server, nc *nats.Conn, _ *jsm.Manager) {
+		SetContext(context.Background())
+		timeout := opts.Timeout
+		opts.Timeout = time.Second
+		defer func() {
+			opts.Timeout = timeout
+			opts.Conn, opts.Mgr = nil, nil
+		}()
+
+		svc, err := micro.AddService(nc, micro.Config{Name: "echo", Version: "1.0.0"})
+		checkErr(t, err, "service failed: %v", err)
+		defer svc.Stop()
+
+		err = svc.AddEndpoint("echo", micro.HandlerFunc(func(req micro.Request) {
+			if len(req.Data()) == 0 {
+				req.Error("400", "empty request", nil)
+				return
+			}
+			req.Respond(req.Data())
+		}))
+		checkErr(t, err, "endpoint failed: %v", err)
+
+		for _, body := range []string{"hello", "world", ""} {
+			_, err = nc.Request("echo", []byte(body), time.Second)
+			checkErr(t, err, "request failed: %v", err)
+		}
+
+		cmd := &SrvCheckCmd{svcName: "echo", svcInstancesCrit: 1, svcErrorRateWarn: 30, svcErrorRateCrit: 50}
+		check := &monitor.Result{}
+		err = cmd.checkService(check)
+		checkErr(t, err, "check failed: %v", err)
+		assertListIsEmpty(t, check.Criticals)
+		assertListEquals(t, check.Warnings, "endpoint echo error rate 33.3%")
+		assertListEquals(t, check.OKs, "1 instances responding")
+
+		cmd.svcName = "missing"
+		check = &monitor.Result{}
+		err = cmd.checkService(check)
+		checkErr(t, err, "check failed: %v", err)
+		assertListEquals(t, check.Criticals, "0 instances responding")
+	})
+}
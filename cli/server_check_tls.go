@@ -0,0 +1,237 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/choria-io/fisk"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/monitor"
+)
+
+func (c *SrvCheckCmd) tlsCheckFlags(t *fisk.CmdClause) {
+	t.Flag("target", "Server to check as host:port, defaults to all servers known to the connected cluster (repeatable)").PlaceHolder("HOST:PORT").StringsVar(&c.tlsTargets)
+	t.Flag("hostname", "Host name certificates must be valid for, defaults to the host of each server").PlaceHolder("NAME").StringVar(&c.tlsHostname)
+	t.Flag("expire-warn", "Warning threshold for time before certificate expiry").Default("3w").PlaceHolder("DURATION").DurationVar(&c.tlsExpireWarn)
+	t.Flag("expire-critical", "Critical threshold for time before certificate expiry").Default("1w").PlaceHolder("DURATION").DurationVar(&c.tlsExpireCrit)
+	t.Flag("min-rsa-bits", "Minimum size of RSA keys").Default("2048").PlaceHolder("BITS").IntVar(&c.tlsMinRSABits)
+	t.Flag("min-ecdsa-bits", "Minimum size of ECDSA keys").Default("256").PlaceHolder("BITS").IntVar(&c.tlsMinECDSABits)
+}
+
+func (c *SrvCheckCmd) checkTLS(check *monitor.Result) error {
+	targets := c.tlsTargets
+	if len(targets) == 0 {
+		nc, _, err := prepareHelper("", natsOpts()...)
+		if err != nil {
+			return fmt.Errorf("connection failed: %s", err)
+		}
+
+		seen := make(map[string]struct{})
+		for _, s := range nc.Servers() {
+			u, err := url.Parse(s)
+			if err != nil {
+				return fmt.Errorf("invalid server url %q: %s", s, err)
+			}
+			if _, ok := seen[u.Host]; ok {
+				continue
+			}
+			seen[u.Host] = struct{}{}
+			targets = append(targets, u.Host)
+		}
+	}
+
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		return fmt.Errorf("could not load system certificates: %s", err)
+	}
+	if opts.TlsCA != "" {
+		pem, err := os.ReadFile(opts.TlsCA)
+		if err != nil {
+			return fmt.Errorf("could not read CA: %s", err)
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA %s", opts.TlsCA)
+		}
+	}
+
+	var certs []tls.Certificate
+	if opts.TlsCert != "" && opts.TlsKey != "" {
+		cert, err := tls.LoadX509KeyPair(opts.TlsCert, opts.TlsKey)
+		if err != nil {
+			return fmt.Errorf("could not load client certificate: %s", err)
+		}
+		certs = append(certs, cert)
+	}
+
+	chains := make(map[string][]*x509.Certificate)
+	for _, target := range targets {
+		chain, err := c.fetchTLSChain(target, certs)
+		if err != nil {
+			check.Critical("%s: %s", target, err)
+			continue
+		}
+		chains[target] = chain
+	}
+
+	c.checkTLSChains(check, chains, roots, time.Now())
+
+	return nil
+}
+
+// fetchTLSChain connects to a server and returns the certificates it presents, without verifying them
+func (c *SrvCheckCmd) fetchTLSChain(target string, certs []tls.Certificate) ([]*x509.Certificate, error) {
+	host, _, err := net.SplitHostPort(target)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := net.DialTimeout("tcp", target, opts.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(opts.Timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	// unless the TLS handshake comes first, servers send INFO in plain text and the client then starts the handshake
+	if !opts.TlsFirst {
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("could not read server greeting: %s", err)
+		}
+
+		var info server.Info
+		js, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+		if !ok {
+			return nil, fmt.Errorf("unexpected server greeting")
+		}
+		err = json.Unmarshal([]byte(js), &info)
+		if err != nil {
+			return nil, fmt.Errorf("invalid server greeting: %s", err)
+		}
+		if !info.TLSRequired && !info.TLSAvailable {
+			return nil, fmt.Errorf("TLS is not enabled")
+		}
+	}
+
+	serverName := host
+	if c.tlsHostname != "" {
+		serverName = c.tlsHostname
+	}
+
+	// verification is done separately to report every problem with the chain
+	tc := tls.Client(conn, &tls.Config{ServerName: serverName, Certificates: certs, InsecureSkipVerify: true})
+	err = tc.Handshake()
+	if err != nil {
+		return nil, fmt.Errorf("TLS handshake failed: %s", err)
+	}
+
+	chain := tc.ConnectionState().PeerCertificates
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no certificates presented")
+	}
+
+	return chain, nil
+}
+
+// checkTLSChains checks the certificate chains presented by servers, keyed by host:port, for expiry, host name,
+// key sizes and trust
+func (c *SrvCheckCmd) checkTLSChains(check *monitor.Result, chains map[string][]*x509.Certificate, roots *x509.CertPool, now time.Time) {
+	check.Pd(&monitor.PerfDataItem{Name: "servers", Value: float64(len(chains)), Help: "Number of servers presenting TLS certificates"})
+
+	var soonest *x509.Certificate
+	for _, target := range sortedMapKeys(chains) {
+		chain := chains[target]
+		leaf := chain[0]
+
+		hostname := c.tlsHostname
+		if hostname == "" {
+			hostname, _, _ = net.SplitHostPort(target)
+		}
+		err := leaf.VerifyHostname(hostname)
+		if err != nil {
+			check.Critical("%s certificate is not valid for %s", target, hostname)
+		}
+
+		intermediates := x509.NewCertPool()
+		for _, cert := range chain[1:] {
+			intermediates.AddCert(cert)
+		}
+		_, err = leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, CurrentTime: now})
+		var invalid x509.CertificateInvalidError
+		if err != nil && !(errors.As(err, &invalid) && invalid.Reason == x509.Expired) {
+			check.Critical("%s certificate chain is not trusted: %s", target, err)
+		}
+
+		for _, cert := range chain {
+			subject := cert.Subject.String()
+
+			switch key := cert.PublicKey.(type) {
+			case *rsa.PublicKey:
+				if c.tlsMinRSABits > 0 && key.N.BitLen() < c.tlsMinRSABits {
+					check.Critical("%s certificate %q has a weak %d bit RSA key", target, subject, key.N.BitLen())
+				}
+			case *ecdsa.PublicKey:
+				if c.tlsMinECDSABits > 0 && key.Curve.Params().BitSize < c.tlsMinECDSABits {
+					check.Critical("%s certificate %q has a weak %d bit ECDSA key", target, subject, key.Curve.Params().BitSize)
+				}
+			}
+
+			until := cert.NotAfter.Sub(now)
+			switch {
+			case until <= 0:
+				check.Critical("%s certificate %q expired %s ago", target, subject, f(-until))
+			case c.tlsExpireCrit > 0 && until < c.tlsExpireCrit:
+				check.Critical("%s certificate %q expires in %s", target, subject, f(until))
+			case c.tlsExpireWarn > 0 && until < c.tlsExpireWarn:
+				check.Warn("%s certificate %q expires in %s", target, subject, f(until))
+			}
+
+			if soonest == nil || cert.NotAfter.Before(soonest.NotAfter) {
+				soonest = cert
+			}
+		}
+	}
+
+	if soonest == nil {
+		if len(check.Criticals) == 0 {
+			check.Critical("no servers to check")
+		}
+		return
+	}
+
+	until := soonest.NotAfter.Sub(now)
+	check.Pd(&monitor.PerfDataItem{Name: "expiry", Value: until.Seconds(), Warn: c.tlsExpireWarn.Seconds(), Crit: c.tlsExpireCrit.Seconds(), Unit: "s", Help: "Time in seconds before the first certificate expires"})
+
+	if len(check.Criticals) == 0 {
+		check.Ok("%d servers, first certificate expires in %s", len(chains), f(until))
+	}
+}
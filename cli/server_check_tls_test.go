@@ -0,0 +1,212 @@
+// Copyright 2024 The NATS Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/natscli/monitor"
+)
+
+type testTLSCert struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+// testTLSCertificate creates a CA certificate signed by parent, or self signed without a parent, certificates
+// named localhost are server certificates instead
+func testTLSCertificate(t *testing.T, name string, parent *testTLSCert, key crypto.Signer, notBefore time.Time, notAfter time.Time) *testTLSCert {
+	t.Helper()
+
+	if key == nil {
+		var err error
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		checkErr(t, err, "key failed: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		parent = &testTLSCert{cert: template, key: key}
+	} else if name != "localhost" {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		template.DNSNames = []string{"localhost"}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent.cert, key.Public(), parent.key)
+	checkErr(t, err, "certificate failed: %v", err)
+	cert, err := x509.ParseCertificate(der)
+	checkErr(t, err, "parse failed: %v", err)
+
+	return &testTLSCert{cert: cert, key: key}
+}
+
+func TestCheckTLSChains(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	yearAgo := now.AddDate(-1, 0, 0)
+	ca := testTLSCertificate(t, "Test CA", nil, nil, yearAgo, now.AddDate(10, 0, 0))
+	intermediate := testTLSCertificate(t, "Test Intermediate", ca, nil, yearAgo, now.AddDate(5, 0, 0))
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	chain := func(expires time.Duration, key crypto.Signer) []*x509.Certificate {
+		leaf := testTLSCertificate(t, "localhost", intermediate, key, yearAgo, now.Add(expires))
+		return []*x509.Certificate{leaf.cert, intermediate.cert}
+	}
+	cmd := func() *SrvCheckCmd {
+		return &SrvCheckCmd{tlsExpireWarn: 21 * 24 * time.Hour, tlsExpireCrit: 7 * 24 * time.Hour, tlsMinRSABits: 2048, tlsMinECDSABits: 256}
+	}
+
+	t.Run("healthy", func(t *testing.T) {
+		check := &monitor.Result{}
+		cmd().checkTLSChains(check, map[string][]*x509.Certificate{
+			"localhost:4222": chain(90*24*time.Hour, nil),
+			"127.0.0.1:4222": chain(60*24*time.Hour, nil),
+		}, roots, now)
+		assertListIsEmpty(t, check.Criticals)
+		assertListIsEmpty(t, check.Warnings)
+		assertListEquals(t, check.OKs, "2 servers, first certificate expires in 60d0h0m0s")
+		if check.PerfData[1].Name != "expiry" || check.PerfData[1].Value != (60*24*time.Hour).Seconds() {
+			t.Fatalf("unexpected expiry perf data: %+v", check.PerfData[1])
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		check := &monitor.Result{}
+		cmd().checkTLSChains(check, map[string][]*x509.Certificate{
+			"localhost:4222": chain(10*24*time.Hour, nil),
+			"127.0.0.1:4222": chain(2*24*time.Hour, nil),
+			"127.0.0.1:4223": chain(-time.Hour, nil),
+		}, roots, now)
+		assertListEquals(t, check.Criticals,
+			`127.0.0.1:4222 certificate "CN=localhost" expires in 2d0h0m0s`,
+			`127.0.0.1:4223 certificate "CN=localhost" expired 1h0m0s ago`,
+		)
+		assertListEquals(t, check.Warnings, `localhost:4222 certificate "CN=localhost" expires in 10d0h0m0s`)
+		if check.PerfData[1].Value != -time.Hour.Seconds() {
+			t.Fatalf("unexpected expiry perf data: %+v", check.PerfData[1])
+		}
+	})
+
+	t.Run("hostname", func(t *testing.T) {
+		check := &monitor.Result{}
+		cmd().checkTLSChains(check, map[string][]*x509.Certificate{"example.net:4222": chain(90*24*time.Hour, nil)}, roots, now)
+		assertListEquals(t, check.Criticals, "example.net:4222 certificate is not valid for example.net")
+
+		c := cmd()
+		c.tlsHostname = "localhost"
+		check = &monitor.Result{}
+		c.checkTLSChains(check, map[string][]*x509.Certificate{"example.net:4222": chain(90*24*time.Hour, nil)}, roots, now)
+		assertListIsEmpty(t, check.Criticals)
+	})
+
+	t.Run("untrusted", func(t *testing.T) {
+		check := &monitor.Result{}
+		cmd().checkTLSChains(check, map[string][]*x509.Certificate{"localhost:4222": chain(90*24*time.Hour, nil)[:1]}, roots, now)
+		if len(check.Criticals) != 1 {
+			t.Fatalf("expected untrusted critical, got %v", check.Criticals)
+		}
+
+		check = &monitor.Result{}
+		cmd().checkTLSChains(check, map[string][]*x509.Certificate{"localhost:4222": chain(90*24*time.Hour, nil)}, x509.NewCertPool(), now)
+		if len(check.Criticals) != 1 {
+			t.Fatalf("expected untrusted critical, got %v", check.Criticals)
+		}
+	})
+
+	t.Run("weak keys", func(t *testing.T) {
+		key, err := rsa.GenerateKey(rand.Reader, 1024)
+		checkErr(t, err, "key failed: %v", err)
+
+		check := &monitor.Result{}
+		cmd().checkTLSChains(check, map[string][]*x509.Certificate{"localhost:4222": chain(90*24*time.Hour, key)}, roots, now)
+		assertListEquals(t, check.Criticals, `localhost:4222 certificate "CN=localhost" has a weak 1024 bit RSA key`)
+	})
+
+	t.Run("no servers", func(t *testing.T) {
+		check := &monitor.Result{}
+		cmd().checkTLSChains(check, nil, roots, now)
+		assertListEquals(t, check.Criticals, "no servers to check")
+	})
+}
+
+func TestCheckTLS(t *testing.T) {
+	now := time.Now()
+	ca := testTLSCertificate(t, "Test CA", nil, nil, now.Add(-time.Hour), now.AddDate(1, 0, 0))
+	leaf := testTLSCertificate(t, "localhost", ca, nil, now.Add(-time.Hour), now.AddDate(0, 0, 10))
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}), 0600)
+	checkErr(t, err, "write failed: %v", err)
+
+	start := func(tlsc *tls.Config) *server.Server {
+		srv, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoSigs: true, TLS: tlsc != nil, TLSConfig: tlsc})
+		checkErr(t, err, "could not start server: %v", err)
+		go srv.Start()
+		if !srv.ReadyForConnections(10 * time.Second) {
+			t.Fatalf("nats server did not start")
+		}
+		t.Cleanup(srv.Shutdown)
+		return srv
+	}
+
+	tlsSrv := start(&tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{leaf.cert.Raw}, PrivateKey: leaf.key}}})
+	plainSrv := start(nil)
+
+	timeout, tlsCA := opts.Timeout, opts.TlsCA
+	opts.Timeout, opts.TlsCA = time.Second, caFile
+	defer func() { opts.Timeout, opts.TlsCA = timeout, tlsCA }()
+
+	tlsTarget := fmt.Sprintf("127.0.0.1:%d", tlsSrv.Addr().(*net.TCPAddr).Port)
+	plainTarget := fmt.Sprintf("127.0.0.1:%d", plainSrv.Addr().(*net.TCPAddr).Port)
+
+	cmd := &SrvCheckCmd{tlsTargets: []string{tlsTarget, plainTarget}, tlsExpireWarn: 21 * 24 * time.Hour, tlsExpireCrit: 7 * 24 * time.Hour}
+	check := &monitor.Result{}
+	err = cmd.checkTLS(check)
+	checkErr(t, err, "check failed: %v", err)
+	assertListEquals(t, check.Criticals, plainTarget+": TLS is not enabled")
+	if len(check.Warnings) != 1 {
+		t.Fatalf("expected an expiry warning, got %v", check.Warnings)
+	}
+	if check.PerfData[0].Name != "servers" || check.PerfData[0].Value != 1 {
+		t.Fatalf("unexpected servers perf data: %+v", check.PerfData[0])
+	}
+}
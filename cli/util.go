@@ -31,6 +31,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -857,6 +858,11 @@ func renderCluster(cluster *api.ClusterInfo) string {
 }
 
 func doReqAsync(req any, subj string, waitFor int, nc *nats.Conn, cb func([]byte)) error {
+	return doReqAsyncWithTimeout(req, subj, waitFor, opts.Timeout, nc, cb)
+}
+
+// doReqAsyncWithTimeout is like doReqAsync but waits for responses up to the given timeout rather than the global one
+func doReqAsyncWithTimeout(req any, subj string, waitFor int, timeout time.Duration, nc *nats.Conn, cb func([]byte)) error {
 	jreq := []byte("{}")
 	var err error
 
@@ -882,11 +888,11 @@ func doReqAsync(req any, subj string, waitFor int, nc *nats.Conn, cb func([]byte
 		finisher *time.Timer
 	)
 
-	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
 	if waitFor == 0 {
-		finisher = time.NewTimer(opts.Timeout)
+		finisher = time.NewTimer(timeout)
 		go func() {
 			select {
 			case <-finisher.C:
@@ -981,10 +987,15 @@ func doReqAsync(req any, subj string, waitFor int, nc *nats.Conn, cb func([]byte
 }
 
 func doReq(req any, subj string, waitFor int, nc *nats.Conn) ([][]byte, error) {
+	return doReqWithTimeout(req, subj, waitFor, opts.Timeout, nc)
+}
+
+// doReqWithTimeout is like doReq but waits for responses up to the given timeout rather than the global one
+func doReqWithTimeout(req any, subj string, waitFor int, timeout time.Duration, nc *nats.Conn) ([][]byte, error) {
 	res := [][]byte{}
 	mu := sync.Mutex{}
 
-	err := doReqAsync(req, subj, waitFor, nc, func(r []byte) {
+	err := doReqAsyncWithTimeout(req, subj, waitFor, timeout, nc, func(r []byte) {
 		mu.Lock()
 		res = append(res, r)
 		mu.Unlock()
@@ -1585,6 +1596,32 @@ func mapKeys[M ~map[K]V, K comparable, V any](m M) []K {
 	return r
 }
 
+// sortedMapKeys returns the sorted keys present in any of the maps, without duplicates
+func sortedMapKeys[M ~map[K]V, K constraints.Ordered, V any](maps ...M) []K {
+	seen := make(map[K]struct{})
+	r := []K{}
+	for _, m := range maps {
+		for k := range m {
+			if _, ok := seen[k]; ok {
+				continue
+			}
+			seen[k] = struct{}{}
+			r = append(r, k)
+		}
+	}
+	slices.Sort(r)
+
+	return r
+}
+
+// sortedCopy returns a sorted copy of the list, leaving the list unchanged
+func sortedCopy[S ~[]E, E constraints.Ordered](s S) S {
+	r := slices.Clone(s)
+	slices.Sort(r)
+
+	return r
+}
+
 func readKeyFile(filename string) ([]byte, error) {
 	var key []byte
 	contents, err := os.ReadFile(filename)
@@ -262,3 +262,26 @@ func TestMultipleSort(t *testing.T) {
 		t.Fatalf("expected true")
 	}
 }
+
+func TestSortedMapKeys(t *testing.T) {
+	a := map[string]int{"x": 1, "y": 2, "z": 3}
+	b := map[string]int{"w": 0, "y": 0, "z": 0}
+
+	if keys := sortedMapKeys(a, b); !cmp.Equal(keys, []string{"w", "x", "y", "z"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	if keys := sortedMapKeys(map[string]int{}, b); !cmp.Equal(keys, []string{"w", "y", "z"}) {
+		t.Fatalf("unexpected keys: %v", keys)
+	}
+	assertListIsEmpty(t, sortedMapKeys(map[string]int{}, map[string]int{}))
+}
+
+func TestSortedCopy(t *testing.T) {
+	list := []string{"c", "a", "b"}
+	if sorted := sortedCopy(list); !cmp.Equal(sorted, []string{"a", "b", "c"}) {
+		t.Fatalf("unexpected sorted list: %v", sorted)
+	}
+	if !cmp.Equal(list, []string{"c", "a", "b"}) {
+		t.Fatalf("expected the list to be unchanged: %v", list)
+	}
+}